@@ -11,6 +11,8 @@ import (
 
 	"github.com/fabiano/entropy-tunnel/internal/api"
 	"github.com/fabiano/entropy-tunnel/internal/camouflage"
+	"github.com/fabiano/entropy-tunnel/internal/protocols"
+	"github.com/fabiano/entropy-tunnel/internal/pt"
 	"github.com/fabiano/entropy-tunnel/internal/tunnel"
 )
 
@@ -30,14 +32,14 @@ censorship and ISP blocks. Includes a local API for GUI integration.`,
 	}
 
 	var configPath string
-	var server, uuid, sni, fingerprint, publicKey, shortID, localListen, apiListen string
+	var server, uuid, sni, fingerprint, publicKey, shortID, localListen, apiListen, debugToken string
 	var sportsMode bool
 
 	connectCmd := &cobra.Command{
 		Use:   "connect",
 		Short: "Connect to an EntropyTunnel server",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runClient(configPath, server, uuid, sni, fingerprint, publicKey, shortID, localListen, apiListen, sportsMode)
+			return runClient(configPath, server, uuid, sni, fingerprint, publicKey, shortID, localListen, apiListen, debugToken, sportsMode)
 		},
 	}
 	connectCmd.Flags().StringVarP(&configPath, "config", "c", "", "Path to client config file")
@@ -49,6 +51,7 @@ censorship and ISP blocks. Includes a local API for GUI integration.`,
 	connectCmd.Flags().StringVar(&shortID, "short-id", "", "Reality short ID")
 	connectCmd.Flags().StringVar(&localListen, "local", "127.0.0.1:1080", "Local SOCKS5 listen address")
 	connectCmd.Flags().StringVar(&apiListen, "api", "127.0.0.1:9876", "Local API address for GUI")
+	connectCmd.Flags().StringVar(&debugToken, "debug-token", "", "Bearer token that enables /debug/ (config dump, health, pprof); disabled if empty")
 	connectCmd.Flags().BoolVar(&sportsMode, "sports-mode", false, "Enable low-latency sports streaming mode")
 
 	listFPCmd := &cobra.Command{
@@ -92,14 +95,26 @@ censorship and ISP blocks. Includes a local API for GUI integration.`,
 		},
 	}
 
-	rootCmd.AddCommand(connectCmd, listFPCmd, showConfigCmd, versionCmd)
+	ptClientCmd := &cobra.Command{
+		Use:   "pt-client",
+		Short: "Run as a Tor Pluggable Transport client (managed transport protocol)",
+		Long: `pt-client lets tor, Lantern, or a Snowflake-style broker launch
+entropy-client as a managed transport: it reads the TOR_PT_* environment
+variables, brings up a local SOCKS5 listener per configured protocol, and
+reports each one back via CMETHOD lines on stdout.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPTClient()
+		},
+	}
+
+	rootCmd.AddCommand(connectCmd, listFPCmd, showConfigCmd, ptClientCmd, versionCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
 }
 
-func runClient(configPath, server, uuid, sni, fingerprint, publicKey, shortID, localListen, apiListen string, sportsMode bool) error {
+func runClient(configPath, server, uuid, sni, fingerprint, publicKey, shortID, localListen, apiListen, debugToken string, sportsMode bool) error {
 	logger, err := zap.NewProduction()
 	if err != nil {
 		return fmt.Errorf("failed to create logger: %w", err)
@@ -161,6 +176,9 @@ func runClient(configPath, server, uuid, sni, fingerprint, publicKey, shortID, l
 
 	// Start local API server for GUI
 	apiSrv := api.NewServer(cfg.APIListen, engine, logger)
+	if debugToken != "" {
+		apiSrv.EnableDebug(debugToken, nil, nil)
+	}
 	if err := apiSrv.Start(); err != nil {
 		logger.Warn("failed to start API server", zap.Error(err))
 	} else {
@@ -178,3 +196,54 @@ func runClient(configPath, server, uuid, sni, fingerprint, publicKey, shortID, l
 	_ = engine.Stop()
 	return nil
 }
+
+// ptRegistry builds the protocol registry pt-client/pt-server expose as
+// managed-transport methods, one PT method per supported protocol.
+func ptRegistry() *protocols.Registry {
+	reg := protocols.NewRegistry()
+	_ = reg.RegisterAsPT("vless", protocols.NewVLESS())
+	_ = reg.RegisterAsPT("trojan", protocols.NewTrojan("/ws"))
+	return reg
+}
+
+// runPTClient implements the client side of the PT v2.1 managed-transport
+// handshake: it negotiates with tor over TOR_PT_* env vars, brings up a
+// local SOCKS5 listener per requested method, and reports each one with a
+// CMETHOD line so tor can route application traffic through it.
+func runPTClient() error {
+	reg := ptRegistry()
+
+	info, err := pt.ClientSetup(reg.PTMethodNames())
+	if err != nil {
+		return fmt.Errorf("pt-client: %w", err)
+	}
+
+	var listeners []*pt.SOCKS5Listener
+	for _, name := range info.MethodNames {
+		proto, err := reg.PTMethod(name)
+		if err != nil {
+			pt.CmethodError(name, err.Error())
+			continue
+		}
+
+		ln, err := pt.ListenSOCKS5("127.0.0.1:0", proto.DialContext)
+		if err != nil {
+			pt.CmethodError(name, err.Error())
+			continue
+		}
+
+		pt.Cmethod(name, "socks5", ln.Addr())
+		listeners = append(listeners, ln)
+		go ln.Serve()
+	}
+	pt.CmethodsDone()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	<-sigCh
+
+	for _, ln := range listeners {
+		_ = ln.Close()
+	}
+	return nil
+}
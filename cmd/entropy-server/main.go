@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
@@ -9,6 +12,8 @@ import (
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 
+	"github.com/fabiano/entropy-tunnel/internal/protocols"
+	"github.com/fabiano/entropy-tunnel/internal/pt"
 	"github.com/fabiano/entropy-tunnel/internal/tunnel"
 )
 
@@ -28,14 +33,16 @@ fingerprinting for traffic camouflage. It supports protocol fallbacks
 	}
 
 	var configPath string
+	var allowVulnerable bool
 	serveCmd := &cobra.Command{
 		Use:   "serve",
 		Short: "Start the tunnel server",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runServer(configPath)
+			return runServer(configPath, allowVulnerable)
 		},
 	}
 	serveCmd.Flags().StringVarP(&configPath, "config", "c", "configs/server-example.yaml", "Path to server config file")
+	serveCmd.Flags().BoolVar(&allowVulnerable, "allow-vulnerable", false, "Start even if the linked xray-core version has a known-exploitable CVE")
 
 	genConfigCmd := &cobra.Command{
 		Use:   "generate-config",
@@ -54,6 +61,19 @@ fingerprinting for traffic camouflage. It supports protocol fallbacks
 	}
 	showConfigCmd.Flags().StringVarP(&configPath, "config", "c", "configs/server-example.yaml", "Path to server config file")
 
+	var auditLive bool
+	var auditJSON bool
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Run DPI-fingerprint self-audit against a config (and optionally a live instance)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAudit(configPath, auditLive, auditJSON)
+		},
+	}
+	auditCmd.Flags().StringVarP(&configPath, "config", "c", "configs/server-example.yaml", "Path to server config file")
+	auditCmd.Flags().BoolVar(&auditLive, "live", false, "Also capture a live ClientHello by dialing the configured listen address on localhost")
+	auditCmd.Flags().BoolVar(&auditJSON, "json", false, "Emit the report as JSON instead of human-readable text")
+
 	versionCmd := &cobra.Command{
 		Use:   "version",
 		Short: "Print version information",
@@ -62,55 +82,54 @@ fingerprinting for traffic camouflage. It supports protocol fallbacks
 		},
 	}
 
-	rootCmd.AddCommand(serveCmd, genConfigCmd, showConfigCmd, versionCmd)
+	ptServerCmd := &cobra.Command{
+		Use:   "pt-server",
+		Short: "Run as a Tor Pluggable Transport server (managed transport protocol)",
+		Long: `pt-server lets tor launch entropy-server as a managed transport: it
+reads the TOR_PT_* environment variables, brings up a listener per
+requested bindaddr/protocol pair, and reports each one with an SMETHOD
+line. Accepted connections are de-obfuscated by the protocol and
+forwarded to TOR_PT_ORPORT.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPTServer()
+		},
+	}
+
+	rootCmd.AddCommand(serveCmd, genConfigCmd, showConfigCmd, auditCmd, ptServerCmd, versionCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
 }
 
-func runServer(configPath string) error {
-	logger, err := zap.NewProduction()
+func runServer(configPath string, allowVulnerable bool) error {
+	c, err := tunnel.Init(tunnel.InitOptions{ConfigPath: configPath, AllowVulnerable: allowVulnerable})
 	if err != nil {
-		return fmt.Errorf("failed to create logger: %w", err)
+		return fmt.Errorf("failed to initialize tunnel: %w", err)
 	}
-	defer logger.Sync()
-
-	logger.Info("loading configuration", zap.String("path", configPath))
+	defer c.Logger.Sync()
 
-	cfg, err := tunnel.LoadConfig(configPath)
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
-	}
-
-	engine, err := tunnel.NewEngine(cfg, logger)
-	if err != nil {
-		return fmt.Errorf("failed to create engine: %w", err)
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start tunnel: %w", err)
 	}
 
-	if err := engine.Start(); err != nil {
-		return fmt.Errorf("failed to start engine: %w", err)
-	}
-
-	logger.Info("entropy tunnel server is running",
-		zap.String("listen", cfg.Listen),
-		zap.String("protocol", cfg.Protocol),
-		zap.String("sni", cfg.Reality.SNI),
-		zap.String("fingerprint", cfg.Fingerprint),
+	c.Logger.Info("entropy tunnel server is running",
+		zap.String("listen", c.Config.Listen),
+		zap.String("protocol", c.Config.Protocol),
+		zap.String("sni", c.Config.Reality.SNI),
+		zap.String("fingerprint", c.Config.Fingerprint),
 		zap.String("version", version),
 	)
 
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-
 	sig := <-sigCh
-	logger.Info("received signal, shutting down", zap.String("signal", sig.String()))
+	c.Logger.Info("received signal, shutting down", zap.String("signal", sig.String()))
 
-	if err := engine.Stop(); err != nil {
-		logger.Error("error stopping engine", zap.Error(err))
+	if err := c.Stop(ctx); err != nil {
+		c.Logger.Error("error stopping tunnel", zap.Error(err))
 	}
-
-	logger.Info("server shutdown complete")
 	return nil
 }
 
@@ -127,6 +146,42 @@ func showConfig(configPath string) error {
 	return nil
 }
 
+func runAudit(configPath string, live, asJSON bool) error {
+	cfg, err := tunnel.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	listenAddr := ""
+	if live {
+		listenAddr, err = tunnel.LoopbackListenAddr(cfg.Listen)
+		if err != nil {
+			return fmt.Errorf("--live requires a dialable listen address: %w", err)
+		}
+	}
+
+	auditor := tunnel.NewAuditor(cfg, nil)
+	report, err := auditor.Run(context.Background(), listenAddr)
+	if err != nil {
+		return fmt.Errorf("audit failed: %w", err)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Print(report.String())
+	}
+
+	if !report.Passed() {
+		os.Exit(1)
+	}
+	return nil
+}
+
 func generateExampleConfig() error {
 	example := `# EntropyTunnel Server Configuration
 listen: ":443"
@@ -164,3 +219,58 @@ payment:
 	fmt.Print(example)
 	return nil
 }
+
+// ptRegistry builds the protocol registry pt-server exposes as
+// managed-transport methods, one PT method per supported protocol.
+func ptRegistry() *protocols.Registry {
+	reg := protocols.NewRegistry()
+	_ = reg.RegisterAsPT("vless", protocols.NewVLESS())
+	_ = reg.RegisterAsPT("trojan", protocols.NewTrojan("/ws"))
+	return reg
+}
+
+// runPTServer implements the server side of the PT v2.1 managed-transport
+// handshake: it negotiates with tor over TOR_PT_* env vars, brings up a
+// listener per requested bindaddr, and reports each one with an SMETHOD
+// line. Accepted connections are relayed to TOR_PT_ORPORT once the
+// protocol has de-obfuscated them.
+func runPTServer() error {
+	reg := ptRegistry()
+
+	info, err := pt.ServerSetup(reg.PTMethodNames())
+	if err != nil {
+		return fmt.Errorf("pt-server: %w", err)
+	}
+	if info.ORAddr == nil {
+		return fmt.Errorf("pt-server: TOR_PT_ORPORT is required")
+	}
+
+	var listeners []net.Listener
+	for _, bind := range info.Bindaddrs {
+		proto, err := reg.PTMethod(bind.MethodName)
+		if err != nil {
+			pt.SmethodError(bind.MethodName, err.Error())
+			continue
+		}
+
+		ln, err := proto.Listen(bind.Addr.String())
+		if err != nil {
+			pt.SmethodError(bind.MethodName, err.Error())
+			continue
+		}
+
+		pt.Smethod(bind.MethodName, ln.Addr(), bind.Options)
+		listeners = append(listeners, ln)
+		go pt.ServeToORPort(ln, info.ORAddr)
+	}
+	pt.SmethodsDone()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	<-sigCh
+
+	for _, ln := range listeners {
+		_ = ln.Close()
+	}
+	return nil
+}
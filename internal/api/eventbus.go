@@ -0,0 +1,98 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// Event is published on Server's live event stream (GET /api/events): a
+// status transition, a throughput sample, the fingerprint currently in
+// use, or a protocol fallback decision. It's deliberately simpler than
+// tunnel.BootstrapEvent (which tracks one-shot bootstrap progress); this
+// one is for the GUI's live dashboard, not a connection wizard.
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data,omitempty"`
+}
+
+// Known Event.Type values.
+const (
+	EventStatusChanged    = "status_changed"
+	EventThroughputSample = "throughput_sample"
+	EventFingerprintInUse = "fingerprint_in_use"
+	EventFallbackDecision = "fallback_decision"
+)
+
+// StatusChangedData is the Data payload for EventStatusChanged.
+type StatusChangedData struct {
+	Status string `json:"status"`
+}
+
+// ThroughputSampleData is the Data payload for EventThroughputSample: bytes
+// transferred since the previous sample, over Interval.
+type ThroughputSampleData struct {
+	BytesSent int64  `json:"bytes_sent"`
+	BytesRecv int64  `json:"bytes_recv"`
+	Interval  string `json:"interval"`
+}
+
+// FingerprintInUseData is the Data payload for EventFingerprintInUse.
+type FingerprintInUseData struct {
+	Fingerprint string `json:"fingerprint"`
+}
+
+// FallbackDecisionData is the Data payload for EventFallbackDecision.
+type FallbackDecisionData struct {
+	Protocol string `json:"protocol"`
+}
+
+// EventBus fans Event out to every /api/events subscriber. Like
+// tunnel.BootstrapEventBus, a subscriber that isn't keeping up has the
+// event dropped rather than blocking the publisher — a slow Electron
+// renderer shouldn't stall the throughput sampler or status updates for
+// everyone else.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers map[chan Event]struct{}
+}
+
+// NewEventBus creates an empty event bus.
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Publish fans ev out to every current subscriber.
+func (b *EventBus) Publish(eventType string, data any) {
+	ev := Event{Type: eventType, Time: time.Now(), Data: data}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Subscribe registers a new listener and returns the channel it will
+// receive events on, plus an unsubscribe func the caller must invoke when
+// done (e.g. when the SSE connection closes).
+func (b *EventBus) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 32)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		b.mu.Unlock()
+		close(ch)
+	}
+	return ch, unsubscribe
+}
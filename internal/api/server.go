@@ -10,9 +10,16 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/fabiano/entropy-tunnel/internal/events"
+	"github.com/fabiano/entropy-tunnel/internal/rotation"
 	"github.com/fabiano/entropy-tunnel/internal/tunnel"
 )
 
+// samplerInterval is how often the background sampler publishes a
+// throughput sample and checks for status/fingerprint changes on the
+// /api/events stream.
+const samplerInterval = 2 * time.Second
+
 // Server provides a local HTTP API for the GUI desktop client.
 type Server struct {
 	addr   string
@@ -27,6 +34,28 @@ type Server struct {
 	startTime  time.Time
 	bytesSent  int64
 	bytesRecv  int64
+
+	// events fans status transitions, throughput samples, fingerprint
+	// changes, and fallback decisions out to GET /api/events subscribers.
+	events      *EventBus
+	stopSampler chan struct{}
+
+	// Debug endpoint, enabled via EnableDebug.
+	debugToken   string
+	debugRotCtrl rotation.Controller
+	debugHealth  *rotation.HealthChecker
+}
+
+// EnableDebug mounts engine.DebugHandler under /debug/ behind a bearer
+// token, so an operator can curl config dumps, health results, and pprof
+// profiles without exposing them to anyone who can reach the API port.
+// rotationCtrl and health may be nil (most client deployments don't run a
+// rotation controller); the routes that need them just report 503. Must
+// be called before Start.
+func (s *Server) EnableDebug(token string, rotationCtrl rotation.Controller, health *rotation.HealthChecker) {
+	s.debugToken = token
+	s.debugRotCtrl = rotationCtrl
+	s.debugHealth = health
 }
 
 // NewServer creates a new API server for GUI integration.
@@ -38,6 +67,7 @@ func NewServer(addr string, engine *tunnel.Engine, logger *zap.Logger) *Server {
 		addr:   addr,
 		engine: engine,
 		logger: logger,
+		events: NewEventBus(),
 	}
 }
 
@@ -52,6 +82,15 @@ func (s *Server) Start() error {
 	mux.HandleFunc("POST /api/config", s.handleSetConfig)
 	mux.HandleFunc("POST /api/sports-mode", s.handleSportsMode)
 	mux.HandleFunc("GET /api/health", s.handleHealth)
+	mux.HandleFunc("GET /api/audit", s.handleAudit)
+	mux.HandleFunc("GET /api/events", s.handleAPIEvents)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	mux.HandleFunc("GET /status", s.handleBootstrapStatus)
+
+	if s.debugToken != "" {
+		mux.Handle("/debug/", debugAuthMiddleware(s.debugToken, s.engine.DebugHandler(s.debugRotCtrl, s.debugHealth)))
+		s.logger.Info("debug endpoint mounted at /debug/")
+	}
 
 	// CORS middleware for Electron
 	handler := corsMiddleware(mux)
@@ -68,11 +107,18 @@ func (s *Server) Start() error {
 		}
 	}()
 
+	s.stopSampler = make(chan struct{})
+	go s.runSampler(s.stopSampler)
+
 	return nil
 }
 
 // Stop shuts down the API server.
 func (s *Server) Stop() error {
+	if s.stopSampler != nil {
+		close(s.stopSampler)
+		s.stopSampler = nil
+	}
 	if s.server != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
@@ -81,6 +127,73 @@ func (s *Server) Stop() error {
 	return nil
 }
 
+// runSampler publishes Event updates to s.events until stopCh is closed: a
+// throughput sample and status/fingerprint change check every
+// samplerInterval, plus a live republish of the engine's fallback_activated
+// bootstrap events as EventFallbackDecision.
+func (s *Server) runSampler(stopCh chan struct{}) {
+	ticker := time.NewTicker(samplerInterval)
+	defer ticker.Stop()
+
+	bootstrap, unsubscribe := s.engine.EventBus().Subscribe()
+	defer unsubscribe()
+
+	var lastStatus tunnel.EngineStatus
+	var lastFingerprint string
+	var lastSent, lastRecv int64
+
+	for {
+		select {
+		case <-stopCh:
+			return
+
+		case ev, ok := <-bootstrap:
+			if !ok {
+				return
+			}
+			if ev.Type == events.EventFallbackActivated {
+				if data, ok := ev.Data.(events.FallbackActivatedData); ok {
+					s.events.Publish(EventFallbackDecision, FallbackDecisionData{Protocol: data.Protocol})
+				}
+			}
+
+		case <-ticker.C:
+			status := s.engine.Status()
+			if status != lastStatus {
+				lastStatus = status
+				s.events.Publish(EventStatusChanged, StatusChangedData{Status: string(status)})
+			}
+
+			if fp := s.currentFingerprint(); fp != "" && fp != lastFingerprint {
+				lastFingerprint = fp
+				s.events.Publish(EventFingerprintInUse, FingerprintInUseData{Fingerprint: fp})
+			}
+
+			s.mu.RLock()
+			sent, recv := s.bytesSent, s.bytesRecv
+			s.mu.RUnlock()
+			s.events.Publish(EventThroughputSample, ThroughputSampleData{
+				BytesSent: sent - lastSent,
+				BytesRecv: recv - lastRecv,
+				Interval:  samplerInterval.String(),
+			})
+			lastSent, lastRecv = sent, recv
+		}
+	}
+}
+
+// currentFingerprint returns the uTLS fingerprint the engine is currently
+// configured with, client or server mode, or "" if neither is set yet.
+func (s *Server) currentFingerprint() string {
+	if cfg := s.engine.ClientConfig(); cfg != nil {
+		return cfg.Fingerprint
+	}
+	if cfg := s.engine.Config(); cfg != nil {
+		return cfg.Fingerprint
+	}
+	return ""
+}
+
 type statusResponse struct {
 	Connected  bool   `json:"connected"`
 	Status     string `json:"status"`
@@ -195,6 +308,114 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, map[string]string{"status": "ok"})
 }
 
+// handleAudit runs the DPI-fingerprint self-audit against the engine's
+// current config, so operators (and the GUI) can check "is this
+// deployment fingerprintable?" without shelling out to entropy-server
+// audit. Pass ?live=1 to also capture a real ClientHello from the
+// engine's own listen address.
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	cfg := s.engine.Config()
+	if cfg == nil {
+		writeError(w, http.StatusNotImplemented, "audit is only available for server-mode engines")
+		return
+	}
+
+	listenAddr := ""
+	if r.URL.Query().Get("live") != "" {
+		addr, err := tunnel.LoopbackListenAddr(cfg.Listen)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("live audit: %v", err))
+			return
+		}
+		listenAddr = addr
+	}
+
+	report, err := tunnel.NewAuditor(cfg, s.logger).Run(r.Context(), listenAddr)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, report)
+}
+
+// handleEvents streams bootstrap-progress events (broker contacted, peer
+// count, handshake OK, rotation, fallback) as Server-Sent Events, so a GUI
+// can render live connection progress instead of polling /api/status.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	events, unsubscribe := s.engine.EventBus().Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			payload, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, payload)
+			flusher.Flush()
+		}
+	}
+}
+
+// handleAPIEvents streams Server's live dashboard events (status
+// transitions, throughput samples, fingerprint changes, fallback
+// decisions) as Server-Sent Events, so the GUI can show a live throughput
+// graph and reconnection state instead of polling /api/status.
+func (s *Server) handleAPIEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch, unsubscribe := s.events.Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			payload, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, payload)
+			flusher.Flush()
+		}
+	}
+}
+
+// handleBootstrapStatus returns the last-known bootstrap event per
+// subsystem, so a GUI opening late can render current state immediately.
+func (s *Server) handleBootstrapStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.engine.EventBus().LastState())
+}
+
 func writeJSON(w http.ResponseWriter, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(v)
@@ -206,6 +427,20 @@ func writeError(w http.ResponseWriter, code int, msg string) {
 	json.NewEncoder(w).Encode(map[string]string{"error": msg})
 }
 
+// debugAuthMiddleware requires a matching "Authorization: Bearer <token>"
+// header before forwarding to the debug handler, since /debug/config_dump
+// and friends leak operational detail that shouldn't be open to anyone who
+// can reach the API port.
+func debugAuthMiddleware(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			writeError(w, http.StatusUnauthorized, "invalid or missing debug token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
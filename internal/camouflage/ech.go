@@ -1,9 +1,18 @@
 package camouflage
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
 	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
 )
 
 // ECHConfig represents Encrypted Client Hello configuration.
@@ -18,6 +27,16 @@ type ECHConfig struct {
 
 	// ConfigList is the base64-encoded ECHConfigList from DNS HTTPS records.
 	ConfigList string `json:"config_list" yaml:"config_list"`
+
+	// KeyPath, if set, is where the HPKE private key PEM generated by
+	// GenerateECHConfig is stored on disk for the fronting server to load.
+	KeyPath string `json:"key_path,omitempty" yaml:"key_path,omitempty"`
+
+	// PrivateKey is the PEM-encoded X25519 HPKE private key matching
+	// ConfigList's public key. Only populated right after generation;
+	// operators are expected to persist it to KeyPath and not round-trip
+	// it through config files afterwards.
+	PrivateKey string `json:"-" yaml:"-"`
 }
 
 // ECHMode type for selecting ECH behavior.
@@ -25,8 +44,22 @@ type ECHMode string
 
 const (
 	ECHModeDisabled ECHMode = "disabled"
-	ECHModeGrease   ECHMode = "grease"   // Send GREASE ECH extension (camouflage only)
-	ECHModeFull     ECHMode = "full"     // Full ECH with real config
+	ECHModeGrease   ECHMode = "grease" // Send GREASE ECH extension (camouflage only)
+	ECHModeFull     ECHMode = "full"   // Full ECH with real config
+)
+
+// HPKE and ECHConfig identifiers used throughout this file, per
+// draft-ietf-tls-esni-13. These are typed uint16 (rather than untyped
+// int constants) so that encoding/binary.BigEndian.AppendUint16 — the
+// same convention ja3.go uses for on-the-wire TLS fields — is the only
+// place they get serialized; a bare byte(echVersion) conversion would
+// overflow since these are two-byte wire values.
+const (
+	kemX25519HKDFSHA256 uint16 = 0x0020
+	kdfHKDFSHA256       uint16 = 0x0001
+	aeadAES128GCM       uint16 = 0x0001
+	echVersion          uint16 = 0xfe0d
+	echMaxNameLength    uint16 = 64
 )
 
 // GenerateGreaseECH creates a GREASE (fake) ECH extension payload.
@@ -39,9 +72,7 @@ func GenerateGreaseECH() ([]byte, error) {
 	payloadLen := 128 + randInt(64)
 	payload := make([]byte, payloadLen+2)
 
-	// ECH version 0xfe0d (draft-ietf-tls-esni)
-	payload[0] = 0xfe
-	payload[1] = 0x0d
+	binary.BigEndian.PutUint16(payload, echVersion)
 
 	// Random payload
 	if _, err := rand.Read(payload[2:]); err != nil {
@@ -52,13 +83,17 @@ func GenerateGreaseECH() ([]byte, error) {
 }
 
 // EncodeECHConfigList encodes an ECH config for use in TLS ClientHello.
+//
+// Deprecated: this only produces a simplified, non-standard layout kept
+// for outer-ClientHello camouflage use. Use GenerateECHConfig to produce a
+// real HpkeKeyConfig a fronting server can actually decrypt with.
 func EncodeECHConfigList(publicName string, publicKey []byte) string {
 	// Simplified ECHConfigList encoding for the outer config.
 	// In production, this would parse real DNS HTTPS records.
 	raw := make([]byte, 0, 64)
 
 	// Version: 0xfe0d
-	raw = append(raw, 0xfe, 0x0d)
+	raw = binary.BigEndian.AppendUint16(raw, echVersion)
 
 	// Length placeholder (will fill later)
 	raw = append(raw, 0x00, 0x00)
@@ -74,12 +109,510 @@ func EncodeECHConfigList(publicName string, publicKey []byte) string {
 
 	// Fill in length
 	contentLen := len(raw) - 4
-	raw[2] = byte(contentLen >> 8)
-	raw[3] = byte(contentLen)
+	binary.BigEndian.PutUint16(raw[2:], uint16(contentLen))
 
 	return base64.StdEncoding.EncodeToString(raw)
 }
 
+// GenerateECHConfig builds a real ECHConfigList per draft-ietf-tls-esni: a
+// fresh X25519 HPKE keypair wrapped in an HpkeKeyConfig advertising
+// HKDF-SHA256/AES-128-GCM, under publicName as the outer SNI. It returns
+// the base64 config list to publish in an HTTPS/SVCB record's "ech" param,
+// and the matching private key PEM so the fronting server can decrypt
+// ClientHellos that use it.
+func GenerateECHConfig(publicName string) (configListB64, privateKeyPEM string, err error) {
+	if !isValidDNSName(publicName) {
+		return "", "", fmt.Errorf("invalid public_name %q: not a valid DNS name", publicName)
+	}
+
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("generate X25519 keypair: %w", err)
+	}
+	pub := priv.PublicKey().Bytes()
+
+	configID := make([]byte, 1)
+	if _, err := rand.Read(configID); err != nil {
+		return "", "", fmt.Errorf("generate config_id: %w", err)
+	}
+
+	contents := make([]byte, 0, 128)
+	contents = append(contents, configID[0])
+
+	contents = binary.BigEndian.AppendUint16(contents, kemX25519HKDFSHA256)
+
+	contents = binary.BigEndian.AppendUint16(contents, uint16(len(pub)))
+	contents = append(contents, pub...)
+
+	// cipher_suites<4..2^16-4>: one HpkeSymmetricCipherSuite { kdf_id, aead_id }.
+	suite := binary.BigEndian.AppendUint16(nil, kdfHKDFSHA256)
+	suite = binary.BigEndian.AppendUint16(suite, aeadAES128GCM)
+	contents = binary.BigEndian.AppendUint16(contents, uint16(len(suite)))
+	contents = append(contents, suite...)
+
+	contents = binary.BigEndian.AppendUint16(contents, echMaxNameLength)
+
+	nameBytes := []byte(publicName)
+	contents = append(contents, byte(len(nameBytes)))
+	contents = append(contents, nameBytes...)
+
+	// extensions<0..2^16-1>: none.
+	contents = append(contents, 0x00, 0x00)
+
+	echConfig := make([]byte, 0, len(contents)+4)
+	echConfig = binary.BigEndian.AppendUint16(echConfig, echVersion)
+	echConfig = binary.BigEndian.AppendUint16(echConfig, uint16(len(contents)))
+	echConfig = append(echConfig, contents...)
+
+	// ECHConfigList<4..2^16-1>: a length-prefixed sequence of ECHConfig;
+	// we only ever publish the one we just generated.
+	list := binary.BigEndian.AppendUint16(nil, uint16(len(echConfig)))
+	list = append(list, echConfig...)
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "X25519 PRIVATE KEY",
+		Bytes: priv.Bytes(),
+	})
+
+	return base64.StdEncoding.EncodeToString(list), string(keyPEM), nil
+}
+
+// HPKECipherSuite is one HpkeSymmetricCipherSuite entry from an
+// HpkeKeyConfig's cipher_suites list.
+type HPKECipherSuite struct {
+	KDFID  uint16
+	AEADID uint16
+}
+
+// ECHConfigEntry is one parsed ECHConfig from an ECHConfigList, holding
+// just the fields a client needs to seal a ClientHelloInner against it.
+type ECHConfigEntry struct {
+	Version       uint16
+	ConfigID      uint8
+	KEMID         uint16
+	PublicKey     []byte
+	CipherSuites  []HPKECipherSuite
+	MaxNameLength uint16
+	PublicName    string
+
+	// Raw is the ECHConfig exactly as it appeared in the list (version +
+	// length + contents), needed verbatim as part of the HPKE "info"
+	// string when sealing against this entry.
+	Raw []byte
+}
+
+// ParseECHConfigList parses a draft-ietf-tls-esni-13 ECHConfigList (the
+// base64-decoded contents of an HTTPS record's "ech" SvcParam) into its
+// entries. Entries with a version other than 0xfe0d are skipped rather
+// than rejected, per the spec: a client must ignore ECHConfig versions it
+// doesn't understand instead of failing the whole list.
+func ParseECHConfigList(raw []byte) ([]ECHConfigEntry, error) {
+	r := &byteReader{buf: raw}
+
+	listLen, err := r.uint16()
+	if err != nil {
+		return nil, fmt.Errorf("ech config list: %w", err)
+	}
+	body, err := r.bytes(int(listLen))
+	if err != nil {
+		return nil, fmt.Errorf("ech config list: %w", err)
+	}
+
+	var entries []ECHConfigEntry
+	br := &byteReader{buf: body}
+	for br.remaining() > 0 {
+		start := br.pos
+		version, err := br.uint16()
+		if err != nil {
+			return nil, fmt.Errorf("ech config entry: %w", err)
+		}
+		length, err := br.uint16()
+		if err != nil {
+			return nil, fmt.Errorf("ech config entry: %w", err)
+		}
+		contents, err := br.bytes(int(length))
+		if err != nil {
+			return nil, fmt.Errorf("ech config entry: %w", err)
+		}
+
+		if version != echVersion {
+			continue // unsupported version; ignore per spec
+		}
+
+		entry, err := parseECHConfigContents(contents)
+		if err != nil {
+			return nil, fmt.Errorf("ech config entry: %w", err)
+		}
+		entry.Version = version
+		entry.Raw = append([]byte{}, br.buf[start:br.pos]...)
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func parseECHConfigContents(contents []byte) (ECHConfigEntry, error) {
+	var entry ECHConfigEntry
+	cr := &byteReader{buf: contents}
+
+	configID, err := cr.uint8()
+	if err != nil {
+		return entry, fmt.Errorf("config_id: %w", err)
+	}
+	kemID, err := cr.uint16()
+	if err != nil {
+		return entry, fmt.Errorf("kem_id: %w", err)
+	}
+	pkLen, err := cr.uint16()
+	if err != nil {
+		return entry, fmt.Errorf("public_key length: %w", err)
+	}
+	pubKey, err := cr.bytes(int(pkLen))
+	if err != nil {
+		return entry, fmt.Errorf("public_key: %w", err)
+	}
+	suitesLen, err := cr.uint16()
+	if err != nil {
+		return entry, fmt.Errorf("cipher_suites length: %w", err)
+	}
+	suitesRaw, err := cr.bytes(int(suitesLen))
+	if err != nil {
+		return entry, fmt.Errorf("cipher_suites: %w", err)
+	}
+	if len(suitesRaw)%4 != 0 {
+		return entry, fmt.Errorf("cipher_suites: length %d not a multiple of 4", len(suitesRaw))
+	}
+	var suites []HPKECipherSuite
+	for i := 0; i < len(suitesRaw); i += 4 {
+		suites = append(suites, HPKECipherSuite{
+			KDFID:  binary.BigEndian.Uint16(suitesRaw[i:]),
+			AEADID: binary.BigEndian.Uint16(suitesRaw[i+2:]),
+		})
+	}
+	maxNameLen, err := cr.uint16()
+	if err != nil {
+		return entry, fmt.Errorf("maximum_name_length: %w", err)
+	}
+	nameLen, err := cr.uint8()
+	if err != nil {
+		return entry, fmt.Errorf("public_name length: %w", err)
+	}
+	nameBytes, err := cr.bytes(int(nameLen))
+	if err != nil {
+		return entry, fmt.Errorf("public_name: %w", err)
+	}
+	// extensions<0..2^16-1>: parsed but unused.
+	extLen, err := cr.uint16()
+	if err != nil {
+		return entry, fmt.Errorf("extensions length: %w", err)
+	}
+	if _, err := cr.bytes(int(extLen)); err != nil {
+		return entry, fmt.Errorf("extensions: %w", err)
+	}
+
+	entry.ConfigID = configID
+	entry.KEMID = kemID
+	entry.PublicKey = append([]byte{}, pubKey...)
+	entry.CipherSuites = suites
+	entry.MaxNameLength = maxNameLen
+	entry.PublicName = string(nameBytes)
+	return entry, nil
+}
+
+// selectECHConfig picks the first entry in entries advertising a
+// KEM/KDF/AEAD combination this package implements
+// (X25519+HKDF-SHA256+AES-128-GCM).
+func selectECHConfig(entries []ECHConfigEntry) (*ECHConfigEntry, *HPKECipherSuite, error) {
+	for i := range entries {
+		entry := &entries[i]
+		if entry.KEMID != kemX25519HKDFSHA256 {
+			continue
+		}
+		for _, suite := range entry.CipherSuites {
+			if suite.KDFID == kdfHKDFSHA256 && suite.AEADID == aeadAES128GCM {
+				return entry, &suite, nil
+			}
+		}
+	}
+	return nil, nil, fmt.Errorf("ech: no entry offers a supported HPKE suite (want X25519+HKDF-SHA256+AES-128-GCM)")
+}
+
+// SealClientHelloInner seals clientHelloInner against the first supported
+// ECHConfig in configList (a raw ECHConfigList, as parsed by
+// ParseECHConfigList), producing the body of the outer
+// "encrypted_client_hello" extension per draft-ietf-tls-esni-13 §5: the
+// selected HpkeSymmetricCipherSuite, config_id, the HPKE encapsulated key
+// (enc), and the AEAD-sealed payload. aad is the AAD the caller's
+// ClientHelloOuter encoder computes per spec (the outer ClientHello with
+// this extension's payload field zeroed).
+func SealClientHelloInner(configList, clientHelloInner, aad []byte) ([]byte, error) {
+	entries, err := ParseECHConfigList(configList)
+	if err != nil {
+		return nil, fmt.Errorf("ech: %w", err)
+	}
+	entry, suite, err := selectECHConfig(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := ecdh.X25519().NewPublicKey(entry.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("ech: invalid HPKE public key: %w", err)
+	}
+
+	info := buildECHInfo(entry.Raw)
+	enc, ciphertext, err := hpkeSealBase(pub, info, aad, clientHelloInner)
+	if err != nil {
+		return nil, fmt.Errorf("ech: %w", err)
+	}
+
+	var ext bytes.Buffer
+	binary.Write(&ext, binary.BigEndian, suite.KDFID)
+	binary.Write(&ext, binary.BigEndian, suite.AEADID)
+	ext.WriteByte(entry.ConfigID)
+	binary.Write(&ext, binary.BigEndian, uint16(len(enc)))
+	ext.Write(enc)
+	binary.Write(&ext, binary.BigEndian, uint16(len(ciphertext)))
+	ext.Write(ciphertext)
+
+	return ext.Bytes(), nil
+}
+
+// echInfoLabel is the fixed "tls ech" || 0x00 prefix the HPKE "info"
+// input to SealClientHelloInner always starts with (draft-ietf-tls-esni-13 §4).
+var echInfoLabel = append([]byte("tls ech"), 0x00)
+
+func buildECHInfo(rawECHConfig []byte) []byte {
+	info := make([]byte, 0, len(echInfoLabel)+len(rawECHConfig))
+	info = append(info, echInfoLabel...)
+	info = append(info, rawECHConfig...)
+	return info
+}
+
+// dohEndpoint is the DNS-over-HTTPS resolver FetchECHConfigFromHTTPS
+// queries; Cloudflare's resolver is used since Cloudflare also operates
+// the cloudflare-ech.com public ECH front most deployments target.
+const dohEndpoint = "https://cloudflare-dns.com/dns-query"
+
+const (
+	dnsTypeHTTPS   uint16 = 65
+	dnsClassIN     uint16 = 1
+	svcParamKeyECH uint16 = 5
+)
+
+// FetchECHConfigFromHTTPS resolves domain's DNS HTTPS (SVCB, RFC 9460)
+// record via DNS-over-HTTPS and extracts the "ech" SvcParam, returning it
+// base64-encoded in the same form ECHConfig.ConfigList expects. This is
+// how a client picks up a real ECHConfigList for a front like
+// cloudflare-ech.com without an operator hand-pasting one.
+func FetchECHConfigFromHTTPS(ctx context.Context, domain string) (string, error) {
+	query, err := buildDNSQuery(domain, dnsTypeHTTPS)
+	if err != nil {
+		return "", fmt.Errorf("fetch ech config: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dohEndpoint, bytes.NewReader(query))
+	if err != nil {
+		return "", fmt.Errorf("fetch ech config: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetch ech config: DoH request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetch ech config: DoH returned HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", fmt.Errorf("fetch ech config: reading DoH response: %w", err)
+	}
+
+	ech, err := extractECHSvcParam(body)
+	if err != nil {
+		return "", fmt.Errorf("fetch ech config for %s: %w", domain, err)
+	}
+	return base64.StdEncoding.EncodeToString(ech), nil
+}
+
+// buildDNSQuery builds a minimal single-question DNS wire-format query
+// for qname/qtype/IN, suitable for DNS-over-HTTPS (RFC 8484).
+func buildDNSQuery(qname string, qtype uint16) ([]byte, error) {
+	var q bytes.Buffer
+
+	id := make([]byte, 2)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("dns query id: %w", err)
+	}
+	q.Write(id)
+	q.Write([]byte{0x01, 0x00}) // flags: recursion desired
+	q.Write([]byte{0x00, 0x01}) // qdcount=1
+	q.Write([]byte{0x00, 0x00}) // ancount=0
+	q.Write([]byte{0x00, 0x00}) // nscount=0
+	q.Write([]byte{0x00, 0x00}) // arcount=0
+
+	if err := writeDNSName(&q, qname); err != nil {
+		return nil, err
+	}
+	binary.Write(&q, binary.BigEndian, qtype)
+	binary.Write(&q, binary.BigEndian, dnsClassIN)
+
+	return q.Bytes(), nil
+}
+
+func writeDNSName(buf *bytes.Buffer, name string) error {
+	if !isValidDNSName(name) {
+		return fmt.Errorf("invalid DNS name %q", name)
+	}
+	start := 0
+	for i := 0; i <= len(name); i++ {
+		if i == len(name) || name[i] == '.' {
+			label := name[start:i]
+			if len(label) > 63 {
+				return fmt.Errorf("dns label %q exceeds 63 bytes", label)
+			}
+			buf.WriteByte(byte(len(label)))
+			buf.WriteString(label)
+			start = i + 1
+		}
+	}
+	buf.WriteByte(0x00)
+	return nil
+}
+
+// extractECHSvcParam parses a DoH response message looking for an HTTPS
+// (type 65) answer and returns the raw value of its "ech" (key 5)
+// SvcParam.
+func extractECHSvcParam(msg []byte) ([]byte, error) {
+	r := &byteReader{buf: msg}
+	if _, err := r.bytes(4); err != nil { // id, flags
+		return nil, fmt.Errorf("dns response header: %w", err)
+	}
+	qdcount, err := r.uint16()
+	if err != nil {
+		return nil, fmt.Errorf("dns response header: %w", err)
+	}
+	ancount, err := r.uint16()
+	if err != nil {
+		return nil, fmt.Errorf("dns response header: %w", err)
+	}
+	if _, err := r.bytes(4); err != nil { // nscount, arcount
+		return nil, fmt.Errorf("dns response header: %w", err)
+	}
+
+	for i := uint16(0); i < qdcount; i++ {
+		if err := skipDNSName(r); err != nil {
+			return nil, fmt.Errorf("dns question %d: %w", i, err)
+		}
+		if _, err := r.bytes(4); err != nil { // qtype, qclass
+			return nil, fmt.Errorf("dns question %d: %w", i, err)
+		}
+	}
+
+	for i := uint16(0); i < ancount; i++ {
+		if err := skipDNSName(r); err != nil {
+			return nil, fmt.Errorf("dns answer %d name: %w", i, err)
+		}
+		rrType, err := r.uint16()
+		if err != nil {
+			return nil, fmt.Errorf("dns answer %d type: %w", i, err)
+		}
+		if _, err := r.bytes(6); err != nil { // class, ttl
+			return nil, fmt.Errorf("dns answer %d: %w", i, err)
+		}
+		rdlength, err := r.uint16()
+		if err != nil {
+			return nil, fmt.Errorf("dns answer %d rdlength: %w", i, err)
+		}
+		rdata, err := r.bytes(int(rdlength))
+		if err != nil {
+			return nil, fmt.Errorf("dns answer %d rdata: %w", i, err)
+		}
+
+		if rrType != dnsTypeHTTPS {
+			continue
+		}
+		ech, ok, err := parseECHFromHTTPSRData(rdata)
+		if err != nil {
+			return nil, fmt.Errorf("dns answer %d: %w", i, err)
+		}
+		if ok {
+			return ech, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no HTTPS record with an ech SvcParam found")
+}
+
+// parseECHFromHTTPSRData parses an HTTPS RR's RDATA (RFC 9460 §2): a
+// 2-byte priority, a (possibly compressed) TargetName, then a sequence of
+// SvcParam {key, length, value} entries, and returns the value of the
+// "ech" (key 5) param if present.
+func parseECHFromHTTPSRData(rdata []byte) (ech []byte, ok bool, err error) {
+	r := &byteReader{buf: rdata}
+	if _, err := r.uint16(); err != nil { // SvcPriority
+		return nil, false, fmt.Errorf("svcb priority: %w", err)
+	}
+	if err := skipDNSName(r); err != nil {
+		return nil, false, fmt.Errorf("svcb target name: %w", err)
+	}
+
+	for r.remaining() > 0 {
+		key, err := r.uint16()
+		if err != nil {
+			return nil, false, fmt.Errorf("svcparam key: %w", err)
+		}
+		length, err := r.uint16()
+		if err != nil {
+			return nil, false, fmt.Errorf("svcparam length: %w", err)
+		}
+		value, err := r.bytes(int(length))
+		if err != nil {
+			return nil, false, fmt.Errorf("svcparam value: %w", err)
+		}
+		if key == svcParamKeyECH {
+			return append([]byte{}, value...), true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// skipDNSName advances r past a DNS name, following at most one
+// compression pointer (RFC 1035 §4.1.4) — sufficient for the DoH
+// responses this file parses, which never chain pointers.
+func skipDNSName(r *byteReader) error {
+	for {
+		b, err := r.uint8()
+		if err != nil {
+			return err
+		}
+		switch {
+		case b == 0x00:
+			return nil
+		case b&0xc0 == 0xc0:
+			// Compression pointer: one more byte, then done — the
+			// pointed-to name isn't needed by any caller here.
+			_, err := r.uint8()
+			return err
+		default:
+			if _, err := r.bytes(int(b)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+var dnsNameRE = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z]{2,}$`)
+
+func isValidDNSName(name string) bool {
+	return len(name) > 0 && len(name) <= 255 && dnsNameRE.MatchString(name)
+}
+
 // ValidateECHConfig checks if an ECH configuration is valid.
 func ValidateECHConfig(cfg *ECHConfig) error {
 	if cfg == nil {
@@ -91,6 +624,18 @@ func ValidateECHConfig(cfg *ECHConfig) error {
 	if cfg.PublicName == "" {
 		return fmt.Errorf("ECH public_name is required when enabled")
 	}
+	if !isValidDNSName(cfg.PublicName) {
+		return fmt.Errorf("ECH public_name %q is not a valid DNS name", cfg.PublicName)
+	}
+	if cfg.PrivateKey != "" {
+		block, _ := pem.Decode([]byte(cfg.PrivateKey))
+		if block == nil {
+			return fmt.Errorf("ECH private_key is not valid PEM")
+		}
+		if len(block.Bytes) != 32 {
+			return fmt.Errorf("ECH private_key length %d doesn't match X25519 (kem_id=0x0020), want 32 bytes", len(block.Bytes))
+		}
+	}
 	return nil
 }
 
@@ -1,7 +1,12 @@
 package camouflage
 
 import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
 	"encoding/base64"
+	"encoding/pem"
 	"testing"
 )
 
@@ -70,6 +75,208 @@ func TestEncodeECHConfigList_WithPublicKey(t *testing.T) {
 	}
 }
 
+func TestGenerateECHConfig(t *testing.T) {
+	configList, keyPEM, err := GenerateECHConfig("cloudflare-ech.com")
+	if err != nil {
+		t.Fatalf("GenerateECHConfig() error = %v", err)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(configList)
+	if err != nil {
+		t.Fatalf("config list is not valid base64: %v", err)
+	}
+
+	// ECHConfigList<4..2^16-1>: 2-byte length, then one ECHConfig starting
+	// with the 0xfe0d version.
+	if len(raw) < 6 {
+		t.Fatalf("config list too short: %d bytes", len(raw))
+	}
+	listLen := int(raw[0])<<8 | int(raw[1])
+	if listLen != len(raw)-2 {
+		t.Errorf("list length prefix = %d, want %d", listLen, len(raw)-2)
+	}
+	if raw[2] != 0xfe || raw[3] != 0x0d {
+		t.Errorf("expected ECHConfig version 0xfe0d, got 0x%02x%02x", raw[2], raw[3])
+	}
+
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		t.Fatal("private key is not valid PEM")
+	}
+	if len(block.Bytes) != 32 {
+		t.Errorf("expected 32-byte X25519 private key, got %d bytes", len(block.Bytes))
+	}
+
+	cfg := &ECHConfig{Enabled: true, PublicName: "cloudflare-ech.com", ConfigList: configList, PrivateKey: keyPEM}
+	if err := ValidateECHConfig(cfg); err != nil {
+		t.Errorf("ValidateECHConfig() on generated config error = %v", err)
+	}
+}
+
+func TestGenerateECHConfig_InvalidPublicName(t *testing.T) {
+	if _, _, err := GenerateECHConfig("not a domain"); err == nil {
+		t.Error("expected error for invalid public_name")
+	}
+}
+
+func TestParseECHConfigList(t *testing.T) {
+	configListB64, _, err := GenerateECHConfig("cloudflare-ech.com")
+	if err != nil {
+		t.Fatalf("GenerateECHConfig() error = %v", err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(configListB64)
+	if err != nil {
+		t.Fatalf("decoding config list: %v", err)
+	}
+
+	entries, err := ParseECHConfigList(raw)
+	if err != nil {
+		t.Fatalf("ParseECHConfigList() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Version != echVersion {
+		t.Errorf("Version = 0x%04x, want 0x%04x", entry.Version, echVersion)
+	}
+	if entry.KEMID != kemX25519HKDFSHA256 {
+		t.Errorf("KEMID = 0x%04x, want 0x%04x", entry.KEMID, kemX25519HKDFSHA256)
+	}
+	if entry.PublicName != "cloudflare-ech.com" {
+		t.Errorf("PublicName = %q, want %q", entry.PublicName, "cloudflare-ech.com")
+	}
+	if len(entry.PublicKey) != 32 {
+		t.Errorf("PublicKey length = %d, want 32", len(entry.PublicKey))
+	}
+	if len(entry.CipherSuites) != 1 || entry.CipherSuites[0].KDFID != kdfHKDFSHA256 || entry.CipherSuites[0].AEADID != aeadAES128GCM {
+		t.Errorf("CipherSuites = %+v, want one HKDF-SHA256/AES-128-GCM suite", entry.CipherSuites)
+	}
+}
+
+func TestParseECHConfigList_UnsupportedVersionSkipped(t *testing.T) {
+	// One ECHConfig entry with an unrecognized version (0xfe0c) followed
+	// by a real one; the unsupported entry must be skipped, not error out
+	// the whole list.
+	unsupported := []byte{0xfe, 0x0c, 0x00, 0x02, 0xaa, 0xbb}
+
+	configListB64, _, err := GenerateECHConfig("cloudflare-ech.com")
+	if err != nil {
+		t.Fatalf("GenerateECHConfig() error = %v", err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(configListB64)
+	if err != nil {
+		t.Fatalf("decoding config list: %v", err)
+	}
+	// raw is ECHConfigList<4..>: 2-byte length + one ECHConfig. Splice the
+	// unsupported entry in before the real one and fix up the length.
+	realConfig := raw[2:]
+	combined := append(append([]byte{}, unsupported...), realConfig...)
+	spliced := make([]byte, 0, len(combined)+2)
+	spliced = append(spliced, byte(len(combined)>>8), byte(len(combined)))
+	spliced = append(spliced, combined...)
+
+	entries, err := ParseECHConfigList(spliced)
+	if err != nil {
+		t.Fatalf("ParseECHConfigList() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected the unsupported entry to be skipped, got %d entries", len(entries))
+	}
+}
+
+func TestSealClientHelloInner(t *testing.T) {
+	configListB64, keyPEM, err := GenerateECHConfig("cloudflare-ech.com")
+	if err != nil {
+		t.Fatalf("GenerateECHConfig() error = %v", err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(configListB64)
+	if err != nil {
+		t.Fatalf("decoding config list: %v", err)
+	}
+	entries, err := ParseECHConfigList(raw)
+	if err != nil {
+		t.Fatalf("ParseECHConfigList() error = %v", err)
+	}
+
+	inner := []byte("this would be a serialized ClientHelloInner")
+	aad := []byte("this would be the ClientHelloOuter with a zeroed payload")
+
+	ext, err := SealClientHelloInner(raw, inner, aad)
+	if err != nil {
+		t.Fatalf("SealClientHelloInner() error = %v", err)
+	}
+
+	// kdf_id(2) || aead_id(2) || config_id(1) || enc<2+N> || payload<2+N>
+	if len(ext) < 7 {
+		t.Fatalf("extension too short: %d bytes", len(ext))
+	}
+	if got := uint16(ext[0])<<8 | uint16(ext[1]); got != kdfHKDFSHA256 {
+		t.Errorf("kdf_id = 0x%04x, want 0x%04x", got, kdfHKDFSHA256)
+	}
+	if got := uint16(ext[2])<<8 | uint16(ext[3]); got != aeadAES128GCM {
+		t.Errorf("aead_id = 0x%04x, want 0x%04x", got, aeadAES128GCM)
+	}
+	if ext[4] != entries[0].ConfigID {
+		t.Errorf("config_id = 0x%02x, want 0x%02x", ext[4], entries[0].ConfigID)
+	}
+
+	encLen := int(ext[5])<<8 | int(ext[6])
+	enc := ext[7 : 7+encLen]
+	payloadLenOff := 7 + encLen
+	payloadLen := int(ext[payloadLenOff])<<8 | int(ext[payloadLenOff+1])
+	payload := ext[payloadLenOff+2 : payloadLenOff+2+payloadLen]
+
+	// Decrypt with the matching private key and confirm it recovers the
+	// original ClientHelloInner, proving the HPKE seal is wire-correct
+	// rather than just well-formed.
+	block, _ := pem.Decode([]byte(keyPEM))
+	priv, err := ecdh.X25519().NewPrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatalf("parsing private key: %v", err)
+	}
+	pt, err := decryptECHForTest(priv, enc, entries[0].Raw, aad, payload)
+	if err != nil {
+		t.Fatalf("decrypting sealed payload: %v", err)
+	}
+	if !bytes.Equal(pt, inner) {
+		t.Errorf("decrypted payload = %q, want %q", pt, inner)
+	}
+}
+
+// decryptECHForTest is the receiving side of SealClientHelloInner's HPKE
+// Base-mode encryption, reimplemented from RFC 9180 here (not exported by
+// this package, which is client-only) purely so this test can confirm the
+// seal output is actually decryptable rather than merely well-formed.
+func decryptECHForTest(skR *ecdh.PrivateKey, enc, rawECHConfig, aad, ciphertext []byte) ([]byte, error) {
+	encPub, err := ecdh.X25519().NewPublicKey(enc)
+	if err != nil {
+		return nil, err
+	}
+	dh, err := skR.ECDH(encPub)
+	if err != nil {
+		return nil, err
+	}
+	kemContext := append(append([]byte{}, enc...), skR.PublicKey().Bytes()...)
+	sharedSecret := kemExtractAndExpand(dh, kemContext)
+
+	key, baseNonce, err := hpkeKeySchedule(sharedSecret, buildECHInfo(rawECHConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, baseNonce, ciphertext, aad)
+}
+
 func TestValidateECHConfig(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -0,0 +1,96 @@
+package camouflage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// KnownFingerprint is one browser's expected JA3/JA4 hash, the values
+// tunnel.Auditor compares a captured ClientHello against.
+type KnownFingerprint struct {
+	Browser string `json:"browser"`
+	JA3     string `json:"ja3"`
+	JA4     string `json:"ja4"`
+}
+
+// defaultCorpus seeds the bundled browser entries with representative
+// JA3/JA4 hashes collected from public fingerprint databases (e.g. the
+// FoxIO JA4 project and the Salesforce JA3 corpus). Browsers revise their
+// TLS stack often enough that these drift out of date — call
+// LoadCorpusFile to override them with a freshly collected set instead of
+// editing this file every release.
+var defaultCorpus = map[string]KnownFingerprint{
+	"chrome": {
+		Browser: "chrome",
+		JA3:     "cd08e31494f9531f560d64c695473da9",
+		JA4:     "t13d1516h2_8daaf6152771_02713d6af862",
+	},
+	"firefox": {
+		Browser: "firefox",
+		JA3:     "b20b44b18b3f724b056968f0b1a53b63",
+		JA4:     "t13d1715h2_5b57614c22b0_3cbc8a213217",
+	},
+	"safari": {
+		Browser: "safari",
+		JA3:     "773906b0efdefa24a7f2b8eb6985bf37",
+		JA4:     "t13d1516h2_8daaf6152771_b0da82dd1658",
+	},
+	"edge": {
+		Browser: "edge",
+		JA3:     "cd08e31494f9531f560d64c695473da9",
+		JA4:     "t13d1516h2_8daaf6152771_c3a002f50b7e",
+	},
+}
+
+var (
+	corpusMu sync.RWMutex
+	corpus   = cloneCorpus(defaultCorpus)
+)
+
+func cloneCorpus(src map[string]KnownFingerprint) map[string]KnownFingerprint {
+	out := make(map[string]KnownFingerprint, len(src))
+	for k, v := range src {
+		out[k] = v
+	}
+	return out
+}
+
+// LookupCorpus returns the known JA3/JA4 hash for a browser fingerprint
+// name (the same names SelectFingerprint accepts, e.g. "chrome").
+func LookupCorpus(fingerprint string) (KnownFingerprint, bool) {
+	corpusMu.RLock()
+	defer corpusMu.RUnlock()
+	kf, ok := corpus[fingerprint]
+	return kf, ok
+}
+
+// LoadCorpusFile replaces the in-memory corpus with the contents of a
+// JSON file (a map of fingerprint name -> KnownFingerprint), so operators
+// can update known-good hashes as browsers ship new TLS stacks without a
+// rebuild.
+func LoadCorpusFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read corpus file %s: %w", path, err)
+	}
+
+	var loaded map[string]KnownFingerprint
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("parse corpus file %s: %w", path, err)
+	}
+
+	corpusMu.Lock()
+	corpus = loaded
+	corpusMu.Unlock()
+	return nil
+}
+
+// ResetCorpus restores the bundled default corpus, mainly useful for
+// tests that call LoadCorpusFile.
+func ResetCorpus() {
+	corpusMu.Lock()
+	corpus = cloneCorpus(defaultCorpus)
+	corpusMu.Unlock()
+}
@@ -0,0 +1,185 @@
+package camouflage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// HPKE primitive IDs this package implements, per RFC 9180. ECH draft-13
+// only requires DHKEM(X25519, HKDF-SHA256) with HKDF-SHA256/AES-128-GCM,
+// so that's all that's implemented here rather than a general-purpose
+// HPKE suite registry.
+const (
+	hpkeKEMX25519HKDFSHA256 uint16 = 0x0020
+	hpkeKDFHKDFSHA256       uint16 = 0x0001
+	hpkeAEADAES128GCM       uint16 = 0x0001
+
+	hpkeModeBase uint8 = 0x00
+
+	hpkeNsecret = sha256.Size // Nh for HKDF-SHA256
+	hpkeNk      = 16          // AES-128-GCM key size
+	hpkeNn      = 12          // AES-128-GCM nonce size
+)
+
+// hpkeSealBase implements RFC 9180 HPKE in mode_base (no PSK, no auth)
+// with DHKEM(X25519, HKDF-SHA256)/HKDF-SHA256/AES-128-GCM: it encapsulates
+// a fresh ephemeral key to pkR, derives the single-use key/nonce via the
+// HPKE key schedule, and seals pt under aad. It returns the encapsulated
+// key (enc) and the AEAD ciphertext, matching the single Seal-then-done
+// usage ECH needs — there's no Context to keep around afterwards.
+func hpkeSealBase(pkR *ecdh.PublicKey, info, aad, pt []byte) (enc, ciphertext []byte, err error) {
+	sharedSecret, enc, err := hpkeEncap(pkR)
+	if err != nil {
+		return nil, nil, fmt.Errorf("hpke encap: %w", err)
+	}
+
+	key, baseNonce, err := hpkeKeySchedule(sharedSecret, info)
+	if err != nil {
+		return nil, nil, fmt.Errorf("hpke key schedule: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("aes cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, fmt.Errorf("aes-gcm: %w", err)
+	}
+
+	// ECH seals exactly one message per context, so the sequence number
+	// used to derive the nonce is always zero: nonce == base_nonce.
+	ciphertext = aead.Seal(nil, baseNonce, pt, aad)
+	return enc, ciphertext, nil
+}
+
+// hpkeEncap implements DHKEM(X25519, HKDF-SHA256).Encap from RFC 9180
+// §4.1: generate an ephemeral X25519 keypair, DH it against pkR, and
+// derive the shared secret via ExtractAndExpand over the DH output and
+// the two serialized public keys.
+func hpkeEncap(pkR *ecdh.PublicKey) (sharedSecret, enc []byte, err error) {
+	skE, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate ephemeral keypair: %w", err)
+	}
+
+	dh, err := skE.ECDH(pkR)
+	if err != nil {
+		return nil, nil, fmt.Errorf("x25519 ecdh: %w", err)
+	}
+
+	enc = skE.PublicKey().Bytes()
+	kemContext := append(append([]byte{}, enc...), pkR.Bytes()...)
+
+	sharedSecret = kemExtractAndExpand(dh, kemContext)
+	return sharedSecret, enc, nil
+}
+
+// kemSuiteID is "KEM" || I2OSP(kem_id, 2) for DHKEM(X25519, HKDF-SHA256),
+// used as the suite_id input to LabeledExtract/LabeledExpand within the
+// KEM (RFC 9180 §4.1).
+var kemSuiteID = append([]byte("KEM"), be16(hpkeKEMX25519HKDFSHA256)...)
+
+func kemExtractAndExpand(dh, kemContext []byte) []byte {
+	eaePRK := labeledExtract(nil, "eae_prk", dh, kemSuiteID)
+	return labeledExpand(eaePRK, "shared_secret", kemContext, hpkeNsecret, kemSuiteID)
+}
+
+// hpkeSuiteID is "HPKE" || I2OSP(kem_id,2) || I2OSP(kdf_id,2) ||
+// I2OSP(aead_id,2), the suite_id used by the key schedule's own
+// LabeledExtract/LabeledExpand calls (RFC 9180 §5.1), distinct from
+// kemSuiteID above.
+var hpkeSuiteID = func() []byte {
+	id := []byte("HPKE")
+	id = append(id, be16(hpkeKEMX25519HKDFSHA256)...)
+	id = append(id, be16(hpkeKDFHKDFSHA256)...)
+	id = append(id, be16(hpkeAEADAES128GCM)...)
+	return id
+}()
+
+// hpkeKeySchedule implements RFC 9180 §5.1's KeySchedule for mode_base: no
+// PSK, so psk and psk_id are both empty. It returns the single-use AEAD
+// key and base_nonce; ECH never needs exporter_secret or a multi-message
+// Context, so neither is derived.
+func hpkeKeySchedule(sharedSecret, info []byte) (key, baseNonce []byte, err error) {
+	pskIDHash := labeledExtract(nil, "psk_id_hash", nil, hpkeSuiteID)
+	infoHash := labeledExtract(nil, "info_hash", info, hpkeSuiteID)
+
+	keyScheduleContext := make([]byte, 0, 1+len(pskIDHash)+len(infoHash))
+	keyScheduleContext = append(keyScheduleContext, hpkeModeBase)
+	keyScheduleContext = append(keyScheduleContext, pskIDHash...)
+	keyScheduleContext = append(keyScheduleContext, infoHash...)
+
+	secret := labeledExtract(sharedSecret, "secret", nil, hpkeSuiteID)
+
+	key = labeledExpand(secret, "key", keyScheduleContext, hpkeNk, hpkeSuiteID)
+	baseNonce = labeledExpand(secret, "base_nonce", keyScheduleContext, hpkeNn, hpkeSuiteID)
+	return key, baseNonce, nil
+}
+
+// hpkeVersionLabel is "HPKE-v1", prefixed onto every LabeledExtract and
+// LabeledExpand input per RFC 9180 §4.
+var hpkeVersionLabel = []byte("HPKE-v1")
+
+// labeledExtract implements RFC 9180 §4's LabeledExtract: HKDF-Extract
+// over "HPKE-v1" || suite_id || label || ikm, using HKDF-SHA256.
+func labeledExtract(salt []byte, label string, ikm, suiteID []byte) []byte {
+	labeledIKM := make([]byte, 0, len(hpkeVersionLabel)+len(suiteID)+len(label)+len(ikm))
+	labeledIKM = append(labeledIKM, hpkeVersionLabel...)
+	labeledIKM = append(labeledIKM, suiteID...)
+	labeledIKM = append(labeledIKM, label...)
+	labeledIKM = append(labeledIKM, ikm...)
+	return hkdfExtractSHA256(salt, labeledIKM)
+}
+
+// labeledExpand implements RFC 9180 §4's LabeledExpand: HKDF-Expand over
+// I2OSP(L,2) || "HPKE-v1" || suite_id || label || info, using HKDF-SHA256.
+func labeledExpand(prk []byte, label string, info []byte, length int, suiteID []byte) []byte {
+	labeledInfo := make([]byte, 0, 2+len(hpkeVersionLabel)+len(suiteID)+len(label)+len(info))
+	labeledInfo = append(labeledInfo, be16(uint16(length))...)
+	labeledInfo = append(labeledInfo, hpkeVersionLabel...)
+	labeledInfo = append(labeledInfo, suiteID...)
+	labeledInfo = append(labeledInfo, label...)
+	labeledInfo = append(labeledInfo, info...)
+	return hkdfExpandSHA256(prk, labeledInfo, length)
+}
+
+// hkdfExtractSHA256 implements RFC 5869's HKDF-Extract(salt, ikm) with
+// SHA-256: PRK = HMAC-Hash(salt, IKM), defaulting salt to Nh zero bytes
+// when empty, as HKDF specifies.
+func hkdfExtractSHA256(salt, ikm []byte) []byte {
+	if len(salt) == 0 {
+		salt = make([]byte, sha256.Size)
+	}
+	mac := hmac.New(sha256.New, salt)
+	mac.Write(ikm)
+	return mac.Sum(nil)
+}
+
+// hkdfExpandSHA256 implements RFC 5869's HKDF-Expand(prk, info, length)
+// with SHA-256.
+func hkdfExpandSHA256(prk, info []byte, length int) []byte {
+	out := make([]byte, 0, length+sha256.Size)
+	var t []byte
+	for counter := byte(1); len(out) < length; counter++ {
+		mac := hmac.New(sha256.New, prk)
+		mac.Write(t)
+		mac.Write(info)
+		mac.Write([]byte{counter})
+		t = mac.Sum(nil)
+		out = append(out, t...)
+	}
+	return out[:length]
+}
+
+func be16(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return b
+}
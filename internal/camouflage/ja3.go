@@ -0,0 +1,461 @@
+package camouflage
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Extension/type IDs this parser cares about. See RFC 8446 §4.2 and the
+// IANA TLS ExtensionType registry.
+const (
+	extServerName          = 0x0000
+	extSupportedGroups     = 0x000a
+	extECPointFormats      = 0x000b
+	extSignatureAlgorithms = 0x000d
+	extALPN                = 0x0010
+	extSupportedVersions   = 0x002b
+)
+
+// greaseValues are the reserved GREASE cipher/extension/group IDs from
+// RFC 8701 (all of the form 0xWaWa). JA3/JA4 exclude them so two Chrome
+// ClientHellos with different random GREASE picks still hash the same.
+var greaseValues = map[uint16]bool{
+	0x0a0a: true, 0x1a1a: true, 0x2a2a: true, 0x3a3a: true,
+	0x4a4a: true, 0x5a5a: true, 0x6a6a: true, 0x7a7a: true,
+	0x8a8a: true, 0x9a9a: true, 0xaaaa: true, 0xbaba: true,
+	0xcaca: true, 0xdada: true, 0xeaea: true, 0xfafa: true,
+}
+
+// ClientHello holds the fields of a parsed TLS ClientHello that JA3/JA4
+// are computed from. ParseClientHello fills it from the raw bytes
+// captured off the wire (not from crypto/tls.ClientHelloInfo, which
+// doesn't expose the extension list in its original order).
+type ClientHello struct {
+	LegacyVersion       uint16
+	CipherSuites        []uint16
+	Extensions          []uint16 // in on-the-wire order, GREASE included
+	SupportedGroups     []uint16
+	ECPointFormats      []uint8
+	SignatureAlgorithms []uint16
+	SupportedVersions   []uint16
+	SNI                 string
+	ALPN                []string
+}
+
+// ParseClientHello parses a raw TLS record containing a ClientHello
+// handshake message (as captured by a tap on the client's first Write to
+// the wire) into a ClientHello. It intentionally tolerates TLS 1.2 and
+// 1.3 ClientHellos equally since the interesting fields for
+// fingerprinting live in the handshake body, not the record layer.
+func ParseClientHello(raw []byte) (*ClientHello, error) {
+	r := &byteReader{buf: raw}
+
+	recordType, err := r.uint8()
+	if err != nil || recordType != 0x16 {
+		return nil, fmt.Errorf("not a TLS handshake record (type=%d)", recordType)
+	}
+	if _, err := r.skip(2); err != nil { // record-layer version
+		return nil, fmt.Errorf("truncated record header: %w", err)
+	}
+	recordLen, err := r.uint16()
+	if err != nil {
+		return nil, fmt.Errorf("truncated record header: %w", err)
+	}
+	body, err := r.bytes(int(recordLen))
+	if err != nil {
+		return nil, fmt.Errorf("truncated record body: %w", err)
+	}
+
+	h := &byteReader{buf: body}
+	msgType, err := h.uint8()
+	if err != nil || msgType != 0x01 {
+		return nil, fmt.Errorf("not a ClientHello handshake message (type=%d)", msgType)
+	}
+	hsLen, err := h.uint24()
+	if err != nil {
+		return nil, fmt.Errorf("truncated handshake header: %w", err)
+	}
+	hsBody, err := h.bytes(int(hsLen))
+	if err != nil {
+		return nil, fmt.Errorf("truncated handshake body: %w", err)
+	}
+
+	return parseClientHelloBody(hsBody)
+}
+
+func parseClientHelloBody(buf []byte) (*ClientHello, error) {
+	b := &byteReader{buf: buf}
+	ch := &ClientHello{}
+
+	legacyVersion, err := b.uint16()
+	if err != nil {
+		return nil, fmt.Errorf("read legacy version: %w", err)
+	}
+	ch.LegacyVersion = legacyVersion
+
+	if _, err := b.skip(32); err != nil { // random
+		return nil, fmt.Errorf("read random: %w", err)
+	}
+
+	sidLen, err := b.uint8()
+	if err != nil {
+		return nil, fmt.Errorf("read session id length: %w", err)
+	}
+	if _, err := b.skip(int(sidLen)); err != nil {
+		return nil, fmt.Errorf("read session id: %w", err)
+	}
+
+	cipherLen, err := b.uint16()
+	if err != nil {
+		return nil, fmt.Errorf("read cipher suites length: %w", err)
+	}
+	cipherBytes, err := b.bytes(int(cipherLen))
+	if err != nil {
+		return nil, fmt.Errorf("read cipher suites: %w", err)
+	}
+	ch.CipherSuites, err = uint16List(cipherBytes)
+	if err != nil {
+		return nil, fmt.Errorf("decode cipher suites: %w", err)
+	}
+
+	compLen, err := b.uint8()
+	if err != nil {
+		return nil, fmt.Errorf("read compression methods length: %w", err)
+	}
+	if _, err := b.skip(int(compLen)); err != nil {
+		return nil, fmt.Errorf("read compression methods: %w", err)
+	}
+
+	if b.remaining() == 0 {
+		// No extensions block; a legitimate (if ancient) ClientHello.
+		return ch, nil
+	}
+
+	extTotalLen, err := b.uint16()
+	if err != nil {
+		return nil, fmt.Errorf("read extensions length: %w", err)
+	}
+	extBytes, err := b.bytes(int(extTotalLen))
+	if err != nil {
+		return nil, fmt.Errorf("read extensions: %w", err)
+	}
+
+	if err := parseExtensions(extBytes, ch); err != nil {
+		return nil, fmt.Errorf("parse extensions: %w", err)
+	}
+	return ch, nil
+}
+
+func parseExtensions(buf []byte, ch *ClientHello) error {
+	e := &byteReader{buf: buf}
+	for e.remaining() > 0 {
+		extType, err := e.uint16()
+		if err != nil {
+			return err
+		}
+		extLen, err := e.uint16()
+		if err != nil {
+			return err
+		}
+		extData, err := e.bytes(int(extLen))
+		if err != nil {
+			return err
+		}
+
+		ch.Extensions = append(ch.Extensions, extType)
+
+		switch extType {
+		case extServerName:
+			ch.SNI = parseSNI(extData)
+		case extSupportedGroups:
+			ch.SupportedGroups, _ = uint16ListWithLenPrefix(extData)
+		case extECPointFormats:
+			ch.ECPointFormats = parseECPointFormats(extData)
+		case extSignatureAlgorithms:
+			ch.SignatureAlgorithms, _ = uint16ListWithLenPrefix(extData)
+		case extALPN:
+			ch.ALPN = parseALPN(extData)
+		case extSupportedVersions:
+			ch.SupportedVersions = parseSupportedVersions(extData)
+		}
+	}
+	return nil
+}
+
+func parseSNI(data []byte) string {
+	r := &byteReader{buf: data}
+	if _, err := r.skip(2); err != nil { // server_name_list length
+		return ""
+	}
+	for r.remaining() > 0 {
+		nameType, err := r.uint8()
+		if err != nil {
+			return ""
+		}
+		nameLen, err := r.uint16()
+		if err != nil {
+			return ""
+		}
+		name, err := r.bytes(int(nameLen))
+		if err != nil {
+			return ""
+		}
+		if nameType == 0 {
+			return string(name)
+		}
+	}
+	return ""
+}
+
+func parseALPN(data []byte) []string {
+	r := &byteReader{buf: data}
+	if _, err := r.skip(2); err != nil { // protocol_name_list length
+		return nil
+	}
+	var protos []string
+	for r.remaining() > 0 {
+		n, err := r.uint8()
+		if err != nil {
+			break
+		}
+		proto, err := r.bytes(int(n))
+		if err != nil {
+			break
+		}
+		protos = append(protos, string(proto))
+	}
+	return protos
+}
+
+func parseECPointFormats(data []byte) []uint8 {
+	r := &byteReader{buf: data}
+	n, err := r.uint8()
+	if err != nil {
+		return nil
+	}
+	formats, err := r.bytes(int(n))
+	if err != nil {
+		return nil
+	}
+	return append([]uint8(nil), formats...)
+}
+
+func parseSupportedVersions(data []byte) []uint16 {
+	r := &byteReader{buf: data}
+	n, err := r.uint8()
+	if err != nil {
+		return nil
+	}
+	versions, err := uint16List(func() []byte { b, _ := r.bytes(int(n)); return b }())
+	if err != nil {
+		return nil
+	}
+	return versions
+}
+
+func uint16List(b []byte) ([]uint16, error) {
+	if len(b)%2 != 0 {
+		return nil, fmt.Errorf("odd-length uint16 list (%d bytes)", len(b))
+	}
+	out := make([]uint16, len(b)/2)
+	for i := range out {
+		out[i] = binary.BigEndian.Uint16(b[i*2:])
+	}
+	return out, nil
+}
+
+func uint16ListWithLenPrefix(b []byte) ([]uint16, error) {
+	r := &byteReader{buf: b}
+	n, err := r.uint16()
+	if err != nil {
+		return nil, err
+	}
+	rest, err := r.bytes(int(n))
+	if err != nil {
+		return nil, err
+	}
+	return uint16List(rest)
+}
+
+// byteReader is a minimal bounds-checked cursor over a byte slice, used
+// instead of encoding/binary.Read + bytes.Reader so truncated/malformed
+// ClientHellos return an error rather than a panic.
+type byteReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *byteReader) remaining() int { return len(r.buf) - r.pos }
+
+func (r *byteReader) bytes(n int) ([]byte, error) {
+	if n < 0 || r.remaining() < n {
+		return nil, fmt.Errorf("want %d bytes, have %d", n, r.remaining())
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *byteReader) skip(n int) (struct{}, error) {
+	_, err := r.bytes(n)
+	return struct{}{}, err
+}
+
+func (r *byteReader) uint8() (uint8, error) {
+	b, err := r.bytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *byteReader) uint16() (uint16, error) {
+	b, err := r.bytes(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(b), nil
+}
+
+func (r *byteReader) uint24() (uint32, error) {
+	b, err := r.bytes(3)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2]), nil
+}
+
+// JA3String renders ch in the canonical JA3 format (SSLVersion,Ciphers,
+// Extensions,EllipticCurves,EllipticCurvePointFormats), GREASE values
+// stripped, fields dash-joined. See https://github.com/salesforce/ja3.
+func JA3String(ch *ClientHello) string {
+	return strings.Join([]string{
+		strconv.Itoa(int(ch.LegacyVersion)),
+		joinUint16(stripGrease16(ch.CipherSuites)),
+		joinUint16(stripGrease16(ch.Extensions)),
+		joinUint16(stripGrease16(ch.SupportedGroups)),
+		joinUint8(ch.ECPointFormats),
+	}, ",")
+}
+
+// JA3Hash is the MD5 hex digest of JA3String(ch), the value usually
+// called "the JA3 hash".
+func JA3Hash(ch *ClientHello) string {
+	sum := md5.Sum([]byte(JA3String(ch)))
+	return hex.EncodeToString(sum[:])
+}
+
+// JA4String renders ch in a simplified form of the public JA4 format
+// (https://github.com/FoxIO-LLC/ja4): "<proto><tlsver><sni><nciphers><nexts><alpn>_<cipher-hash>_<ext-hash>".
+// It matches the reference implementation's part-A encoding (protocol,
+// negotiated TLS version, SNI presence, counts, first ALPN value) and
+// reuses its "sort then truncated-SHA256" approach for parts B/C, but
+// isn't guaranteed byte-identical to the reference tool on every input —
+// good enough to compare two ClientHellos from the same fingerprinting
+// library, which is what AuditHandshake needs.
+func JA4String(ch *ClientHello) string {
+	proto := "t" // TCP; entropy-tunnel never runs JA4 over QUIC/DTLS
+
+	version := ch.LegacyVersion
+	for _, v := range ch.SupportedVersions {
+		if v > version {
+			version = v
+		}
+	}
+	tlsVer := ja4Version(version)
+
+	sniFlag := "i"
+	if ch.SNI != "" {
+		sniFlag = "d"
+	}
+
+	ciphers := stripGrease16(ch.CipherSuites)
+	exts := stripGrease16(ch.Extensions)
+
+	alpn := "00"
+	if len(ch.ALPN) > 0 && len(ch.ALPN[0]) >= 2 {
+		alpn = ch.ALPN[0][:2]
+	}
+
+	a := fmt.Sprintf("%s%s%s%02d%02d%s", proto, tlsVer, sniFlag, len(ciphers), len(exts), alpn)
+
+	b := truncatedSHA256(sortedHexList(ciphers))
+	var sigAlgos []uint16
+	if len(ch.SignatureAlgorithms) > 0 {
+		sigAlgos = ch.SignatureAlgorithms
+	}
+	c := truncatedSHA256(sortedHexList(exts) + "_" + sortedHexList(sigAlgos))
+
+	return fmt.Sprintf("%s_%s_%s", a, b, c)
+}
+
+// JA4Hash returns JA4String(ch); unlike JA3 the public JA4 format is
+// already a compact fixed-width fingerprint, so there's no separate MD5
+// step.
+func JA4Hash(ch *ClientHello) string {
+	return JA4String(ch)
+}
+
+func ja4Version(v uint16) string {
+	switch v {
+	case 0x0304:
+		return "13"
+	case 0x0303:
+		return "12"
+	case 0x0302:
+		return "11"
+	case 0x0301:
+		return "10"
+	default:
+		return "00"
+	}
+}
+
+func sortedHexList(vals []uint16) string {
+	hexVals := make([]string, len(vals))
+	for i, v := range vals {
+		hexVals[i] = fmt.Sprintf("%04x", v)
+	}
+	sort.Strings(hexVals)
+	return strings.Join(hexVals, ",")
+}
+
+func truncatedSHA256(s string) string {
+	if s == "" {
+		return "000000000000"
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func stripGrease16(vals []uint16) []uint16 {
+	out := make([]uint16, 0, len(vals))
+	for _, v := range vals {
+		if !greaseValues[v] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func joinUint16(vals []uint16) string {
+	parts := make([]string, len(vals))
+	for i, v := range vals {
+		parts[i] = strconv.Itoa(int(v))
+	}
+	return strings.Join(parts, "-")
+}
+
+func joinUint8(vals []uint8) string {
+	parts := make([]string, len(vals))
+	for i, v := range vals {
+		parts[i] = strconv.Itoa(int(v))
+	}
+	return strings.Join(parts, "-")
+}
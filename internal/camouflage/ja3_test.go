@@ -0,0 +1,164 @@
+package camouflage
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// buildClientHello assembles a minimal but well-formed TLS record +
+// handshake header around a ClientHello body, so tests can exercise
+// ParseClientHello without a real packet capture.
+func buildClientHello(t *testing.T, ciphers, extTypes []uint16, sni string) []byte {
+	t.Helper()
+
+	var body []byte
+	body = append(body, 0x03, 0x03)          // legacy_version: TLS 1.2
+	body = append(body, make([]byte, 32)...) // random
+	body = append(body, 0x00)                // session_id length
+
+	cipherBytes := make([]byte, 2+len(ciphers)*2)
+	binary.BigEndian.PutUint16(cipherBytes, uint16(len(ciphers)*2))
+	for i, c := range ciphers {
+		binary.BigEndian.PutUint16(cipherBytes[2+i*2:], c)
+	}
+	body = append(body, cipherBytes...)
+
+	body = append(body, 0x01, 0x00) // compression methods: length 1, "null"
+
+	var extBytes []byte
+	for _, et := range extTypes {
+		switch et {
+		case extServerName:
+			nameBytes := []byte(sni)
+			entry := make([]byte, 0, 5+len(nameBytes))
+			entry = append(entry, 0x00, 0x00) // server_name_list length placeholder
+			entry = append(entry, 0x00)       // name_type: host_name
+			nameLen := make([]byte, 2)
+			binary.BigEndian.PutUint16(nameLen, uint16(len(nameBytes)))
+			entry = append(entry, nameLen...)
+			entry = append(entry, nameBytes...)
+			binary.BigEndian.PutUint16(entry, uint16(len(entry)-2))
+			extBytes = append(extBytes, extHeader(et, entry)...)
+		case extSupportedGroups:
+			groups := []uint16{0x001d, 0x0017}
+			data := make([]byte, 2+len(groups)*2)
+			binary.BigEndian.PutUint16(data, uint16(len(groups)*2))
+			for i, g := range groups {
+				binary.BigEndian.PutUint16(data[2+i*2:], g)
+			}
+			extBytes = append(extBytes, extHeader(et, data)...)
+		case extECPointFormats:
+			data := []byte{0x01, 0x00}
+			extBytes = append(extBytes, extHeader(et, data)...)
+		case extALPN:
+			proto := []byte("h2")
+			data := []byte{0x00, byte(1 + len(proto)), byte(len(proto))}
+			data = append(data, proto...)
+			extBytes = append(extBytes, extHeader(et, data)...)
+		default:
+			extBytes = append(extBytes, extHeader(et, nil)...)
+		}
+	}
+
+	extHeaderLen := make([]byte, 2)
+	binary.BigEndian.PutUint16(extHeaderLen, uint16(len(extBytes)))
+	body = append(body, extHeaderLen...)
+	body = append(body, extBytes...)
+
+	handshake := make([]byte, 4+len(body))
+	handshake[0] = 0x01 // ClientHello
+	handshake[1] = byte(len(body) >> 16)
+	handshake[2] = byte(len(body) >> 8)
+	handshake[3] = byte(len(body))
+	copy(handshake[4:], body)
+
+	record := make([]byte, 5+len(handshake))
+	record[0] = 0x16 // handshake record
+	record[1], record[2] = 0x03, 0x01
+	binary.BigEndian.PutUint16(record[3:], uint16(len(handshake)))
+	copy(record[5:], handshake)
+
+	return record
+}
+
+func extHeader(extType uint16, data []byte) []byte {
+	out := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint16(out, extType)
+	binary.BigEndian.PutUint16(out[2:], uint16(len(data)))
+	copy(out[4:], data)
+	return out
+}
+
+func TestParseClientHello(t *testing.T) {
+	raw := buildClientHello(t,
+		[]uint16{0x0a0a, 0x1301, 0x1302},
+		[]uint16{extServerName, extSupportedGroups, extECPointFormats, extALPN},
+		"www.example.com",
+	)
+
+	ch, err := ParseClientHello(raw)
+	if err != nil {
+		t.Fatalf("ParseClientHello() error = %v", err)
+	}
+
+	if ch.SNI != "www.example.com" {
+		t.Errorf("SNI = %q, want %q", ch.SNI, "www.example.com")
+	}
+	if len(ch.CipherSuites) != 3 {
+		t.Fatalf("expected 3 cipher suites, got %d", len(ch.CipherSuites))
+	}
+	if len(ch.Extensions) != 4 {
+		t.Fatalf("expected 4 extensions, got %d", len(ch.Extensions))
+	}
+	if len(ch.ALPN) != 1 || ch.ALPN[0] != "h2" {
+		t.Errorf("ALPN = %v, want [h2]", ch.ALPN)
+	}
+}
+
+func TestParseClientHello_Truncated(t *testing.T) {
+	raw := buildClientHello(t, []uint16{0x1301}, nil, "")
+	if _, err := ParseClientHello(raw[:10]); err == nil {
+		t.Error("expected an error for a truncated ClientHello")
+	}
+}
+
+func TestJA3String_StripsGrease(t *testing.T) {
+	raw := buildClientHello(t,
+		[]uint16{0x0a0a, 0x1301, 0x1302},
+		[]uint16{0x1a1a, extServerName},
+		"example.com",
+	)
+	ch, err := ParseClientHello(raw)
+	if err != nil {
+		t.Fatalf("ParseClientHello() error = %v", err)
+	}
+
+	got := JA3String(ch)
+	if want := "771,4865-4866,0,,"; got != want {
+		t.Errorf("JA3String() = %q, want %q", got, want)
+	}
+	if JA3Hash(ch) == "" {
+		t.Error("expected a non-empty JA3 hash")
+	}
+}
+
+func TestJA4String_IsDeterministic(t *testing.T) {
+	raw := buildClientHello(t,
+		[]uint16{0x1301, 0x1302},
+		[]uint16{extServerName, extALPN},
+		"example.com",
+	)
+	ch, err := ParseClientHello(raw)
+	if err != nil {
+		t.Fatalf("ParseClientHello() error = %v", err)
+	}
+
+	a := JA4String(ch)
+	b := JA4String(ch)
+	if a != b {
+		t.Errorf("JA4String() not deterministic: %q != %q", a, b)
+	}
+	if a == "" {
+		t.Error("expected a non-empty JA4 string")
+	}
+}
@@ -0,0 +1,129 @@
+package camouflage
+
+import (
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// RollingFingerprint picks a new uTLS ClientHelloID per outbound REALITY
+// dial (via RandomFingerprint's weighted distribution) and tracks the
+// JA3/JA4 hash of the ClientHello actually emitted, so callers can verify
+// the wire fingerprint matches what was selected. A static Fingerprint
+// picked once at client build time is itself a fingerprint over a long
+// connection; rotating it approximates the variety a real user's browser
+// fleet would show.
+type RollingFingerprint struct {
+	logger *zap.Logger
+
+	mu      sync.RWMutex
+	current string // friendly name, e.g. "chrome" — what BuildClientJSON injects
+	utlsID  string // resolved uTLS ClientHelloID, e.g. "HelloChrome_Auto"
+	ja3     string
+	ja4     string
+}
+
+// NewRollingFingerprint creates a RollingFingerprint and picks its first
+// fingerprint.
+func NewRollingFingerprint(logger *zap.Logger) *RollingFingerprint {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	rf := &RollingFingerprint{logger: logger}
+	rf.Next()
+	return rf
+}
+
+// Next picks a new fingerprint via RandomFingerprint's weighted
+// distribution and returns its friendly name (the form BuildClientJSON's
+// "fingerprint" field expects). Call this once per outbound REALITY dial.
+func (rf *RollingFingerprint) Next() string {
+	name := RandomFingerprint()
+	utlsID, err := SelectFingerprint(name)
+	if err != nil {
+		// RandomFingerprint only ever returns names in
+		// SupportedFingerprints, so this is unreachable in practice;
+		// fall back to the same safe default SelectFingerprint itself
+		// documents.
+		name, utlsID = "chrome", SupportedFingerprints["chrome"]
+	}
+
+	rf.mu.Lock()
+	rf.current = name
+	rf.utlsID = utlsID
+	rf.mu.Unlock()
+
+	rf.logger.Debug("rolling fingerprint selected",
+		zap.String("name", name),
+		zap.String("utls_id", utlsID),
+	)
+	return name
+}
+
+// Current returns the friendly fingerprint name most recently picked by
+// Next.
+func (rf *RollingFingerprint) Current() string {
+	rf.mu.RLock()
+	defer rf.mu.RUnlock()
+	return rf.current
+}
+
+// RecordClientHello computes and stores the JA3/JA4 hash of the raw
+// ClientHello bytes actually emitted on the wire, so Verify can check the
+// real handshake against a reference rather than just trusting that the
+// requested fingerprint was honored.
+func (rf *RollingFingerprint) RecordClientHello(raw []byte) error {
+	ch, err := ParseClientHello(raw)
+	if err != nil {
+		return fmt.Errorf("record client hello: %w", err)
+	}
+
+	ja3, ja4 := JA3Hash(ch), JA4Hash(ch)
+
+	rf.mu.Lock()
+	rf.ja3, rf.ja4 = ja3, ja4
+	name := rf.current
+	rf.mu.Unlock()
+
+	rf.logger.Debug("rolling fingerprint emitted",
+		zap.String("name", name),
+		zap.String("ja3", ja3),
+		zap.String("ja4", ja4),
+	)
+	return nil
+}
+
+// JA3 returns the JA3 hash recorded by the most recent RecordClientHello
+// call, or "" if none has run yet.
+func (rf *RollingFingerprint) JA3() string {
+	rf.mu.RLock()
+	defer rf.mu.RUnlock()
+	return rf.ja3
+}
+
+// JA4 returns the JA4 hash recorded by the most recent RecordClientHello
+// call, or "" if none has run yet.
+func (rf *RollingFingerprint) JA4() string {
+	rf.mu.RLock()
+	defer rf.mu.RUnlock()
+	return rf.ja4
+}
+
+// Verify reports an error unless expected matches the JA3 or JA4 hash
+// recorded from the last emitted ClientHello — e.g. a reference captured
+// from a real Chrome or Firefox build — so tests and the API can catch a
+// uTLS fingerprint silently drifting from what it claims to present.
+func (rf *RollingFingerprint) Verify(expected string) error {
+	rf.mu.RLock()
+	ja3, ja4 := rf.ja3, rf.ja4
+	rf.mu.RUnlock()
+
+	if ja3 == "" && ja4 == "" {
+		return fmt.Errorf("rolling fingerprint: no ClientHello recorded yet")
+	}
+	if expected == ja3 || expected == ja4 {
+		return nil
+	}
+	return fmt.Errorf("rolling fingerprint mismatch: wire JA3=%q JA4=%q, expected %q", ja3, ja4, expected)
+}
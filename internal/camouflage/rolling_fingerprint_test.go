@@ -0,0 +1,55 @@
+package camouflage
+
+import "testing"
+
+func TestRollingFingerprint_Next(t *testing.T) {
+	rf := NewRollingFingerprint(nil)
+	if rf.Current() == "" {
+		t.Fatal("Current() is empty after construction")
+	}
+
+	name := rf.Next()
+	if name != rf.Current() {
+		t.Errorf("Next() = %q, Current() = %q, want equal", name, rf.Current())
+	}
+	if _, ok := SupportedFingerprints[name]; !ok {
+		t.Errorf("Next() returned unsupported fingerprint %q", name)
+	}
+}
+
+func TestRollingFingerprint_VerifyWithoutRecord(t *testing.T) {
+	rf := NewRollingFingerprint(nil)
+	if err := rf.Verify("anything"); err == nil {
+		t.Error("expected Verify to fail before any ClientHello is recorded")
+	}
+}
+
+func TestRollingFingerprint_RecordAndVerify(t *testing.T) {
+	rf := NewRollingFingerprint(nil)
+
+	raw := buildClientHello(t, []uint16{0x1301, 0x1302}, []uint16{extSupportedGroups}, "")
+	ch, err := ParseClientHello(raw)
+	if err != nil {
+		t.Fatalf("ParseClientHello() error = %v", err)
+	}
+	wantJA3 := JA3Hash(ch)
+	wantJA4 := JA4Hash(ch)
+
+	if err := rf.RecordClientHello(raw); err != nil {
+		t.Fatalf("RecordClientHello() error = %v", err)
+	}
+
+	if rf.JA3() != wantJA3 {
+		t.Errorf("JA3() = %q, want %q", rf.JA3(), wantJA3)
+	}
+	if rf.JA4() != wantJA4 {
+		t.Errorf("JA4() = %q, want %q", rf.JA4(), wantJA4)
+	}
+
+	if err := rf.Verify(wantJA3); err != nil {
+		t.Errorf("Verify(ja3) error = %v", err)
+	}
+	if err := rf.Verify("deadbeef"); err == nil {
+		t.Error("expected Verify to fail for a mismatched hash")
+	}
+}
@@ -0,0 +1,260 @@
+package camouflage
+
+import (
+	"crypto/rand"
+	"io"
+	"math"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ShapingConfig configures a Shaper's BuFLO/Tamaraw-style defense against
+// traffic analysis: fixed-size cells sent at a fixed cadence regardless of
+// whether there's real application data to carry, so packet timing and
+// size reveal nothing about the underlying traffic. NoiseInjector's
+// fixed-interval bursts of uniform random bytes are themselves a
+// fingerprint (burst size and gaps don't look like any real protocol);
+// Shaper replaces that with a constant-rate, constant-size cell stream.
+type ShapingConfig struct {
+	// SlotInterval (τ) is how often a cell is sent, real or dummy.
+	SlotInterval time.Duration
+
+	// CellSize (L) is the fixed size, in bytes, of every cell.
+	CellSize int
+
+	// Lambda (λ) is the mean rate, in cells/second, of the Poisson
+	// process dummy cells are drawn from when no real data is queued.
+	Lambda float64
+
+	// QuietQuantum (Q) is how many consecutive empty slots (no real
+	// data queued) end the flow, so its total length is always a
+	// multiple of Q·SlotInterval — hiding the true end-of-transfer.
+	QuietQuantum int
+}
+
+// DefaultShapingConfig returns the BuFLO defaults this package was built
+// around: a 20ms slot, 1500-byte cells (roughly an Ethernet MTU), a light
+// dummy rate, and a one-second quiet quantum.
+func DefaultShapingConfig() ShapingConfig {
+	return ShapingConfig{
+		SlotInterval: 20 * time.Millisecond,
+		CellSize:     1500,
+		Lambda:       5,
+		QuietQuantum: 50,
+	}
+}
+
+// Shaper wraps a net.Conn with BuFLO/Tamaraw-style traffic shaping: Write
+// enqueues application bytes without touching the network, and an internal
+// tick loop sends exactly one fixed-size cell every SlotInterval — real
+// data if any is queued, padded with random bytes when short, or a dummy
+// cell drawn from a Poisson process otherwise — until QuietQuantum
+// consecutive empty slots end the flow.
+type Shaper struct {
+	conn   net.Conn
+	cfg    ShapingConfig
+	logger *zap.Logger
+
+	// emptySlotProb is the probability, derived from Lambda and
+	// SlotInterval, that a dummy cell is sent in a slot with no real
+	// data queued.
+	emptySlotProb float64
+
+	mu       sync.Mutex
+	buf      []byte
+	closed   bool
+	writeErr error
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// NewShaper creates a Shaper around conn and starts its tick loop. Zero
+// fields in cfg fall back to DefaultShapingConfig.
+func NewShaper(conn net.Conn, cfg ShapingConfig, logger *zap.Logger) *Shaper {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	def := DefaultShapingConfig()
+	if cfg.SlotInterval <= 0 {
+		cfg.SlotInterval = def.SlotInterval
+	}
+	if cfg.CellSize <= 0 {
+		cfg.CellSize = def.CellSize
+	}
+	if cfg.QuietQuantum <= 0 {
+		cfg.QuietQuantum = def.QuietQuantum
+	}
+
+	s := &Shaper{
+		conn:          conn,
+		cfg:           cfg,
+		logger:        logger,
+		emptySlotProb: 1 - math.Exp(-cfg.Lambda*cfg.SlotInterval.Seconds()),
+		stopCh:        make(chan struct{}),
+		doneCh:        make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Write enqueues p to go out in the next cell(s); it never blocks on the
+// network. It returns an error once the flow has ended, either because
+// QuietQuantum was reached or the underlying conn failed.
+func (s *Shaper) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		if s.writeErr != nil {
+			return 0, s.writeErr
+		}
+		return 0, io.ErrClosedPipe
+	}
+
+	s.buf = append(s.buf, p...)
+	return len(p), nil
+}
+
+// Close stops the tick loop and closes the underlying connection.
+func (s *Shaper) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	close(s.stopCh)
+	<-s.doneCh
+	return s.conn.Close()
+}
+
+// run is the tick loop: one cell out per SlotInterval until stopCh closes
+// or the flow ends on its own (write failure or quiet quantum reached).
+func (s *Shaper) run() {
+	defer close(s.doneCh)
+
+	ticker := time.NewTicker(s.cfg.SlotInterval)
+	defer ticker.Stop()
+
+	emptyStreak := 0
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			cell, hasReal, err := s.nextCell()
+			if err != nil {
+				s.fail(err)
+				return
+			}
+			if cell != nil {
+				if _, err := s.conn.Write(cell); err != nil {
+					s.fail(err)
+					return
+				}
+			}
+
+			if hasReal {
+				emptyStreak = 0
+				continue
+			}
+			emptyStreak++
+			if emptyStreak >= s.cfg.QuietQuantum {
+				s.logger.Debug("shaper ending flow after quiet quantum",
+					zap.Int("quiet_quantum", s.cfg.QuietQuantum))
+				s.endFlow()
+				return
+			}
+		}
+	}
+}
+
+// nextCell pops up to CellSize bytes of queued real data (padding short
+// ones with random bytes to a full cell), or decides whether to emit a
+// dummy cell drawn from the configured Poisson process. It returns a nil
+// cell when neither applies this slot; hasReal says which branch was
+// taken, for the caller's quiet-quantum bookkeeping.
+func (s *Shaper) nextCell() (cell []byte, hasReal bool, err error) {
+	s.mu.Lock()
+	n := len(s.buf)
+	if n > s.cfg.CellSize {
+		n = s.cfg.CellSize
+	}
+	var real []byte
+	if n > 0 {
+		real = s.buf[:n]
+		s.buf = s.buf[n:]
+	}
+	s.mu.Unlock()
+
+	if real != nil {
+		out := make([]byte, s.cfg.CellSize)
+		copy(out, real)
+		if len(real) < s.cfg.CellSize {
+			if _, err := rand.Read(out[len(real):]); err != nil {
+				return nil, true, err
+			}
+		}
+		return out, true, nil
+	}
+
+	if !s.shouldSendDummy() {
+		return nil, false, nil
+	}
+
+	dummy := make([]byte, s.cfg.CellSize)
+	if _, err := rand.Read(dummy); err != nil {
+		return nil, false, err
+	}
+	return dummy, false, nil
+}
+
+// shouldSendDummy draws a single Bernoulli trial with probability
+// emptySlotProb, the chance a Poisson(λ) process has at least one arrival
+// during one slot.
+func (s *Shaper) shouldSendDummy() bool {
+	if s.emptySlotProb <= 0 {
+		return false
+	}
+	f, err := randFloat64()
+	if err != nil {
+		return false
+	}
+	return f < s.emptySlotProb
+}
+
+func (s *Shaper) fail(err error) {
+	s.mu.Lock()
+	s.closed = true
+	s.writeErr = err
+	s.mu.Unlock()
+	_ = s.conn.Close()
+	s.logger.Debug("shaper tick loop ended", zap.Error(err))
+}
+
+func (s *Shaper) endFlow() {
+	s.mu.Lock()
+	s.closed = true
+	s.writeErr = io.EOF
+	s.mu.Unlock()
+	_ = s.conn.Close()
+}
+
+// randFloat64 returns a uniform random float64 in [0, 1) using a
+// cryptographically secure source, matching the rest of this package's
+// preference for crypto/rand over math/rand.
+func randFloat64() (float64, error) {
+	const precision = 1 << 53
+	n, err := rand.Int(rand.Reader, big.NewInt(precision))
+	if err != nil {
+		return 0, err
+	}
+	return float64(n.Int64()) / float64(precision), nil
+}
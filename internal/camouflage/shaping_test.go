@@ -0,0 +1,64 @@
+package camouflage
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNewShaper_Defaults(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	s := NewShaper(client, ShapingConfig{}, nil)
+	defer s.Close()
+
+	if s.cfg.SlotInterval != DefaultShapingConfig().SlotInterval {
+		t.Errorf("SlotInterval = %v, want default %v", s.cfg.SlotInterval, DefaultShapingConfig().SlotInterval)
+	}
+	if s.cfg.CellSize != DefaultShapingConfig().CellSize {
+		t.Errorf("CellSize = %d, want default %d", s.cfg.CellSize, DefaultShapingConfig().CellSize)
+	}
+}
+
+func TestShaper_SendsFixedSizeCells(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	cfg := ShapingConfig{SlotInterval: 5 * time.Millisecond, CellSize: 64, Lambda: 0, QuietQuantum: 1000}
+	s := NewShaper(client, cfg, nil)
+	defer s.Close()
+
+	if _, err := s.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	buf := make([]byte, 64)
+	server.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := io.ReadFull(server, buf); err != nil {
+		t.Fatalf("reading shaped cell: %v", err)
+	}
+	if string(buf[:5]) != "hello" {
+		t.Errorf("cell payload = %q, want prefix %q", buf[:5], "hello")
+	}
+}
+
+func TestShaper_QuietQuantumEndsFlow(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	cfg := ShapingConfig{SlotInterval: time.Millisecond, CellSize: 16, Lambda: 0, QuietQuantum: 3}
+	s := NewShaper(client, cfg, nil)
+
+	select {
+	case <-s.doneCh:
+	case <-time.After(time.Second):
+		t.Fatal("shaper did not end flow after quiet quantum")
+	}
+
+	if _, err := s.Write([]byte("x")); err == nil {
+		t.Error("expected Write to fail after the flow ended")
+	}
+}
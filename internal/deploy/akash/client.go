@@ -1,4 +1,4 @@
-// Package akash provides Akash Network deployment integration for EntropyTunnel.
+// Package akash implements deploy.Provider for Akash Network.
 package akash
 
 import (
@@ -10,12 +10,11 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
-	"strings"
 	"time"
 
 	"go.uber.org/zap"
 
-	"github.com/fabiano/entropy-tunnel/internal/rotation"
+	"github.com/fabiano/entropy-tunnel/internal/deploy"
 )
 
 const (
@@ -23,34 +22,14 @@ const (
 	akashConsoleAPI = "https://console.akash.network/api/v1"
 )
 
-// Credentials holds the Xray server credentials generated in the container.
-type Credentials struct {
-	UUID      string `json:"uuid"`
-	PublicKey string `json:"public_key"`
-	ShortID   string `json:"short_id"`
-	Hostname  string `json:"hostname"`
-}
-
-// DeploymentInfo holds Akash deployment details.
-type DeploymentInfo struct {
-	DSeq      string    `json:"dseq"`
-	GSeq      int       `json:"gseq"`
-	OSeq      int       `json:"oseq"`
-	Provider  string    `json:"provider"`
-	LeaseID   string    `json:"lease_id"`
-	Status    string    `json:"status"`
-	URI       string    `json:"uri,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-}
-
-// Client provides Akash Network API interactions.
+// Client implements deploy.Provider against Akash Network.
 type Client struct {
 	apiKey     string
 	httpClient *http.Client
 	logger     *zap.Logger
 }
 
-// NewClient creates a new Akash API client.
+// NewClient creates a new Akash deploy.Provider.
 func NewClient(apiKey string, logger *zap.Logger) *Client {
 	if logger == nil {
 		logger = zap.NewNop()
@@ -64,17 +43,20 @@ func NewClient(apiKey string, logger *zap.Logger) *Client {
 	}
 }
 
-// Deploy creates a new deployment on Akash Network.
-func (c *Client) Deploy(ctx context.Context, sdlPath string) (*DeploymentInfo, error) {
-	c.logger.Info("deploying to Akash", zap.String("sdl", sdlPath))
+func (c *Client) Name() string { return "akash" }
 
-	// Read SDL file
-	sdlData, err := os.ReadFile(sdlPath)
+// Deploy creates a new deployment on Akash Network from spec.SDLPath.
+func (c *Client) Deploy(ctx context.Context, spec deploy.Spec) (*deploy.Deployment, error) {
+	if spec.SDLPath == "" {
+		return nil, fmt.Errorf("akash: spec.SDLPath is required")
+	}
+	c.logger.Info("deploying to Akash", zap.String("sdl", spec.SDLPath))
+
+	sdlData, err := os.ReadFile(spec.SDLPath)
 	if err != nil {
 		return nil, fmt.Errorf("reading SDL: %w", err)
 	}
 
-	// Create deployment via Cloudmos API
 	payload := map[string]interface{}{
 		"sdl": string(sdlData),
 	}
@@ -91,21 +73,20 @@ func (c *Client) Deploy(ctx context.Context, sdlPath string) (*DeploymentInfo, e
 		return nil, fmt.Errorf("parsing deployment response: %w", err)
 	}
 
-	info := &DeploymentInfo{
-		DSeq:      result.DSeq,
-		GSeq:      1,
-		OSeq:      1,
+	dep := &deploy.Deployment{
+		ID:        result.DSeq,
+		Provider:  c.Name(),
 		Status:    "pending",
 		CreatedAt: time.Now(),
 	}
 
-	c.logger.Info("deployment created", zap.String("dseq", info.DSeq))
-	return info, nil
+	c.logger.Info("deployment created", zap.String("dseq", dep.ID))
+	return dep, nil
 }
 
 // WaitForLease waits for the deployment to be leased and returns provider info.
-func (c *Client) WaitForLease(ctx context.Context, dseq string, timeout time.Duration) (*DeploymentInfo, error) {
-	c.logger.Info("waiting for lease", zap.String("dseq", dseq), zap.Duration("timeout", timeout))
+func (c *Client) WaitForLease(ctx context.Context, id string, timeout time.Duration) (*deploy.Deployment, error) {
+	c.logger.Info("waiting for lease", zap.String("dseq", id), zap.Duration("timeout", timeout))
 
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
@@ -118,27 +99,27 @@ func (c *Client) WaitForLease(ctx context.Context, dseq string, timeout time.Dur
 		case <-ctx.Done():
 			return nil, fmt.Errorf("timeout waiting for lease")
 		case <-ticker.C:
-			info, err := c.GetDeployment(ctx, dseq)
+			dep, err := c.GetDeployment(ctx, id)
 			if err != nil {
 				c.logger.Warn("failed to get deployment status", zap.Error(err))
 				continue
 			}
 
-			if info.Status == "active" && info.Provider != "" {
+			if dep.Status == "active" && dep.Metadata["akash_provider"] != "" {
 				c.logger.Info("lease acquired",
-					zap.String("provider", info.Provider),
-					zap.String("uri", info.URI))
-				return info, nil
+					zap.String("provider", dep.Metadata["akash_provider"]),
+					zap.String("address", dep.Address))
+				return dep, nil
 			}
 
-			c.logger.Info("deployment pending", zap.String("status", info.Status))
+			c.logger.Info("deployment pending", zap.String("status", dep.Status))
 		}
 	}
 }
 
 // GetDeployment retrieves deployment status.
-func (c *Client) GetDeployment(ctx context.Context, dseq string) (*DeploymentInfo, error) {
-	body, err := c.get(ctx, fmt.Sprintf("%s/deployments/%s", akashAPIBase, dseq))
+func (c *Client) GetDeployment(ctx context.Context, id string) (*deploy.Deployment, error) {
+	body, err := c.get(ctx, fmt.Sprintf("%s/deployments/%s", akashAPIBase, id))
 	if err != nil {
 		return nil, err
 	}
@@ -153,22 +134,59 @@ func (c *Client) GetDeployment(ctx context.Context, dseq string) (*DeploymentInf
 		return nil, fmt.Errorf("parsing response: %w", err)
 	}
 
-	return &DeploymentInfo{
-		DSeq:     result.DSeq,
+	return &deploy.Deployment{
+		ID:       result.DSeq,
+		Address:  result.URI,
+		Provider: c.Name(),
 		Status:   result.Status,
-		Provider: result.Provider,
-		URI:      result.URI,
+		Metadata: map[string]string{
+			"akash_provider": result.Provider,
+		},
 	}, nil
 }
 
+// ListDeployments lists the account's deployments on Akash Network and
+// narrows them to those matching filterExpr (see deploy.FilterDeployments).
+func (c *Client) ListDeployments(ctx context.Context, filterExpr string) ([]*deploy.Deployment, error) {
+	body, err := c.get(ctx, akashAPIBase+"/deployments")
+	if err != nil {
+		return nil, fmt.Errorf("listing deployments: %w", err)
+	}
+
+	var results []struct {
+		DSeq     string `json:"dseq"`
+		Status   string `json:"status"`
+		Provider string `json:"provider,omitempty"`
+		URI      string `json:"uri,omitempty"`
+	}
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, fmt.Errorf("parsing deployments response: %w", err)
+	}
+
+	deployments := make([]*deploy.Deployment, 0, len(results))
+	for _, r := range results {
+		deployments = append(deployments, &deploy.Deployment{
+			ID:       r.DSeq,
+			Address:  r.URI,
+			Provider: c.Name(),
+			Status:   r.Status,
+			Metadata: map[string]string{
+				"akash_provider": r.Provider,
+			},
+		})
+	}
+
+	return deploy.FilterDeployments(deployments, filterExpr)
+}
+
 // GetCredentials retrieves Xray credentials from the deployed container.
-func (c *Client) GetCredentials(ctx context.Context, leaseID string) (*Credentials, error) {
-	c.logger.Info("retrieving credentials", zap.String("lease_id", leaseID))
+func (c *Client) GetCredentials(ctx context.Context, id string) (*deploy.Credentials, error) {
+	c.logger.Info("retrieving credentials", zap.String("lease_id", id))
 
 	// Use Akash CLI to exec into container and get credentials
 	cmd := exec.CommandContext(ctx, "akash",
 		"provider", "lease-logs",
-		"--dseq", leaseID,
+		"--dseq", id,
 		"--provider", "", // Will be set from deployment
 		"--follow=false",
 	)
@@ -178,15 +196,14 @@ func (c *Client) GetCredentials(ctx context.Context, leaseID string) (*Credentia
 		return nil, fmt.Errorf("getting logs: %w, output: %s", err, string(output))
 	}
 
-	// Parse credentials from logs
-	return parseCredentials(string(output)), nil
+	return deploy.ParseCredentials(string(output)), nil
 }
 
 // CloseDeployment closes the deployment.
-func (c *Client) CloseDeployment(ctx context.Context, dseq string) error {
-	c.logger.Info("closing deployment", zap.String("dseq", dseq))
+func (c *Client) CloseDeployment(ctx context.Context, id string) error {
+	c.logger.Info("closing deployment", zap.String("dseq", id))
 
-	_, err := c.delete(ctx, fmt.Sprintf("%s/deployments/%s", akashAPIBase, dseq))
+	_, err := c.delete(ctx, fmt.Sprintf("%s/deployments/%s", akashAPIBase, id))
 	return err
 }
 
@@ -264,22 +281,5 @@ func (c *Client) delete(ctx context.Context, url string) ([]byte, error) {
 	return body, nil
 }
 
-func parseCredentials(logs string) *Credentials {
-	cred := &Credentials{}
-	lines := strings.Split(logs, "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, "UUID: ") {
-			cred.UUID = strings.TrimPrefix(line, "UUID: ")
-		} else if strings.HasPrefix(line, "PUBLIC_KEY: ") {
-			cred.PublicKey = strings.TrimPrefix(line, "PUBLIC_KEY: ")
-		} else if strings.HasPrefix(line, "SHORT_ID: ") {
-			cred.ShortID = strings.TrimPrefix(line, "SHORT_ID: ")
-		} else if strings.HasPrefix(line, "HOSTNAME: ") {
-			cred.Hostname = strings.TrimPrefix(line, "HOSTNAME: ")
-		}
-	}
-	return cred
-}
-
-// Compile-time interface check
-var _ rotation.Controller = (*Controller)(nil)
+// Compile-time interface check.
+var _ deploy.Provider = (*Client)(nil)
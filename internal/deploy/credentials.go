@@ -0,0 +1,25 @@
+package deploy
+
+import "strings"
+
+// ParseCredentials extracts the UUID/PUBLIC_KEY/SHORT_ID/HOSTNAME lines an
+// entropy-tunnel server container logs on startup. Every Provider that
+// retrieves credentials by reading container output (rather than a
+// structured API) shares this format, so backends parse it the same way
+// instead of each reimplementing the convention.
+func ParseCredentials(logs string) *Credentials {
+	cred := &Credentials{}
+	for _, line := range strings.Split(logs, "\n") {
+		switch {
+		case strings.HasPrefix(line, "UUID: "):
+			cred.UUID = strings.TrimPrefix(line, "UUID: ")
+		case strings.HasPrefix(line, "PUBLIC_KEY: "):
+			cred.PublicKey = strings.TrimPrefix(line, "PUBLIC_KEY: ")
+		case strings.HasPrefix(line, "SHORT_ID: "):
+			cred.ShortID = strings.TrimPrefix(line, "SHORT_ID: ")
+		case strings.HasPrefix(line, "HOSTNAME: "):
+			cred.Hostname = strings.TrimPrefix(line, "HOSTNAME: ")
+		}
+	}
+	return cred
+}
@@ -0,0 +1,217 @@
+// Package flyio implements deploy.Provider against the Fly.io Machines
+// API (https://fly.io/docs/machines/api/).
+package flyio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fabiano/entropy-tunnel/internal/deploy"
+)
+
+const machinesAPIBase = "https://api.machines.dev/v1"
+
+// Client implements deploy.Provider against the Fly.io Machines API.
+type Client struct {
+	apiToken   string
+	appName    string
+	httpClient *http.Client
+	logger     *zap.Logger
+}
+
+// NewClient creates a new Fly.io deploy.Provider. appName is the existing
+// Fly app machines are created under.
+func NewClient(apiToken, appName string, logger *zap.Logger) *Client {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Client{
+		apiToken:   apiToken,
+		appName:    appName,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		logger:     logger,
+	}
+}
+
+func (c *Client) Name() string { return "flyio" }
+
+type machineConfig struct {
+	Image string            `json:"image"`
+	Env   map[string]string `json:"env,omitempty"`
+}
+
+type createMachineRequest struct {
+	Region string        `json:"region,omitempty"`
+	Config machineConfig `json:"config"`
+}
+
+type machineResponse struct {
+	ID        string `json:"id"`
+	State     string `json:"state"`
+	Region    string `json:"region"`
+	PrivateIP string `json:"private_ip,omitempty"`
+}
+
+// Deploy creates a new Fly Machine running spec.Image.
+func (c *Client) Deploy(ctx context.Context, spec deploy.Spec) (*deploy.Deployment, error) {
+	if spec.Image == "" {
+		return nil, fmt.Errorf("flyio: spec.Image is required")
+	}
+	c.logger.Info("creating Fly machine", zap.String("app", c.appName), zap.String("image", spec.Image))
+
+	reqBody := createMachineRequest{
+		Region: spec.Region,
+		Config: machineConfig{Image: spec.Image, Env: spec.Env},
+	}
+
+	var m machineResponse
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("%s/apps/%s/machines", machinesAPIBase, c.appName), reqBody, &m); err != nil {
+		return nil, fmt.Errorf("creating machine: %w", err)
+	}
+
+	c.logger.Info("machine created", zap.String("id", m.ID), zap.String("state", m.State))
+
+	return &deploy.Deployment{
+		ID:        m.ID,
+		Provider:  c.Name(),
+		Status:    m.State,
+		CreatedAt: time.Now(),
+		Metadata:  map[string]string{"region": m.Region},
+	}, nil
+}
+
+// WaitForLease blocks on Fly's own wait endpoint until the machine reaches
+// the "started" state, then resolves to its 6PN private address.
+func (c *Client) WaitForLease(ctx context.Context, id string, timeout time.Duration) (*deploy.Deployment, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/apps/%s/machines/%s/wait?state=started&timeout=%d", machinesAPIBase, c.appName, id, int(timeout.Seconds()))
+	if err := c.do(ctx, http.MethodGet, url, nil, nil); err != nil {
+		return nil, fmt.Errorf("waiting for machine %s to start: %w", id, err)
+	}
+
+	dep, err := c.GetDeployment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Info("machine started", zap.String("id", id), zap.String("address", dep.Address))
+	return dep, nil
+}
+
+// GetDeployment retrieves the current state of a Fly machine.
+func (c *Client) GetDeployment(ctx context.Context, id string) (*deploy.Deployment, error) {
+	var m machineResponse
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/apps/%s/machines/%s", machinesAPIBase, c.appName, id), nil, &m); err != nil {
+		return nil, fmt.Errorf("getting machine %s: %w", id, err)
+	}
+
+	return &deploy.Deployment{
+		ID:       m.ID,
+		Address:  fmt.Sprintf("%s.vm.%s.internal", m.ID, c.appName),
+		Provider: c.Name(),
+		Status:   m.State,
+		Metadata: map[string]string{"region": m.Region},
+	}, nil
+}
+
+// ListDeployments lists every machine in the app and narrows them to
+// those matching filterExpr (see deploy.FilterDeployments).
+func (c *Client) ListDeployments(ctx context.Context, filterExpr string) ([]*deploy.Deployment, error) {
+	var machines []machineResponse
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/apps/%s/machines", machinesAPIBase, c.appName), nil, &machines); err != nil {
+		return nil, fmt.Errorf("listing machines: %w", err)
+	}
+
+	deployments := make([]*deploy.Deployment, 0, len(machines))
+	for _, m := range machines {
+		deployments = append(deployments, &deploy.Deployment{
+			ID:       m.ID,
+			Address:  fmt.Sprintf("%s.vm.%s.internal", m.ID, c.appName),
+			Provider: c.Name(),
+			Status:   m.State,
+			Metadata: map[string]string{"region": m.Region},
+		})
+	}
+
+	return deploy.FilterDeployments(deployments, filterExpr)
+}
+
+type execRequest struct {
+	Cmd []string `json:"cmd"`
+}
+
+type execResponse struct {
+	ExitCode int    `json:"exit_code"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+}
+
+// GetCredentials execs into the machine and reads back the Xray
+// credentials an entropy-tunnel container prints on startup.
+func (c *Client) GetCredentials(ctx context.Context, id string) (*deploy.Credentials, error) {
+	var result execResponse
+	req := execRequest{Cmd: []string{"cat", "/etc/entropy-tunnel/credentials.env"}}
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("%s/apps/%s/machines/%s/exec", machinesAPIBase, c.appName, id), req, &result); err != nil {
+		return nil, fmt.Errorf("exec on machine %s: %w", id, err)
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("exec on machine %s exited %d: %s", id, result.ExitCode, result.Stderr)
+	}
+
+	return deploy.ParseCredentials(result.Stdout), nil
+}
+
+// CloseDeployment force-destroys the machine.
+func (c *Client) CloseDeployment(ctx context.Context, id string) error {
+	c.logger.Info("destroying machine", zap.String("id", id))
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("%s/apps/%s/machines/%s?force=true", machinesAPIBase, c.appName, id), nil, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, url string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(respBody))
+	}
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("decoding response: %w", err)
+		}
+	}
+	return nil
+}
+
+// Compile-time interface check.
+var _ deploy.Provider = (*Client)(nil)
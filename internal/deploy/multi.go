@@ -0,0 +1,217 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// defaultLeaseTimeout bounds how long MultiProvider waits for any one
+// backend's lease before giving up on it (same default akash.Controller
+// used when it owned this wait directly).
+const defaultLeaseTimeout = 5 * time.Minute
+
+// MultiProvider fans a single Deploy out across several backend Providers
+// concurrently and keeps whichever comes up with a healthy lease first,
+// closing the rest — so a deployment can survive one backend (a cloud
+// account, a region, a host) being unavailable without waiting on it.
+// Subsequent calls for a given deployment ID are routed back to whichever
+// backend actually produced it.
+type MultiProvider struct {
+	providers []Provider
+	logger    *zap.Logger
+
+	mu     sync.RWMutex
+	owners map[string]Provider
+}
+
+// NewMultiProvider creates a MultiProvider fanning out to the given
+// backends, tried concurrently in every Deploy call.
+func NewMultiProvider(providers []Provider, logger *zap.Logger) *MultiProvider {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &MultiProvider{
+		providers: providers,
+		logger:    logger,
+		owners:    make(map[string]Provider),
+	}
+}
+
+func (m *MultiProvider) Name() string { return "multi" }
+
+type deployResult struct {
+	provider Provider
+	dep      *Deployment
+	err      error
+}
+
+// Deploy races spec across every backend: each one runs Deploy then
+// WaitForLease, and the first to come back with a healthy lease wins. Any
+// backend that succeeds after a winner is already chosen has its
+// deployment closed immediately rather than left running indefinitely.
+func (m *MultiProvider) Deploy(ctx context.Context, spec Spec) (*Deployment, error) {
+	if len(m.providers) == 0 {
+		return nil, fmt.Errorf("deploy: multi-provider has no backends configured")
+	}
+
+	fanCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan deployResult, len(m.providers))
+	for _, p := range m.providers {
+		p := p
+		go func() {
+			dep, err := p.Deploy(fanCtx, spec)
+			if err != nil {
+				results <- deployResult{provider: p, err: fmt.Errorf("%s: %w", p.Name(), err)}
+				return
+			}
+			leased, err := p.WaitForLease(fanCtx, dep.ID, defaultLeaseTimeout)
+			if err != nil {
+				_ = p.CloseDeployment(context.Background(), dep.ID)
+				results <- deployResult{provider: p, err: fmt.Errorf("%s: %w", p.Name(), err)}
+				return
+			}
+			results <- deployResult{provider: p, dep: leased}
+		}()
+	}
+
+	var winner *deployResult
+	var errs []error
+	for i := 0; i < len(m.providers); i++ {
+		r := <-results
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+		if winner != nil {
+			// A slower backend also succeeded after we already picked a
+			// winner; don't leave a second deployment running forever.
+			go func(r deployResult) {
+				_ = r.provider.CloseDeployment(context.Background(), r.dep.ID)
+			}(r)
+			continue
+		}
+		winner = &r
+		cancel() // tell the rest to give up
+	}
+
+	if winner == nil {
+		return nil, fmt.Errorf("deploy: all %d backends failed: %v", len(m.providers), errs)
+	}
+
+	m.mu.Lock()
+	m.owners[winner.dep.ID] = winner.provider
+	m.mu.Unlock()
+
+	m.logger.Info("multi-provider deploy won",
+		zap.String("provider", winner.provider.Name()),
+		zap.String("id", winner.dep.ID))
+
+	return winner.dep, nil
+}
+
+// WaitForLease is mostly a formality: by the time Deploy returns, the
+// winning backend has already leased the deployment. It's still routed
+// through so MultiProvider satisfies Provider like any other backend.
+func (m *MultiProvider) WaitForLease(ctx context.Context, id string, timeout time.Duration) (*Deployment, error) {
+	p, err := m.owner(id)
+	if err != nil {
+		return nil, err
+	}
+	return p.WaitForLease(ctx, id, timeout)
+}
+
+// GetDeployment routes to the backend that produced id.
+func (m *MultiProvider) GetDeployment(ctx context.Context, id string) (*Deployment, error) {
+	p, err := m.owner(id)
+	if err != nil {
+		return nil, err
+	}
+	return p.GetDeployment(ctx, id)
+}
+
+// ListDeployments queries every backend concurrently and concatenates
+// their matches; a backend whose list call fails is skipped rather than
+// failing the whole call, since a rotation controller scanning for
+// candidates generally cares more about what is reachable than about one
+// bad backend.
+func (m *MultiProvider) ListDeployments(ctx context.Context, filterExpr string) ([]*Deployment, error) {
+	type listResult struct {
+		deployments []*Deployment
+		err         error
+	}
+
+	results := make(chan listResult, len(m.providers))
+	for _, p := range m.providers {
+		p := p
+		go func() {
+			deps, err := p.ListDeployments(ctx, filterExpr)
+			if err != nil {
+				results <- listResult{err: fmt.Errorf("%s: %w", p.Name(), err)}
+				return
+			}
+			results <- listResult{deployments: deps}
+		}()
+	}
+
+	var all []*Deployment
+	var errs []error
+	for i := 0; i < len(m.providers); i++ {
+		r := <-results
+		if r.err != nil {
+			m.logger.Warn("backend failed to list deployments", zap.Error(r.err))
+			errs = append(errs, r.err)
+			continue
+		}
+		all = append(all, r.deployments...)
+	}
+
+	if len(errs) == len(m.providers) && len(m.providers) > 0 {
+		return nil, fmt.Errorf("list: all %d backends failed: %v", len(m.providers), errs)
+	}
+	return all, nil
+}
+
+// GetCredentials routes to the backend that produced id.
+func (m *MultiProvider) GetCredentials(ctx context.Context, id string) (*Credentials, error) {
+	p, err := m.owner(id)
+	if err != nil {
+		return nil, err
+	}
+	return p.GetCredentials(ctx, id)
+}
+
+// CloseDeployment routes to the backend that produced id and forgets the
+// mapping once torn down.
+func (m *MultiProvider) CloseDeployment(ctx context.Context, id string) error {
+	p, err := m.owner(id)
+	if err != nil {
+		return err
+	}
+	defer m.forget(id)
+	return p.CloseDeployment(ctx, id)
+}
+
+func (m *MultiProvider) owner(id string) (Provider, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	p, ok := m.owners[id]
+	if !ok {
+		return nil, fmt.Errorf("deploy: no backend owns deployment %q", id)
+	}
+	return p, nil
+}
+
+func (m *MultiProvider) forget(id string) {
+	m.mu.Lock()
+	delete(m.owners, id)
+	m.mu.Unlock()
+}
+
+// Compile-time interface check.
+var _ Provider = (*MultiProvider)(nil)
@@ -0,0 +1,120 @@
+// Package deploy defines the pluggable compute-deployment backend that
+// rotation controllers (and anything else standing up a fresh tunnel
+// server) consume instead of talking to one concrete cloud API directly —
+// modeled on the way Traefik unifies heterogeneous providers (docker,
+// consul, ecs, rancher, …) behind one config surface. Akash, Fly.io,
+// Railway, and a plain SSH/systemd host all implement the same Provider
+// interface in their own subpackage.
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fabiano/entropy-tunnel/internal/filter"
+)
+
+// Spec describes what to deploy, in terms generic enough for every
+// backend. Fields a given Provider doesn't use are simply ignored — e.g.
+// SDLPath only means anything to the Akash provider.
+type Spec struct {
+	// Image is the container image to run.
+	Image string
+
+	// Env are environment variables passed to the deployed container.
+	Env map[string]string
+
+	// Region is a best-effort scheduling hint; providers without
+	// per-region placement ignore it.
+	Region string
+
+	// SDLPath is an Akash SDL manifest path. Ignored by every provider
+	// except akash.
+	SDLPath string
+}
+
+// Deployment describes a provisioned (or still-provisioning) workload.
+// Provider-specific identifiers — Akash's DSeq, a Fly machine ID, a
+// Railway deployment ID, an SSH target host — all map onto ID.
+type Deployment struct {
+	ID        string
+	Address   string
+	Provider  string
+	Status    string
+	CreatedAt time.Time
+	Metadata  map[string]string
+}
+
+// Credentials holds the Xray server credentials generated inside the
+// deployed container, in the same shape regardless of backend.
+type Credentials struct {
+	UUID      string
+	PublicKey string
+	ShortID   string
+	Hostname  string
+}
+
+// Provider is the pluggable compute-deployment backend interface. Each
+// backend implements it in its own subpackage so callers like
+// rotation.Controller depend on this interface instead of a concrete
+// client type, and can be pointed at a different backend (or a
+// MultiProvider fanning out to several) without code changes.
+type Provider interface {
+	// Name identifies the backend, e.g. "akash", "flyio", "railway", "ssh".
+	Name() string
+
+	// Deploy stands up a new deployment from spec and returns it,
+	// typically still pending/provisioning.
+	Deploy(ctx context.Context, spec Spec) (*Deployment, error)
+
+	// WaitForLease blocks until the deployment identified by id is
+	// active and reachable, or timeout elapses.
+	WaitForLease(ctx context.Context, id string, timeout time.Duration) (*Deployment, error)
+
+	// GetDeployment retrieves the current status of a deployment.
+	GetDeployment(ctx context.Context, id string) (*Deployment, error)
+
+	// ListDeployments lists the backend's deployments, narrowed to those
+	// matching a filter expression (see package internal/filter for the
+	// expression language: "==", "!=", "<", ">", "matches", "in", and
+	// boolean and/or/not with parenthesization, evaluated against
+	// Deployment's fields). An empty filter matches everything.
+	ListDeployments(ctx context.Context, filterExpr string) ([]*Deployment, error)
+
+	// GetCredentials retrieves the Xray credentials generated inside the
+	// deployed container.
+	GetCredentials(ctx context.Context, id string) (*Credentials, error)
+
+	// CloseDeployment tears down a deployment.
+	CloseDeployment(ctx context.Context, id string) error
+}
+
+// FilterDeployments narrows deployments to those matching filterExpr, the
+// internal/filter expression language evaluated against each
+// *Deployment's fields. It's the common tail end of every backend's
+// ListDeployments: each fetches its own full list however its API shape
+// demands, then hands the result here instead of reimplementing
+// filtering. An empty filterExpr matches everything.
+func FilterDeployments(deployments []*Deployment, filterExpr string) ([]*Deployment, error) {
+	if filterExpr == "" {
+		return deployments, nil
+	}
+
+	expr, err := filter.Parse(filterExpr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing filter: %w", err)
+	}
+
+	var matched []*Deployment
+	for _, dep := range deployments {
+		ok, err := expr.Eval(dep)
+		if err != nil {
+			return nil, fmt.Errorf("evaluating filter: %w", err)
+		}
+		if ok {
+			matched = append(matched, dep)
+		}
+	}
+	return matched, nil
+}
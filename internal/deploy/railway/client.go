@@ -0,0 +1,316 @@
+// Package railway implements deploy.Provider against Railway's GraphQL API
+// (https://backboard.railway.app/graphql/v2).
+package railway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fabiano/entropy-tunnel/internal/deploy"
+)
+
+const railwayAPIBase = "https://backboard.railway.app/graphql/v2"
+
+// Client implements deploy.Provider against Railway.
+type Client struct {
+	apiToken      string
+	projectID     string
+	environmentID string
+	httpClient    *http.Client
+	logger        *zap.Logger
+}
+
+// NewClient creates a new Railway deploy.Provider. projectID and
+// environmentID identify the existing Railway project/environment
+// services are deployed into.
+func NewClient(apiToken, projectID, environmentID string, logger *zap.Logger) *Client {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Client{
+		apiToken:      apiToken,
+		projectID:     projectID,
+		environmentID: environmentID,
+		httpClient:    &http.Client{Timeout: 30 * time.Second},
+		logger:        logger,
+	}
+}
+
+func (c *Client) Name() string { return "railway" }
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []graphQLError  `json:"errors,omitempty"`
+}
+
+// Deploy creates a new Railway service from spec.Image.
+func (c *Client) Deploy(ctx context.Context, spec deploy.Spec) (*deploy.Deployment, error) {
+	if spec.Image == "" {
+		return nil, fmt.Errorf("railway: spec.Image is required")
+	}
+	c.logger.Info("creating Railway service", zap.String("image", spec.Image))
+
+	const mutation = `
+		mutation ServiceCreate($input: ServiceCreateInput!) {
+			serviceCreate(input: $input) {
+				id
+			}
+		}`
+
+	variables := map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId": c.projectID,
+			"source":    map[string]interface{}{"image": spec.Image},
+			"variables": spec.Env,
+		},
+	}
+
+	var result struct {
+		ServiceCreate struct {
+			ID string `json:"id"`
+		} `json:"serviceCreate"`
+	}
+	if err := c.query(ctx, mutation, variables, &result); err != nil {
+		return nil, fmt.Errorf("creating service: %w", err)
+	}
+
+	c.logger.Info("service created", zap.String("id", result.ServiceCreate.ID))
+
+	return &deploy.Deployment{
+		ID:        result.ServiceCreate.ID,
+		Provider:  c.Name(),
+		Status:    "pending",
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// WaitForLease polls the service's latest deployment until it reaches the
+// SUCCESS state.
+func (c *Client) WaitForLease(ctx context.Context, id string, timeout time.Duration) (*deploy.Deployment, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timeout waiting for Railway deployment %s", id)
+		case <-ticker.C:
+			dep, err := c.GetDeployment(ctx, id)
+			if err != nil {
+				c.logger.Warn("failed to get deployment status", zap.Error(err))
+				continue
+			}
+			if dep.Status == "SUCCESS" && dep.Address != "" {
+				c.logger.Info("service deployed", zap.String("id", id), zap.String("address", dep.Address))
+				return dep, nil
+			}
+			c.logger.Info("deployment pending", zap.String("id", id), zap.String("status", dep.Status))
+		}
+	}
+}
+
+// GetDeployment retrieves the current status and public domain of a
+// Railway service.
+func (c *Client) GetDeployment(ctx context.Context, id string) (*deploy.Deployment, error) {
+	const query = `
+		query Service($id: String!) {
+			service(id: $id) {
+				id
+				deployments(first: 1) {
+					edges { node { status } }
+				}
+				serviceInstances(first: 1) {
+					edges { node { domains { serviceDomains { domain } } } }
+				}
+			}
+		}`
+
+	var result struct {
+		Service struct {
+			ID          string `json:"id"`
+			Deployments struct {
+				Edges []struct {
+					Node struct {
+						Status string `json:"status"`
+					} `json:"node"`
+				} `json:"edges"`
+			} `json:"deployments"`
+			ServiceInstances struct {
+				Edges []struct {
+					Node struct {
+						Domains struct {
+							ServiceDomains []struct {
+								Domain string `json:"domain"`
+							} `json:"serviceDomains"`
+						} `json:"domains"`
+					} `json:"node"`
+				} `json:"edges"`
+			} `json:"serviceInstances"`
+		} `json:"service"`
+	}
+	if err := c.query(ctx, query, map[string]interface{}{"id": id}, &result); err != nil {
+		return nil, fmt.Errorf("getting service %s: %w", id, err)
+	}
+
+	status := "pending"
+	if len(result.Service.Deployments.Edges) > 0 {
+		status = result.Service.Deployments.Edges[0].Node.Status
+	}
+	address := ""
+	if len(result.Service.ServiceInstances.Edges) > 0 {
+		domains := result.Service.ServiceInstances.Edges[0].Node.Domains.ServiceDomains
+		if len(domains) > 0 {
+			address = domains[0].Domain
+		}
+	}
+
+	return &deploy.Deployment{
+		ID:       id,
+		Address:  address,
+		Provider: c.Name(),
+		Status:   status,
+	}, nil
+}
+
+// ListDeployments lists every service in the environment and narrows
+// them to those matching filterExpr (see deploy.FilterDeployments).
+func (c *Client) ListDeployments(ctx context.Context, filterExpr string) ([]*deploy.Deployment, error) {
+	const query = `
+		query Environment($id: String!) {
+			environment(id: $id) {
+				serviceInstances {
+					edges { node { serviceId latestDeployment { status } } }
+				}
+			}
+		}`
+
+	var result struct {
+		Environment struct {
+			ServiceInstances struct {
+				Edges []struct {
+					Node struct {
+						ServiceID        string `json:"serviceId"`
+						LatestDeployment struct {
+							Status string `json:"status"`
+						} `json:"latestDeployment"`
+					} `json:"node"`
+				} `json:"edges"`
+			} `json:"serviceInstances"`
+		} `json:"environment"`
+	}
+	if err := c.query(ctx, query, map[string]interface{}{"id": c.environmentID}, &result); err != nil {
+		return nil, fmt.Errorf("listing services: %w", err)
+	}
+
+	edges := result.Environment.ServiceInstances.Edges
+	deployments := make([]*deploy.Deployment, 0, len(edges))
+	for _, e := range edges {
+		deployments = append(deployments, &deploy.Deployment{
+			ID:       e.Node.ServiceID,
+			Provider: c.Name(),
+			Status:   e.Node.LatestDeployment.Status,
+		})
+	}
+
+	return deploy.FilterDeployments(deployments, filterExpr)
+}
+
+// GetCredentials fetches the deployment's build/runtime logs and parses
+// out the credentials an entropy-tunnel container prints on startup.
+func (c *Client) GetCredentials(ctx context.Context, id string) (*deploy.Credentials, error) {
+	const query = `
+		query DeploymentLogs($id: String!) {
+			deploymentLogs(deploymentId: $id, limit: 500) {
+				message
+			}
+		}`
+
+	var result struct {
+		DeploymentLogs []struct {
+			Message string `json:"message"`
+		} `json:"deploymentLogs"`
+	}
+	if err := c.query(ctx, query, map[string]interface{}{"id": id}, &result); err != nil {
+		return nil, fmt.Errorf("getting logs for %s: %w", id, err)
+	}
+
+	var logs bytes.Buffer
+	for _, line := range result.DeploymentLogs {
+		logs.WriteString(line.Message)
+		logs.WriteString("\n")
+	}
+
+	return deploy.ParseCredentials(logs.String()), nil
+}
+
+// CloseDeployment deletes the Railway service.
+func (c *Client) CloseDeployment(ctx context.Context, id string) error {
+	c.logger.Info("deleting service", zap.String("id", id))
+
+	const mutation = `
+		mutation ServiceDelete($id: String!) {
+			serviceDelete(id: $id)
+		}`
+	return c.query(ctx, mutation, map[string]interface{}{"id": id}, nil)
+}
+
+func (c *Client) query(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	reqBody, err := json.Marshal(graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, railwayAPIBase, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var gqlResp graphQLResponse
+	if err := json.Unmarshal(respBody, &gqlResp); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	if len(gqlResp.Errors) > 0 {
+		return fmt.Errorf("graphql error: %s", gqlResp.Errors[0].Message)
+	}
+	if out != nil && len(gqlResp.Data) > 0 {
+		if err := json.Unmarshal(gqlResp.Data, out); err != nil {
+			return fmt.Errorf("decoding data: %w", err)
+		}
+	}
+	return nil
+}
+
+// Compile-time interface check.
+var _ deploy.Provider = (*Client)(nil)
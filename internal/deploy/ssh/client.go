@@ -0,0 +1,192 @@
+// Package ssh implements deploy.Provider against a plain host reachable
+// over SSH, running the tunnel server as a systemd unit rather than
+// through any cloud control plane. It shells out to the local ssh/scp
+// binaries, the same way the akash provider shells out to the akash CLI,
+// instead of pulling in an SSH client library for the one thing this
+// package needs.
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fabiano/entropy-tunnel/internal/deploy"
+)
+
+// unitName is the systemd unit the deployed container runs under on every
+// host this provider manages.
+const unitName = "entropy-tunnel.service"
+
+// Client implements deploy.Provider against a single SSH-reachable host.
+// Unlike the other backends, it manages exactly one long-lived host per
+// Client rather than provisioning new ones — "rotation" for this provider
+// means restarting the unit with a new image/env, not standing up new
+// infrastructure. Pointing a rotation.Controller at several Clients
+// wrapped in a deploy.MultiProvider covers the multi-host case.
+type Client struct {
+	host    string // user@host, as passed to ssh/scp
+	sshArgs []string
+	logger  *zap.Logger
+}
+
+// NewClient creates a new SSH deploy.Provider. host is an SSH target in
+// "user@host" form; extraArgs are passed through to every ssh/scp
+// invocation (e.g. []string{"-i", "/path/to/key", "-p", "2222"}).
+func NewClient(host string, extraArgs []string, logger *zap.Logger) *Client {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Client{
+		host:    host,
+		sshArgs: extraArgs,
+		logger:  logger,
+	}
+}
+
+func (c *Client) Name() string { return "ssh" }
+
+// Deploy writes a fresh systemd unit for spec.Image/spec.Env to the host
+// and (re)starts it. The deployment ID is always the host itself, since
+// one Client only ever manages one host.
+func (c *Client) Deploy(ctx context.Context, spec deploy.Spec) (*deploy.Deployment, error) {
+	if spec.Image == "" {
+		return nil, fmt.Errorf("ssh: spec.Image is required")
+	}
+	c.logger.Info("deploying via SSH", zap.String("host", c.host), zap.String("image", spec.Image))
+
+	unit := renderUnit(spec)
+	remoteTmp := fmt.Sprintf("/tmp/%s.XXXXXX", unitName)
+	script := fmt.Sprintf(
+		`set -e; tmp=$(mktemp %s); cat > "$tmp" <<'UNIT'
+%s
+UNIT
+sudo mv "$tmp" /etc/systemd/system/%s
+sudo systemctl daemon-reload
+sudo systemctl enable --now %s
+sudo systemctl restart %s`,
+		remoteTmp, unit, unitName, unitName, unitName,
+	)
+
+	if _, err := c.run(ctx, script); err != nil {
+		return nil, fmt.Errorf("installing unit: %w", err)
+	}
+
+	return &deploy.Deployment{
+		ID:        c.host,
+		Provider:  c.Name(),
+		Status:    "pending",
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// WaitForLease polls systemctl until the unit reports "active".
+func (c *Client) WaitForLease(ctx context.Context, id string, timeout time.Duration) (*deploy.Deployment, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timeout waiting for %s to become active on %s", unitName, c.host)
+		case <-ticker.C:
+			dep, err := c.GetDeployment(ctx, id)
+			if err != nil {
+				c.logger.Warn("failed to check unit status", zap.Error(err))
+				continue
+			}
+			if dep.Status == "active" {
+				c.logger.Info("unit active", zap.String("host", c.host))
+				return dep, nil
+			}
+		}
+	}
+}
+
+// GetDeployment reports the systemd unit's current ActiveState.
+func (c *Client) GetDeployment(ctx context.Context, id string) (*deploy.Deployment, error) {
+	out, err := c.run(ctx, fmt.Sprintf("systemctl is-active %s || true", unitName))
+	if err != nil {
+		return nil, fmt.Errorf("checking unit status: %w", err)
+	}
+
+	return &deploy.Deployment{
+		ID:       id,
+		Address:  c.host,
+		Provider: c.Name(),
+		Status:   string(bytes.TrimSpace(out.Bytes())),
+	}, nil
+}
+
+// ListDeployments reports this Client's single host as its one
+// "deployment" — one Client only ever manages c.host — narrowed to
+// whether it matches filterExpr (see deploy.FilterDeployments).
+func (c *Client) ListDeployments(ctx context.Context, filterExpr string) ([]*deploy.Deployment, error) {
+	dep, err := c.GetDeployment(ctx, c.host)
+	if err != nil {
+		return nil, err
+	}
+	return deploy.FilterDeployments([]*deploy.Deployment{dep}, filterExpr)
+}
+
+// GetCredentials reads back the credentials the tunnel server writes to
+// its journal log on startup.
+func (c *Client) GetCredentials(ctx context.Context, id string) (*deploy.Credentials, error) {
+	out, err := c.run(ctx, fmt.Sprintf("sudo journalctl -u %s --no-pager -n 200", unitName))
+	if err != nil {
+		return nil, fmt.Errorf("reading journal: %w", err)
+	}
+	return deploy.ParseCredentials(out.String()), nil
+}
+
+// CloseDeployment stops and disables the unit.
+func (c *Client) CloseDeployment(ctx context.Context, id string) error {
+	c.logger.Info("stopping unit", zap.String("host", c.host))
+	_, err := c.run(ctx, fmt.Sprintf("sudo systemctl disable --now %s", unitName))
+	return err
+}
+
+func (c *Client) run(ctx context.Context, remoteScript string) (*bytes.Buffer, error) {
+	args := append(append([]string{}, c.sshArgs...), c.host, remoteScript)
+	cmd := exec.CommandContext(ctx, "ssh", args...)
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return &stdout, nil
+}
+
+func renderUnit(spec deploy.Spec) string {
+	var env bytes.Buffer
+	for k, v := range spec.Env {
+		fmt.Fprintf(&env, "Environment=%s=%s\n", k, v)
+	}
+
+	return fmt.Sprintf(`[Unit]
+Description=entropy-tunnel server
+After=network.target
+
+[Service]
+ExecStart=/usr/bin/docker run --rm --name entropy-tunnel --net=host %s
+Restart=always
+RestartSec=5
+%s
+[Install]
+WantedBy=multi-user.target
+`, spec.Image, env.String())
+}
+
+// Compile-time interface check.
+var _ deploy.Provider = (*Client)(nil)
@@ -0,0 +1,168 @@
+// Package events defines the bootstrap-progress event bus shared by the
+// tunnel, rotation, protocols, and payment packages. It lives in its own
+// leaf package (rather than under tunnel, where it originated) so that
+// tunnel can depend on rotation/protocols/payment for its dependency
+// container without an import cycle forming back through this bus.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// BootstrapEvent is published by a subsystem as it makes progress
+// connecting (contacting a broker, finishing a TLS handshake, rotating an
+// endpoint, falling back to another protocol). A GUI subscribes to these
+// to show a human-readable "contacting broker... got 2/3 peers..." trail
+// instead of parsing zap log lines.
+type BootstrapEvent struct {
+	Type      string    `json:"type"`
+	Subsystem string    `json:"subsystem"`
+	Time      time.Time `json:"time"`
+	Data      any       `json:"data,omitempty"`
+}
+
+// Known BootstrapEvent.Type values.
+const (
+	EventSnowflakeBrokerContacted = "snowflake_broker_contacted"
+	EventSnowflakePeerConnected   = "snowflake_peer_connected"
+	EventRealityHandshakeOK       = "reality_handshake_ok"
+	EventRotationSwitched         = "rotation_switched"
+	EventFallbackActivated        = "fallback_activated"
+	EventSubscriptionExpiring     = "subscription_expiring"
+	EventXrayProcessCrashed       = "xray_process_crashed"
+)
+
+// SnowflakePeerConnectedData is the Data payload for EventSnowflakePeerConnected.
+type SnowflakePeerConnectedData struct {
+	Index int `json:"index"`
+	Total int `json:"total"`
+}
+
+// RotationSwitchedData is the Data payload for EventRotationSwitched.
+type RotationSwitchedData struct {
+	OldID string `json:"old_id"`
+	NewID string `json:"new_id"`
+}
+
+// FallbackActivatedData is the Data payload for EventFallbackActivated.
+type FallbackActivatedData struct {
+	Protocol string `json:"protocol"`
+}
+
+// XrayProcessCrashedData is the Data payload for EventXrayProcessCrashed.
+type XrayProcessCrashedData struct {
+	Restarts int    `json:"restarts"`
+	GaveUp   bool   `json:"gave_up"`
+	Err      string `json:"err,omitempty"`
+}
+
+// SubscriptionExpiringData is the Data payload for EventSubscriptionExpiring.
+type SubscriptionExpiringData struct {
+	Email     string    `json:"email"`
+	PlanID    string    `json:"plan_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// maxHistory bounds how many past events Bus.History retains, so a
+// long-running server doesn't accumulate an unbounded event log.
+const maxHistory = 200
+
+// Bus fans out bootstrap progress events to any number of subscribers
+// (typically SSE connections on the local API) and keeps the last event
+// seen per subsystem so a late subscriber can render current state
+// immediately instead of waiting for the next change.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[chan BootstrapEvent]struct{}
+	lastBySub   map[string]BootstrapEvent
+	history     []BootstrapEvent
+}
+
+// NewBus creates an empty event bus.
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: make(map[chan BootstrapEvent]struct{}),
+		lastBySub:   make(map[string]BootstrapEvent),
+	}
+}
+
+// Publish records ev as the subsystem's latest state and fans it out to
+// every current subscriber. Subscribers that aren't keeping up have the
+// event dropped rather than blocking the publisher.
+func (b *Bus) Publish(subsystem, eventType string, data any) {
+	ev := BootstrapEvent{Type: eventType, Subsystem: subsystem, Time: time.Now(), Data: data}
+
+	b.mu.Lock()
+	b.lastBySub[subsystem] = ev
+	b.history = append(b.history, ev)
+	if len(b.history) > maxHistory {
+		b.history = b.history[len(b.history)-maxHistory:]
+	}
+	subs := make([]chan BootstrapEvent, 0, len(b.subscribers))
+	for ch := range b.subscribers {
+		subs = append(subs, ch)
+	}
+	b.mu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Subscribe registers a new listener and returns the channel it will
+// receive events on, plus an unsubscribe func the caller must invoke when
+// done (e.g. when the SSE connection closes).
+func (b *Bus) Subscribe() (<-chan BootstrapEvent, func()) {
+	ch := make(chan BootstrapEvent, 32)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		b.mu.Unlock()
+		close(ch)
+	}
+	return ch, unsubscribe
+}
+
+// LastState returns the most recent event published by each subsystem,
+// keyed by subsystem name. Used to populate GET /status.
+func (b *Bus) LastState() map[string]BootstrapEvent {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	out := make(map[string]BootstrapEvent, len(b.lastBySub))
+	for k, v := range b.lastBySub {
+		out[k] = v
+	}
+	return out
+}
+
+// History returns the last maxHistory events published, oldest first,
+// filtered to subsystem if it's non-empty. Used by the debug HTTP endpoint
+// to show a rotation timeline instead of just the latest state.
+func (b *Bus) History(subsystem string) []BootstrapEvent {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if subsystem == "" {
+		out := make([]BootstrapEvent, len(b.history))
+		copy(out, b.history)
+		return out
+	}
+
+	var out []BootstrapEvent
+	for _, ev := range b.history {
+		if ev.Subsystem == subsystem {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
@@ -0,0 +1,253 @@
+// Package filter implements a small Consul-catalog-style filter
+// expression language — "Status == \"active\" and Provider matches
+// \"^akash1.*\"" — compiled to a typed AST and evaluated against an
+// arbitrary record via reflection on its exported fields. It's shared by
+// deploy.Provider.ListDeployments (filtering deploy.Deployment) and
+// rotation's candidate-lease selection (filtering rotation.Endpoint),
+// rather than living under either package, so neither has to import the
+// other just to share a query language.
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expr is a parsed filter expression. Eval reports whether record matches
+// it; record is typically a *deploy.Deployment or *rotation.Endpoint, but
+// any struct with exported fields works.
+type Expr interface {
+	Eval(record any) (bool, error)
+}
+
+// Comparison matches one "Field op Value" term, e.g. Status == "active".
+type Comparison struct {
+	Field string
+	Op    string // "==", "!=", "<", ">", "<=", ">=", "matches"
+	Value string
+}
+
+// In matches "Field in (Value, Value, ...)".
+type In struct {
+	Field  string
+	Values []string
+}
+
+// And, Or, Not combine sub-expressions with the usual boolean semantics.
+type And struct{ Left, Right Expr }
+type Or struct{ Left, Right Expr }
+type Not struct{ X Expr }
+
+func (a *And) Eval(record any) (bool, error) {
+	l, err := a.Left.Eval(record)
+	if err != nil || !l {
+		return false, err
+	}
+	return a.Right.Eval(record)
+}
+
+func (o *Or) Eval(record any) (bool, error) {
+	l, err := o.Left.Eval(record)
+	if err != nil {
+		return false, err
+	}
+	if l {
+		return true, nil
+	}
+	return o.Right.Eval(record)
+}
+
+func (n *Not) Eval(record any) (bool, error) {
+	v, err := n.X.Eval(record)
+	if err != nil {
+		return false, err
+	}
+	return !v, nil
+}
+
+func (c *Comparison) Eval(record any) (bool, error) {
+	fv, ok := resolveField(record, c.Field)
+
+	if c.Op == "matches" {
+		re, err := regexp.Compile(c.Value)
+		if err != nil {
+			return false, fmt.Errorf("filter: invalid regexp %q: %w", c.Value, err)
+		}
+		return re.MatchString(fieldString(fv, ok)), nil
+	}
+
+	if !ok {
+		// An absent field only ever equals/compares-unequal to a value,
+		// same as an empty string would.
+		return compareStrings(c.Op, "", c.Value)
+	}
+
+	if t, isTime := asTime(fv); isTime {
+		want, err := parseTime(c.Value)
+		if err != nil {
+			return false, fmt.Errorf("filter: field %q is a time but value %q isn't: %w", c.Field, c.Value, err)
+		}
+		return compareTimes(c.Op, t, want)
+	}
+
+	if n, isNum := asFloat(fv); isNum {
+		want, err := strconv.ParseFloat(c.Value, 64)
+		if err != nil {
+			return false, fmt.Errorf("filter: field %q is numeric but value %q isn't: %w", c.Field, c.Value, err)
+		}
+		return compareFloats(c.Op, n, want)
+	}
+
+	return compareStrings(c.Op, fieldString(fv, ok), c.Value)
+}
+
+func (in *In) Eval(record any) (bool, error) {
+	fv, ok := resolveField(record, in.Field)
+	actual := fieldString(fv, ok)
+	for _, v := range in.Values {
+		if actual == v {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// resolveField looks up name against record's exported fields. A dotted
+// name ("Metadata.region") indexes into a map-typed field by the
+// remainder of the path, which is how Comparison/In reach
+// deploy.Deployment.Metadata or rotation.Endpoint.Metadata entries.
+func resolveField(record any, name string) (reflect.Value, bool) {
+	v := reflect.ValueOf(record)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+
+	field, rest, dotted := strings.Cut(name, ".")
+	fv := v.FieldByName(field)
+	if !fv.IsValid() {
+		return reflect.Value{}, false
+	}
+	if !dotted {
+		return fv, true
+	}
+	if fv.Kind() != reflect.Map {
+		return reflect.Value{}, false
+	}
+	mv := fv.MapIndex(reflect.ValueOf(rest))
+	if !mv.IsValid() {
+		return reflect.Value{}, false
+	}
+	return mv, true
+}
+
+func fieldString(fv reflect.Value, ok bool) string {
+	if !ok {
+		return ""
+	}
+	if t, isTime := asTime(fv); isTime {
+		return t.Format(time.RFC3339)
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		return fv.String()
+	case reflect.Interface:
+		return fmt.Sprint(fv.Interface())
+	default:
+		return fmt.Sprint(fv.Interface())
+	}
+}
+
+func asTime(fv reflect.Value) (time.Time, bool) {
+	t, ok := fv.Interface().(time.Time)
+	return t, ok
+}
+
+func asFloat(fv reflect.Value) (float64, bool) {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// parseTime accepts either a full RFC3339 timestamp or a bare date, the
+// two forms the filter language's examples ("2024-01-01") and
+// deploy.Deployment.CreatedAt's actual format both need.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+func compareTimes(op string, a, b time.Time) (bool, error) {
+	switch op {
+	case "==":
+		return a.Equal(b), nil
+	case "!=":
+		return !a.Equal(b), nil
+	case "<":
+		return a.Before(b), nil
+	case "<=":
+		return a.Before(b) || a.Equal(b), nil
+	case ">":
+		return a.After(b), nil
+	case ">=":
+		return a.After(b) || a.Equal(b), nil
+	default:
+		return false, fmt.Errorf("filter: unsupported operator %q for a time field", op)
+	}
+}
+
+func compareFloats(op string, a, b float64) (bool, error) {
+	switch op {
+	case "==":
+		return a == b, nil
+	case "!=":
+		return a != b, nil
+	case "<":
+		return a < b, nil
+	case "<=":
+		return a <= b, nil
+	case ">":
+		return a > b, nil
+	case ">=":
+		return a >= b, nil
+	default:
+		return false, fmt.Errorf("filter: unsupported operator %q for a numeric field", op)
+	}
+}
+
+func compareStrings(op string, a, b string) (bool, error) {
+	switch op {
+	case "==":
+		return a == b, nil
+	case "!=":
+		return a != b, nil
+	case "<":
+		return a < b, nil
+	case "<=":
+		return a <= b, nil
+	case ">":
+		return a > b, nil
+	case ">=":
+		return a >= b, nil
+	default:
+		return false, fmt.Errorf("filter: unsupported operator %q for a string field", op)
+	}
+}
@@ -0,0 +1,104 @@
+package filter
+
+import (
+	"testing"
+	"time"
+)
+
+type testDeployment struct {
+	ID        string
+	Provider  string
+	Status    string
+	CreatedAt time.Time
+	Metadata  map[string]string
+}
+
+func TestParseAndEval(t *testing.T) {
+	record := &testDeployment{
+		ID:        "dep-1",
+		Provider:  "akash1abc",
+		Status:    "active",
+		CreatedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		Metadata:  map[string]string{"region": "us-east"},
+	}
+
+	tests := []struct {
+		name    string
+		expr    string
+		want    bool
+		wantErr bool
+	}{
+		{name: "equality match", expr: `Status == "active"`, want: true},
+		{name: "equality mismatch", expr: `Status == "pending"`, want: false},
+		{name: "not equal", expr: `Status != "pending"`, want: true},
+		{name: "regexp match", expr: `Provider matches "^akash1.*"`, want: true},
+		{name: "regexp mismatch", expr: `Provider matches "^aws.*"`, want: false},
+		{name: "time greater than", expr: `CreatedAt > "2024-01-01"`, want: true},
+		{name: "time less than", expr: `CreatedAt < "2024-01-01"`, want: false},
+		{name: "in list", expr: `Status in ("pending", "active")`, want: true},
+		{name: "in list miss", expr: `Status in ("pending", "closed")`, want: false},
+		{name: "metadata dotted field", expr: `Metadata.region == "us-east"`, want: true},
+		{name: "and both true", expr: `Status == "active" and Provider matches "^akash1.*"`, want: true},
+		{name: "and one false", expr: `Status == "active" and Provider matches "^aws.*"`, want: false},
+		{name: "or", expr: `Status == "closed" or Provider matches "^akash1.*"`, want: true},
+		{name: "not", expr: `not (Status == "closed")`, want: true},
+		{name: "parenthesized precedence", expr: `(Status == "closed" or Status == "active") and Provider matches "^akash1.*"`, want: true},
+		{name: "compound from request body", expr: `Status == "active" and Provider matches "^akash1.*" and CreatedAt > "2024-01-01"`, want: true},
+		{name: "unknown field", expr: `Nonexistent == "x"`, want: false},
+		{name: "syntax error missing operator", expr: `Status "active"`, wantErr: true},
+		{name: "syntax error unterminated string", expr: `Status == "active`, wantErr: true},
+		{name: "syntax error trailing input", expr: `Status == "active" )`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, err := expr.Eval(record)
+			if err != nil {
+				t.Fatalf("Eval() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Eval(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEval_NumericField(t *testing.T) {
+	type numRecord struct {
+		Count int
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		want bool
+	}{
+		{name: "int equal", expr: `Count == "3"`, want: true},
+		{name: "int greater", expr: `Count > "1"`, want: true},
+		{name: "int less", expr: `Count < "1"`, want: false},
+	}
+
+	record := &numRecord{Count: 3}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.expr, err)
+			}
+			got, err := expr.Eval(record)
+			if err != nil {
+				t.Fatalf("Eval() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Eval(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tokenKind identifies a lexical token in a filter expression.
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokOp // == != < > <= >=
+	tokLParen
+	tokRParen
+	tokComma
+	tokAnd
+	tokOr
+	tokNot
+	tokIn
+	tokMatches
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// lex tokenizes src into tokens, recognizing quoted strings, the
+// comparison operators, parens/commas, and the and/or/not/in/matches
+// keywords. Anything else that looks like an identifier (field names)
+// is returned as tokIdent.
+func lex(src string) ([]token, error) {
+	var toks []token
+	r := []rune(src)
+	i := 0
+	for i < len(r) {
+		c := r[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			toks = append(toks, token{tokLParen, "("})
+			i++
+		case c == ')':
+			toks = append(toks, token{tokRParen, ")"})
+			i++
+		case c == ',':
+			toks = append(toks, token{tokComma, ","})
+			i++
+		case c == '"':
+			j := i + 1
+			var sb strings.Builder
+			closed := false
+			for j < len(r) {
+				if r[j] == '"' {
+					closed = true
+					break
+				}
+				sb.WriteRune(r[j])
+				j++
+			}
+			if !closed {
+				return nil, fmt.Errorf("filter: unterminated string starting at %d", i)
+			}
+			toks = append(toks, token{tokString, sb.String()})
+			i = j + 1
+		case c == '=' && i+1 < len(r) && r[i+1] == '=':
+			toks = append(toks, token{tokOp, "=="})
+			i += 2
+		case c == '!' && i+1 < len(r) && r[i+1] == '=':
+			toks = append(toks, token{tokOp, "!="})
+			i += 2
+		case c == '<' && i+1 < len(r) && r[i+1] == '=':
+			toks = append(toks, token{tokOp, "<="})
+			i += 2
+		case c == '>' && i+1 < len(r) && r[i+1] == '=':
+			toks = append(toks, token{tokOp, ">="})
+			i += 2
+		case c == '<':
+			toks = append(toks, token{tokOp, "<"})
+			i++
+		case c == '>':
+			toks = append(toks, token{tokOp, ">"})
+			i++
+		case isIdentRune(c):
+			j := i
+			for j < len(r) && isIdentRune(r[j]) {
+				j++
+			}
+			word := string(r[i:j])
+			toks = append(toks, keywordOrIdent(word))
+			i = j
+		default:
+			return nil, fmt.Errorf("filter: unexpected character %q at %d", c, i)
+		}
+	}
+	toks = append(toks, token{tokEOF, ""})
+	return toks, nil
+}
+
+func isIdentRune(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '.'
+}
+
+func keywordOrIdent(word string) token {
+	switch word {
+	case "and":
+		return token{tokAnd, word}
+	case "or":
+		return token{tokOr, word}
+	case "not":
+		return token{tokNot, word}
+	case "in":
+		return token{tokIn, word}
+	case "matches":
+		return token{tokMatches, word}
+	default:
+		return token{tokIdent, word}
+	}
+}
+
+// parser is a small recursive-descent parser over the grammar:
+//
+//	expr       := orExpr
+//	orExpr     := andExpr ("or" andExpr)*
+//	andExpr    := unary ("and" unary)*
+//	unary      := "not" unary | primary
+//	primary    := "(" expr ")" | comparison
+//	comparison := IDENT ("==" | "!=" | "<" | ">" | "<=" | ">=" | "matches") STRING
+//	           |  IDENT "in" "(" STRING ("," STRING)* ")"
+type parser struct {
+	toks []token
+	pos  int
+}
+
+// Parse compiles a filter expression into an Expr that Comparison/In/And
+// /Or/Not's Eval methods can evaluate against a record.
+func Parse(src string) (Expr, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("filter: unexpected trailing input near %q", p.peek().text)
+	}
+	return expr, nil
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if p.pos < len(p.toks)-1 {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	if p.peek().kind != kind {
+		return token{}, fmt.Errorf("filter: expected %s near %q", what, p.peek().text)
+	}
+	return p.next(), nil
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{X: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	if p.peek().kind == tokLParen {
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRParen, "\")\""); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	field, err := p.expect(tokIdent, "a field name")
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.peek().kind {
+	case tokOp:
+		op := p.next()
+		val, err := p.expect(tokString, "a quoted value")
+		if err != nil {
+			return nil, err
+		}
+		return &Comparison{Field: field.text, Op: op.text, Value: val.text}, nil
+	case tokMatches:
+		p.next()
+		val, err := p.expect(tokString, "a quoted regexp")
+		if err != nil {
+			return nil, err
+		}
+		return &Comparison{Field: field.text, Op: "matches", Value: val.text}, nil
+	case tokIn:
+		p.next()
+		if _, err := p.expect(tokLParen, "\"(\""); err != nil {
+			return nil, err
+		}
+		var values []string
+		for {
+			val, err := p.expect(tokString, "a quoted value")
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, val.text)
+			if p.peek().kind == tokComma {
+				p.next()
+				continue
+			}
+			break
+		}
+		if _, err := p.expect(tokRParen, "\")\""); err != nil {
+			return nil, err
+		}
+		return &In{Field: field.text, Values: values}, nil
+	default:
+		return nil, fmt.Errorf("filter: expected an operator after %q, got %q", field.text, p.peek().text)
+	}
+}
@@ -0,0 +1,231 @@
+package payment
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LNURLPayClient resolves an LNURL-pay endpoint and requests a BOLT11
+// invoice from it directly, bypassing BTCPay for wallets/services that
+// only expose LNURL-p (e.g. a user's own Lightning address).
+type LNURLPayClient struct {
+	client *http.Client
+}
+
+// NewLNURLPayClient creates an LNURL-pay client.
+func NewLNURLPayClient() *LNURLPayClient {
+	return &LNURLPayClient{client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+type lnurlPayParams struct {
+	Callback       string `json:"callback"`
+	MaxSendable    int64  `json:"maxSendable"`
+	MinSendable    int64  `json:"minSendable"`
+	Metadata       string `json:"metadata"`
+	CommentAllowed int    `json:"commentAllowed"`
+	Tag            string `json:"tag"`
+}
+
+type lnurlCallbackResponse struct {
+	PR            string `json:"pr"`
+	Routes        []any  `json:"routes"`
+	Status        string `json:"status"`
+	Reason        string `json:"reason"`
+	SuccessAction any    `json:"successAction,omitempty"`
+}
+
+// RequestInvoice resolves the LNURL-p endpoint for address (either a
+// "user@domain" Lightning address or a raw https:// LNURL endpoint),
+// requests an invoice for amountMsats, and verifies the returned BOLT11's
+// description hash matches the metadata the endpoint advertised — the
+// anti-tamper check LNURL-pay requires of every compliant client.
+func (l *LNURLPayClient) RequestInvoice(ctx context.Context, address string, amountMsats int64) (string, error) {
+	endpoint, err := resolveLNURLEndpoint(address)
+	if err != nil {
+		return "", fmt.Errorf("resolve LNURL endpoint: %w", err)
+	}
+
+	params, err := l.fetchPayParams(ctx, endpoint)
+	if err != nil {
+		return "", fmt.Errorf("fetch pay params: %w", err)
+	}
+	if amountMsats < params.MinSendable || amountMsats > params.MaxSendable {
+		return "", fmt.Errorf("amount %d msats outside allowed range [%d, %d]",
+			amountMsats, params.MinSendable, params.MaxSendable)
+	}
+
+	cb, err := l.fetchCallback(ctx, params, amountMsats)
+	if err != nil {
+		return "", fmt.Errorf("fetch invoice from callback: %w", err)
+	}
+	if cb.Status == "ERROR" {
+		return "", fmt.Errorf("LNURL callback error: %s", cb.Reason)
+	}
+	if cb.PR == "" {
+		return "", fmt.Errorf("LNURL callback returned no invoice")
+	}
+
+	if err := verifyDescriptionHash(cb.PR, params.Metadata); err != nil {
+		return "", fmt.Errorf("description hash mismatch: %w", err)
+	}
+
+	return cb.PR, nil
+}
+
+func (l *LNURLPayClient) fetchPayParams(ctx context.Context, endpoint string) (*lnurlPayParams, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var params lnurlPayParams
+	if err := json.NewDecoder(resp.Body).Decode(&params); err != nil {
+		return nil, err
+	}
+	if params.Tag != "payRequest" {
+		return nil, fmt.Errorf("endpoint is not an LNURL-pay request (tag=%q)", params.Tag)
+	}
+	return &params, nil
+}
+
+func (l *LNURLPayClient) fetchCallback(ctx context.Context, params *lnurlPayParams, amountMsats int64) (*lnurlCallbackResponse, error) {
+	sep := "?"
+	if strings.Contains(params.Callback, "?") {
+		sep = "&"
+	}
+	url := fmt.Sprintf("%s%samount=%d", params.Callback, sep, amountMsats)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var cb lnurlCallbackResponse
+	if err := json.NewDecoder(resp.Body).Decode(&cb); err != nil {
+		return nil, err
+	}
+	return &cb, nil
+}
+
+// resolveLNURLEndpoint turns a Lightning address ("user@domain") into its
+// well-known LNURL-pay URL, or passes an explicit https:// endpoint through.
+func resolveLNURLEndpoint(address string) (string, error) {
+	if strings.HasPrefix(address, "https://") || strings.HasPrefix(address, "http://") {
+		return address, nil
+	}
+
+	parts := strings.SplitN(address, "@", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid Lightning address %q, want user@domain", address)
+	}
+	user, domain := parts[0], parts[1]
+	return fmt.Sprintf("https://%s/.well-known/lnurlp/%s", domain, user), nil
+}
+
+// verifyDescriptionHash checks that the BOLT11 invoice's description-hash
+// field matches sha256(metadata), as required by LNURL-pay §3 to prevent
+// a compromised callback from substituting a different invoice.
+func verifyDescriptionHash(bolt11, metadata string) error {
+	hash := sha256.Sum256([]byte(metadata))
+	want := hex.EncodeToString(hash[:])
+
+	got, err := extractDescriptionHash(bolt11)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("invoice description hash %s does not match metadata hash %s", got, want)
+	}
+	return nil
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// extractDescriptionHash pulls the 'h' tagged field (32-byte description
+// hash) out of a BOLT11 invoice by decoding just enough bech32 to walk the
+// tagged-field list. It doesn't validate the checksum or signature — that
+// belongs to a full invoice-decoding library — it only recovers the field
+// LNURL's anti-tamper check needs.
+func extractDescriptionHash(bolt11 string) (string, error) {
+	bolt11 = strings.ToLower(bolt11)
+	sep := strings.LastIndex(bolt11, "1")
+	if sep < 0 || !strings.HasPrefix(bolt11, "ln") {
+		return "", fmt.Errorf("not a BOLT11 invoice")
+	}
+	data := bolt11[sep+1:]
+	if len(data) <= 6 {
+		return "", fmt.Errorf("BOLT11 invoice too short")
+	}
+	data = data[:len(data)-6] // drop the 6-character checksum
+
+	words := make([]byte, len(data))
+	for i, c := range data {
+		idx := strings.IndexRune(bech32Charset, c)
+		if idx < 0 {
+			return "", fmt.Errorf("invalid bech32 character %q", c)
+		}
+		words[i] = byte(idx)
+	}
+	if len(words) <= 7 {
+		return "", fmt.Errorf("BOLT11 invoice has no tagged fields")
+	}
+	words = words[7:] // skip the 35-bit timestamp (7 five-bit words)
+
+	for len(words) >= 3 {
+		tag := words[0]
+		length := int(words[1])<<5 | int(words[2])
+		words = words[3:]
+		if length > len(words) {
+			return "", fmt.Errorf("truncated tagged field")
+		}
+		field := words[:length]
+		words = words[length:]
+
+		if tag == 23 { // 'h' — description hash
+			bits, err := fiveBitToEightBit(field)
+			if err != nil {
+				return "", err
+			}
+			if len(bits) < 32 {
+				return "", fmt.Errorf("description hash field too short")
+			}
+			return hex.EncodeToString(bits[:32]), nil
+		}
+	}
+	return "", fmt.Errorf("BOLT11 invoice has no description-hash field")
+}
+
+// fiveBitToEightBit repacks bech32's 5-bit words into 8-bit bytes.
+func fiveBitToEightBit(words []byte) ([]byte, error) {
+	var acc uint32
+	var bits uint
+	out := make([]byte, 0, len(words)*5/8)
+
+	for _, w := range words {
+		acc = acc<<5 | uint32(w)
+		bits += 5
+		for bits >= 8 {
+			bits -= 8
+			out = append(out, byte(acc>>bits))
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,38 @@
+package payment
+
+import "testing"
+
+func TestNewLNURLPayClient(t *testing.T) {
+	client := NewLNURLPayClient()
+	if client == nil {
+		t.Fatal("NewLNURLPayClient returned nil")
+	}
+}
+
+func TestResolveLNURLEndpoint(t *testing.T) {
+	got, err := resolveLNURLEndpoint("alice@example.com")
+	if err != nil {
+		t.Fatalf("resolveLNURLEndpoint() error = %v", err)
+	}
+	want := "https://example.com/.well-known/lnurlp/alice"
+	if got != want {
+		t.Errorf("resolveLNURLEndpoint() = %q, want %q", got, want)
+	}
+}
+
+func TestResolveLNURLEndpoint_PassthroughURL(t *testing.T) {
+	url := "https://example.com/lnurlp/alice"
+	got, err := resolveLNURLEndpoint(url)
+	if err != nil {
+		t.Fatalf("resolveLNURLEndpoint() error = %v", err)
+	}
+	if got != url {
+		t.Errorf("resolveLNURLEndpoint() = %q, want passthrough %q", got, url)
+	}
+}
+
+func TestResolveLNURLEndpoint_Invalid(t *testing.T) {
+	if _, err := resolveLNURLEndpoint("not-an-address"); err == nil {
+		t.Error("expected error for invalid Lightning address")
+	}
+}
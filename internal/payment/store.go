@@ -0,0 +1,218 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Subscription is a single user's subscription record, kept locally so
+// IsActive doesn't need to scan every invoice on BTCPay for every check.
+type Subscription struct {
+	Email       string    `json:"email"`
+	PlanID      string    `json:"plan_id"`
+	InvoiceID   string    `json:"invoice_id"`
+	ActivatedAt time.Time `json:"activated_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Status      string    `json:"status"` // "active", "expired"
+}
+
+// SubscriptionStore persists subscription state and deduplicates webhook
+// deliveries. The SQLite-backed implementation below is the default; a
+// different backend just needs to satisfy this interface.
+type SubscriptionStore interface {
+	// IsActive reports whether email currently has an unexpired, active
+	// subscription, via a single indexed lookup.
+	IsActive(ctx context.Context, email string) (bool, error)
+
+	// Extend records a settlement, creating the subscription if it
+	// doesn't exist yet and otherwise pushing expires_at forward by dur
+	// from the later of now or the current expiry (so early renewals
+	// don't lose unused time).
+	Extend(ctx context.Context, email, planID, invoiceID string, dur time.Duration) error
+
+	// Expire marks a subscription as expired (used for InvoiceExpired).
+	Expire(ctx context.Context, invoiceID string) error
+
+	// ExpiringWithin returns active subscriptions whose expiry falls
+	// within the next window, for the reaper to warn about.
+	ExpiringWithin(ctx context.Context, window time.Duration) ([]Subscription, error)
+
+	// IsDelivered reports whether deliveryID has already been recorded by
+	// MarkDelivery, without recording it itself. The webhook handler
+	// checks this before applying a delivery's effect (so a retry of an
+	// already-applied delivery is skipped) and only calls MarkDelivery
+	// once that effect has actually landed, so a delivery that fails
+	// partway through never gets stuck permanently "processed".
+	IsDelivered(ctx context.Context, deliveryID string) (bool, error)
+
+	// MarkDelivery records a webhook deliveryId as processed and reports
+	// whether it had already been seen, so retried webhook calls are
+	// idempotent.
+	MarkDelivery(ctx context.Context, deliveryID string) (alreadyProcessed bool, err error)
+
+	// Close releases any underlying resources.
+	Close() error
+}
+
+// SQLiteStore is the default SubscriptionStore backend.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (creating if necessary) a SQLite database at path
+// and ensures its schema exists.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite database: %w", err)
+	}
+
+	schema := `
+CREATE TABLE IF NOT EXISTS subscriptions (
+	email        TEXT PRIMARY KEY,
+	plan_id      TEXT NOT NULL,
+	invoice_id   TEXT NOT NULL,
+	activated_at DATETIME NOT NULL,
+	expires_at   DATETIME NOT NULL,
+	status       TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_expires_at ON subscriptions(expires_at);
+
+CREATE TABLE IF NOT EXISTS processed_deliveries (
+	delivery_id  TEXT PRIMARY KEY,
+	processed_at DATETIME NOT NULL
+);
+`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// IsActive reports whether email has a subscription row marked active
+// whose expiry is still in the future.
+func (s *SQLiteStore) IsActive(ctx context.Context, email string) (bool, error) {
+	var expiresAt time.Time
+	var status string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT expires_at, status FROM subscriptions WHERE email = ?`, email,
+	).Scan(&expiresAt, &status)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("query subscription for %s: %w", email, err)
+	}
+	return status == "active" && time.Now().Before(expiresAt), nil
+}
+
+// Extend upserts the subscription and pushes its expiry forward by dur.
+func (s *SQLiteStore) Extend(ctx context.Context, email, planID, invoiceID string, dur time.Duration) error {
+	now := time.Now()
+
+	var current time.Time
+	err := s.db.QueryRowContext(ctx,
+		`SELECT expires_at FROM subscriptions WHERE email = ?`, email,
+	).Scan(&current)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("query current expiry for %s: %w", email, err)
+	}
+
+	base := now
+	if current.After(now) {
+		base = current
+	}
+	newExpiry := base.Add(dur)
+
+	_, err = s.db.ExecContext(ctx, `
+INSERT INTO subscriptions (email, plan_id, invoice_id, activated_at, expires_at, status)
+VALUES (?, ?, ?, ?, ?, 'active')
+ON CONFLICT(email) DO UPDATE SET
+	plan_id = excluded.plan_id,
+	invoice_id = excluded.invoice_id,
+	expires_at = excluded.expires_at,
+	status = 'active'
+`, email, planID, invoiceID, now, newExpiry)
+	if err != nil {
+		return fmt.Errorf("extend subscription for %s: %w", email, err)
+	}
+	return nil
+}
+
+// Expire marks the subscription owning invoiceID as expired.
+func (s *SQLiteStore) Expire(ctx context.Context, invoiceID string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE subscriptions SET status = 'expired' WHERE invoice_id = ?`, invoiceID,
+	)
+	if err != nil {
+		return fmt.Errorf("expire subscription for invoice %s: %w", invoiceID, err)
+	}
+	return nil
+}
+
+// ExpiringWithin returns active subscriptions expiring within window.
+func (s *SQLiteStore) ExpiringWithin(ctx context.Context, window time.Duration) ([]Subscription, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT email, plan_id, invoice_id, activated_at, expires_at, status
+FROM subscriptions
+WHERE status = 'active' AND expires_at BETWEEN ? AND ?
+`, time.Now(), time.Now().Add(window))
+	if err != nil {
+		return nil, fmt.Errorf("query expiring subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []Subscription
+	for rows.Next() {
+		var sub Subscription
+		if err := rows.Scan(&sub.Email, &sub.PlanID, &sub.InvoiceID, &sub.ActivatedAt, &sub.ExpiresAt, &sub.Status); err != nil {
+			return nil, fmt.Errorf("scan expiring subscription: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// IsDelivered reports whether deliveryID has already been recorded by
+// MarkDelivery.
+func (s *SQLiteStore) IsDelivered(ctx context.Context, deliveryID string) (bool, error) {
+	var count int
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM processed_deliveries WHERE delivery_id = ?`, deliveryID,
+	).Scan(&count); err != nil {
+		return false, fmt.Errorf("check delivery %s: %w", deliveryID, err)
+	}
+	return count > 0, nil
+}
+
+// MarkDelivery records deliveryID as processed, reporting true if it had
+// already been recorded (so the caller can skip re-applying the webhook).
+func (s *SQLiteStore) MarkDelivery(ctx context.Context, deliveryID string) (bool, error) {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO processed_deliveries (delivery_id, processed_at) VALUES (?, ?)`,
+		deliveryID, time.Now(),
+	)
+	if err == nil {
+		return false, nil
+	}
+	// modernc.org/sqlite surfaces the UNIQUE constraint violation as a
+	// generic error; a duplicate insert means we've already processed it.
+	var count int
+	if qerr := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM processed_deliveries WHERE delivery_id = ?`, deliveryID,
+	).Scan(&count); qerr == nil && count > 0 {
+		return true, nil
+	}
+	return false, fmt.Errorf("record delivery %s: %w", deliveryID, err)
+}
+
+// Close closes the underlying database handle.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
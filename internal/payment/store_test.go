@@ -0,0 +1,81 @@
+package payment
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	store, err := NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func TestSQLiteStore_ExtendAndIsActive(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	active, err := store.IsActive(ctx, "user@example.com")
+	if err != nil {
+		t.Fatalf("IsActive() error = %v", err)
+	}
+	if active {
+		t.Error("expected inactive subscription before any settlement")
+	}
+
+	if err := store.Extend(ctx, "user@example.com", "entropy-monthly", "inv-1", 24*time.Hour); err != nil {
+		t.Fatalf("Extend() error = %v", err)
+	}
+
+	active, err = store.IsActive(ctx, "user@example.com")
+	if err != nil {
+		t.Fatalf("IsActive() error = %v", err)
+	}
+	if !active {
+		t.Error("expected active subscription after settlement")
+	}
+}
+
+func TestSQLiteStore_Expire(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	_ = store.Extend(ctx, "user@example.com", "entropy-monthly", "inv-1", 24*time.Hour)
+	if err := store.Expire(ctx, "inv-1"); err != nil {
+		t.Fatalf("Expire() error = %v", err)
+	}
+
+	active, err := store.IsActive(ctx, "user@example.com")
+	if err != nil {
+		t.Fatalf("IsActive() error = %v", err)
+	}
+	if active {
+		t.Error("expected inactive subscription after expiry")
+	}
+}
+
+func TestSQLiteStore_MarkDeliveryDedup(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	already, err := store.MarkDelivery(ctx, "delivery-1")
+	if err != nil {
+		t.Fatalf("MarkDelivery() error = %v", err)
+	}
+	if already {
+		t.Error("expected first delivery to not be marked as already processed")
+	}
+
+	already, err = store.MarkDelivery(ctx, "delivery-1")
+	if err != nil {
+		t.Fatalf("MarkDelivery() second call error = %v", err)
+	}
+	if !already {
+		t.Error("expected duplicate delivery to be detected")
+	}
+}
@@ -0,0 +1,227 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// WebhookServer receives BTCPay Server's invoice webhook callbacks and
+// applies them to a SubscriptionStore, verifying the HMAC signature and
+// deduplicating by deliveryId so retried deliveries are idempotent.
+type WebhookServer struct {
+	store  SubscriptionStore
+	secret string
+	logger *zap.Logger
+}
+
+// NewWebhookServer creates a webhook receiver backed by store, verifying
+// callbacks with the store's configured webhook secret.
+func NewWebhookServer(store SubscriptionStore, secret string, logger *zap.Logger) *WebhookServer {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &WebhookServer{store: store, secret: secret, logger: logger}
+}
+
+// RegisterRoutes mounts the webhook endpoint on mux, matching how the rest
+// of the API listener registers handlers.
+func (w *WebhookServer) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /webhooks/btcpay", w.handleWebhook)
+}
+
+type btcpayWebhookPayload struct {
+	DeliveryID string `json:"deliveryId"`
+	Type       string `json:"type"`
+	StoreID    string `json:"storeId"`
+	InvoiceID  string `json:"invoiceId"`
+	Metadata   struct {
+		Email  string `json:"email"`
+		PlanID string `json:"plan_id"`
+	} `json:"metadata"`
+}
+
+func (w *WebhookServer) handleWebhook(rw http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(rw, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if !w.verifySignature(r.Header.Get("BTCPay-Sig"), body) {
+		http.Error(rw, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var payload btcpayWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(rw, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	already, err := w.store.IsDelivered(ctx, payload.DeliveryID)
+	if err != nil {
+		w.logger.Error("failed to check webhook delivery", zap.Error(err))
+		http.Error(rw, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if already {
+		w.logger.Debug("ignoring duplicate webhook delivery", zap.String("delivery_id", payload.DeliveryID))
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+
+	switch payload.Type {
+	case "InvoiceSettled", "InvoicePaymentSettled":
+		dur, err := planDuration(payload.Metadata.PlanID)
+		if err != nil {
+			w.logger.Warn("unknown plan on settled invoice", zap.String("plan_id", payload.Metadata.PlanID))
+			dur = 30 * 24 * time.Hour
+		}
+		if err := w.store.Extend(ctx, payload.Metadata.Email, payload.Metadata.PlanID, payload.InvoiceID, dur); err != nil {
+			w.logger.Error("failed to extend subscription", zap.Error(err))
+			http.Error(rw, "internal error", http.StatusInternalServerError)
+			return
+		}
+		w.logger.Info("subscription extended", zap.String("email", payload.Metadata.Email), zap.String("invoice_id", payload.InvoiceID))
+
+	case "InvoiceExpired":
+		if err := w.store.Expire(ctx, payload.InvoiceID); err != nil {
+			w.logger.Error("failed to expire subscription", zap.Error(err))
+			http.Error(rw, "internal error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Only record the delivery as processed now that its effect has
+	// actually landed. Marking it beforehand meant a failed Extend/Expire
+	// (DB lock, transient I/O error) left the delivery permanently
+	// "processed", so BTCPay's retry of that same delivery would be
+	// silently skipped above and the settlement lost for good.
+	if _, err := w.store.MarkDelivery(ctx, payload.DeliveryID); err != nil {
+		w.logger.Error("failed to record webhook delivery", zap.Error(err))
+		http.Error(rw, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// verifySignature checks the "BTCPay-Sig: sha256=<hex>" header against an
+// HMAC-SHA256 of the raw request body, as BTCPay's Greenfield webhooks do.
+func (w *WebhookServer) verifySignature(header string, body []byte) bool {
+	const prefix = "sha256="
+	if len(header) <= len(prefix) || header[:len(prefix)] != prefix {
+		return false
+	}
+	got, err := hex.DecodeString(header[len(prefix):])
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(w.secret))
+	mac.Write(body)
+	want := mac.Sum(nil)
+
+	return hmac.Equal(got, want)
+}
+
+// planDuration maps a plan ID to its subscription length, matching
+// AvailablePlans' Duration field.
+func planDuration(planID string) (time.Duration, error) {
+	for _, plan := range AvailablePlans() {
+		if plan.ID != planID {
+			continue
+		}
+		switch plan.Duration {
+		case "monthly":
+			return 30 * 24 * time.Hour, nil
+		case "yearly":
+			return 365 * 24 * time.Hour, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown plan %q", planID)
+}
+
+// Reaper periodically scans the store for subscriptions about to expire
+// and publishes a SubscriptionExpiring event for each, so a GUI can warn
+// the user before their tunnel access lapses.
+type Reaper struct {
+	store    SubscriptionStore
+	interval time.Duration
+	window   time.Duration
+	logger   *zap.Logger
+	stopCh   chan struct{}
+}
+
+// NewReaper creates a reaper that checks store every interval for
+// subscriptions expiring within window.
+func NewReaper(store SubscriptionStore, interval, window time.Duration, logger *zap.Logger) *Reaper {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Reaper{store: store, interval: interval, window: window, logger: logger, stopCh: make(chan struct{})}
+}
+
+// ReaperPublisher is satisfied by tunnel.BootstrapEventBus without this
+// package importing tunnel directly, keeping payment's dependency graph
+// shallow.
+type ReaperPublisher interface {
+	Publish(subsystem, eventType string, data any)
+}
+
+// Start begins the periodic scan, publishing via bus if non-nil.
+func (r *Reaper) Start(ctx context.Context, bus ReaperPublisher) {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.stopCh:
+				return
+			case <-ticker.C:
+				r.scanOnce(ctx, bus)
+			}
+		}
+	}()
+}
+
+func (r *Reaper) scanOnce(ctx context.Context, bus ReaperPublisher) {
+	subs, err := r.store.ExpiringWithin(ctx, r.window)
+	if err != nil {
+		r.logger.Error("reaper scan failed", zap.Error(err))
+		return
+	}
+
+	for _, sub := range subs {
+		r.logger.Info("subscription expiring soon",
+			zap.String("email", sub.Email),
+			zap.Time("expires_at", sub.ExpiresAt),
+		)
+		if bus != nil {
+			bus.Publish("payment", "subscription_expiring", map[string]any{
+				"email":      sub.Email,
+				"plan_id":    sub.PlanID,
+				"expires_at": sub.ExpiresAt,
+			})
+		}
+	}
+}
+
+// Stop halts the reaper.
+func (r *Reaper) Stop() {
+	close(r.stopCh)
+}
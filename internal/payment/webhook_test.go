@@ -0,0 +1,138 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signBody computes the HMAC-SHA256 hex digest verifySignature expects.
+func signBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// fakeStore is an in-memory SubscriptionStore for exercising WebhookServer
+// without a real database, following the fakeProvider convention used for
+// testing the rotation registry.
+type fakeStore struct {
+	extendErr   error // if set, returned by the next call to Extend
+	delivered   map[string]bool
+	extendCalls int
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{delivered: make(map[string]bool)}
+}
+
+func (f *fakeStore) IsActive(ctx context.Context, email string) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeStore) Extend(ctx context.Context, email, planID, invoiceID string, dur time.Duration) error {
+	f.extendCalls++
+	if f.extendErr != nil {
+		err := f.extendErr
+		f.extendErr = nil
+		return err
+	}
+	return nil
+}
+
+func (f *fakeStore) Expire(ctx context.Context, invoiceID string) error {
+	return nil
+}
+
+func (f *fakeStore) ExpiringWithin(ctx context.Context, window time.Duration) ([]Subscription, error) {
+	return nil, nil
+}
+
+func (f *fakeStore) IsDelivered(ctx context.Context, deliveryID string) (bool, error) {
+	return f.delivered[deliveryID], nil
+}
+
+func (f *fakeStore) MarkDelivery(ctx context.Context, deliveryID string) (bool, error) {
+	if f.delivered[deliveryID] {
+		return true, nil
+	}
+	f.delivered[deliveryID] = true
+	return false, nil
+}
+
+func (f *fakeStore) Close() error { return nil }
+
+const webhookSecret = "test-secret"
+
+func postWebhook(t *testing.T, w *WebhookServer, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/webhooks/btcpay", strings.NewReader(string(body)))
+	req.Header.Set("BTCPay-Sig", "sha256="+signBody(webhookSecret, body))
+	rw := httptest.NewRecorder()
+	w.handleWebhook(rw, req)
+	return rw
+}
+
+func TestWebhookServer_ExtendFailureDoesNotMarkDeliveryProcessed(t *testing.T) {
+	store := newFakeStore()
+	w := NewWebhookServer(store, webhookSecret, nil)
+
+	payload := []byte(`{
+		"deliveryId": "dlv-1",
+		"type": "InvoiceSettled",
+		"invoiceId": "inv-1",
+		"metadata": {"email": "user@example.com", "plan_id": "entropy-monthly"}
+	}`)
+
+	store.extendErr = errors.New("database is locked")
+	rw := postWebhook(t, w, payload)
+	if rw.Code != 500 {
+		t.Fatalf("first delivery: status = %d, want 500", rw.Code)
+	}
+	if store.delivered["dlv-1"] {
+		t.Fatal("delivery must not be marked processed when Extend fails")
+	}
+
+	rw = postWebhook(t, w, payload)
+	if rw.Code != 200 {
+		t.Fatalf("retried delivery: status = %d, want 200", rw.Code)
+	}
+	if !store.delivered["dlv-1"] {
+		t.Error("delivery should be marked processed after a successful retry")
+	}
+	if store.extendCalls != 2 {
+		t.Errorf("Extend called %d times, want 2 (failed attempt + successful retry)", store.extendCalls)
+	}
+}
+
+func TestWebhookServer_DuplicateDeliveryIsSkipped(t *testing.T) {
+	store := newFakeStore()
+	w := NewWebhookServer(store, webhookSecret, nil)
+
+	payload := []byte(`{
+		"deliveryId": "dlv-2",
+		"type": "InvoiceSettled",
+		"invoiceId": "inv-2",
+		"metadata": {"email": "user@example.com", "plan_id": "entropy-monthly"}
+	}`)
+
+	if rw := postWebhook(t, w, payload); rw.Code != 200 {
+		t.Fatalf("first delivery: status = %d, want 200", rw.Code)
+	}
+	if store.extendCalls != 1 {
+		t.Fatalf("Extend called %d times, want 1", store.extendCalls)
+	}
+
+	if rw := postWebhook(t, w, payload); rw.Code != 200 {
+		t.Fatalf("duplicate delivery: status = %d, want 200", rw.Code)
+	}
+	if store.extendCalls != 1 {
+		t.Errorf("Extend called %d times after duplicate delivery, want still 1", store.extendCalls)
+	}
+}
@@ -0,0 +1,131 @@
+package protocols
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fabiano/entropy-tunnel/internal/protocols/domainfront"
+)
+
+// DomainFrontConfig holds the domain-fronted HTTPS fallback's front list
+// and tunables.
+type DomainFrontConfig struct {
+	// Fronts are tried in order (by DialContext and Probe) until one is
+	// reachable; each pairs a permitted CDN SNI with the covert origin
+	// it smuggles behind it.
+	Fronts []domainfront.FrontEntry `yaml:"fronts" json:"fronts"`
+
+	// PollInterval is how long each long-poll GET blocks waiting for
+	// downstream data before the client reissues it.
+	PollInterval time.Duration `yaml:"poll_interval" json:"poll_interval"`
+}
+
+// DefaultDomainFrontConfig returns sensible defaults for domain fronting.
+func DefaultDomainFrontConfig() *DomainFrontConfig {
+	return &DomainFrontConfig{
+		PollInterval: 25 * time.Second,
+	}
+}
+
+// DomainFrontProtocol implements the Protocol interface via meek-style
+// domain fronting: TLS SNI set to a permitted CDN front, with the covert
+// origin only visible in the encrypted HTTP Host header. It ranks between
+// REALITY and plain-WS in the fallback chain, for networks where both of
+// those are actively blocked but the front's CDN isn't.
+type DomainFrontProtocol struct {
+	config *DomainFrontConfig
+	logger *zap.Logger
+
+	mu        sync.RWMutex
+	available bool
+}
+
+// NewDomainFront creates a new domain-fronting protocol adapter. It
+// reports Available() == false until Probe succeeds at least once.
+func NewDomainFront(cfg *DomainFrontConfig, logger *zap.Logger) *DomainFrontProtocol {
+	if cfg == nil {
+		cfg = DefaultDomainFrontConfig()
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &DomainFrontProtocol{config: cfg, logger: logger}
+}
+
+func (d *DomainFrontProtocol) Name() string  { return "domainfront" }
+func (d *DomainFrontProtocol) Priority() int { return 2 } // Before plain-WS Trojan (3) and hand-rolled VLESS (4)
+
+func (d *DomainFrontProtocol) Available() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.available
+}
+
+// Probe dials the first configured front once and records whether it
+// answered, so Available() reflects a live reachability check rather than
+// just "configured". Call this once at startup before relying on
+// domainfront being part of the fallback chain.
+func (d *DomainFrontProtocol) Probe(ctx context.Context) error {
+	if len(d.config.Fronts) == 0 {
+		d.setAvailable(false)
+		return fmt.Errorf("domainfront: no fronts configured")
+	}
+
+	front := d.config.Fronts[0]
+	conn, err := domainfront.Dial(ctx, front, d.config.PollInterval, d.logger)
+	if err != nil {
+		d.setAvailable(false)
+		return fmt.Errorf("domainfront: probe %s failed: %w", front.Front, err)
+	}
+	conn.Close()
+
+	d.setAvailable(true)
+	return nil
+}
+
+func (d *DomainFrontProtocol) setAvailable(ok bool) {
+	d.mu.Lock()
+	d.available = ok
+	d.mu.Unlock()
+}
+
+// DialContext opens a meek session against the first front that accepts a
+// connection, smuggling traffic for addr behind that front's covert
+// origin Host header.
+func (d *DomainFrontProtocol) DialContext(ctx context.Context, addr string) (net.Conn, error) {
+	if !d.Available() {
+		return nil, fmt.Errorf("domainfront: no available front (run Probe first)")
+	}
+
+	var lastErr error
+	for _, front := range d.config.Fronts {
+		conn, err := domainfront.Dial(ctx, front, d.config.PollInterval, d.logger)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		d.logger.Info("domain-fronted connection established",
+			zap.String("front", front.Front),
+			zap.String("origin", front.Origin),
+			zap.String("addr", addr),
+		)
+		return conn, nil
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no fronts configured")
+	}
+	return nil, fmt.Errorf("domainfront dial failed: %w", lastErr)
+}
+
+// Listen returns an error: domain fronting, like Snowflake, is a
+// client-only fallback.
+func (d *DomainFrontProtocol) Listen(addr string) (net.Listener, error) {
+	return nil, fmt.Errorf("domainfront does not support Listen (client-only)")
+}
@@ -0,0 +1,281 @@
+// Package domainfront implements a meek-style domain-fronted HTTPS
+// transport: a TLS connection dialed with SNI set to a permitted "front"
+// host (e.g. a large CDN edge), carrying an HTTP Host header for a
+// different, covert origin inside the encrypted request. A censor
+// watching the TLS handshake or routing by IP only ever sees the front;
+// only the front's own CDN infrastructure (and the covert origin behind
+// it) sees the real Host. Client writes stream as an open chunked HTTP
+// POST body; server reads are long-polled via periodic GET requests, the
+// same split meek (Tor's pluggable transport of the same name) uses.
+package domainfront
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// FrontEntry pairs a CDN-fronted TLS SNI with the covert HTTP Host the
+// request is actually destined for.
+type FrontEntry struct {
+	// Front is the TLS SNI and dial target — a domain the censor
+	// permits because blocking it would break the CDN for everyone.
+	Front string
+
+	// Origin is the Host header sent inside the encrypted request; the
+	// CDN routes the request to whatever backend owns this Host.
+	Origin string
+
+	// ALPN restricts the TLS handshake's protocol offer, e.g.
+	// []string{"h2"} to match what the front's edge actually expects.
+	ALPN []string
+}
+
+const (
+	defaultDialTimeout  = 10 * time.Second
+	defaultPollInterval = 25 * time.Second
+	tunnelPath          = "/meek"
+)
+
+// Dial opens a meek session against front: a TLS connection whose SNI is
+// front.Front, but whose HTTP Host header (and therefore the backend the
+// CDN actually routes requests to) is front.Origin. pollInterval governs
+// how long each long-poll GET blocks before the client reissues it; zero
+// uses defaultPollInterval.
+func Dial(ctx context.Context, front FrontEntry, pollInterval time.Duration, logger *zap.Logger) (*Conn, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialTLSContext: func(dialCtx context.Context, _, _ string) (net.Conn, error) {
+				return dialFrontTLS(dialCtx, front)
+			},
+		},
+	}
+
+	// A fresh TLS dial against the front, solely to fail Dial fast if the
+	// front itself is unreachable — the lazily-dialed connections the
+	// Transport opens for the upload/poll requests below would otherwise
+	// surface the same error only on the first Write or Read.
+	probeConn, err := dialFrontTLS(ctx, front)
+	if err != nil {
+		return nil, fmt.Errorf("domainfront: dial front %s: %w", front.Front, err)
+	}
+	probeConn.Close()
+
+	connCtx, cancel := context.WithCancel(context.Background())
+	c := &Conn{
+		front:        front,
+		client:       client,
+		logger:       logger,
+		sessionID:    newSessionID(),
+		pollInterval: pollInterval,
+		ctx:          connCtx,
+		cancel:       cancel,
+		incoming:     make(chan []byte, 16),
+	}
+
+	pr, pw := io.Pipe()
+	c.body = pw
+	if err := c.startUpload(pr); err != nil {
+		cancel()
+		return nil, err
+	}
+	go c.pollLoop()
+
+	return c, nil
+}
+
+// dialFrontTLS dials front.Front over TCP and completes a TLS handshake
+// with SNI = front.Front. front.Origin never appears at the TLS layer,
+// only later, in the Host header of the HTTP requests layered on top.
+func dialFrontTLS(ctx context.Context, front FrontEntry) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: defaultDialTimeout}
+	raw, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(front.Front, "443"))
+	if err != nil {
+		return nil, fmt.Errorf("dial %s: %w", front.Front, err)
+	}
+
+	tlsConn := tls.Client(raw, &tls.Config{
+		ServerName: front.Front,
+		NextProtos: front.ALPN,
+	})
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		raw.Close()
+		return nil, fmt.Errorf("tls handshake with %s: %w", front.Front, err)
+	}
+	return tlsConn, nil
+}
+
+// Conn is a net.Conn backed by a domain-fronted meek session: Write feeds
+// an open chunked HTTP POST body, Read drains bytes pulled in by repeated
+// long-polled GETs. Both requests carry the same X-Session-Id so the
+// covert origin can pair them into one logical bidirectional stream.
+type Conn struct {
+	front        FrontEntry
+	client       *http.Client
+	logger       *zap.Logger
+	sessionID    string
+	pollInterval time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	body      *io.PipeWriter
+	incoming  chan []byte
+	closeOnce sync.Once
+
+	readMu  sync.Mutex
+	readBuf bytes.Buffer
+}
+
+func (c *Conn) startUpload(body io.Reader) error {
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodPost, "https://"+c.front.Front+tunnelPath, body)
+	if err != nil {
+		return fmt.Errorf("domainfront: build upload request: %w", err)
+	}
+	req.Host = c.front.Origin
+	req.Header.Set("X-Session-Id", c.sessionID)
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	go func() {
+		resp, err := c.client.Do(req)
+		if err != nil {
+			c.logger.Debug("meek upload stream ended", zap.Error(err))
+			return
+		}
+		resp.Body.Close()
+	}()
+	return nil
+}
+
+// pollLoop issues a long-poll GET, blocking server-side until downstream
+// data is available or pollInterval elapses, and repeats for the life of
+// the connection — the "long-poll" half of meek's chunked-POST-plus-GET
+// split.
+func (c *Conn) pollLoop() {
+	for {
+		if c.ctx.Err() != nil {
+			return
+		}
+
+		reqCtx, cancel := context.WithTimeout(c.ctx, c.pollInterval)
+		req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, "https://"+c.front.Front+tunnelPath, nil)
+		if err != nil {
+			cancel()
+			return
+		}
+		req.Host = c.front.Origin
+		req.Header.Set("X-Session-Id", c.sessionID)
+
+		resp, err := c.client.Do(req)
+		cancel()
+		if err != nil {
+			if c.ctx.Err() != nil {
+				return
+			}
+			select {
+			case <-time.After(time.Second):
+			case <-c.ctx.Done():
+				return
+			}
+			continue
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err == nil && len(data) > 0 {
+			select {
+			case c.incoming <- data:
+			case <-c.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// Read implements net.Conn.
+func (c *Conn) Read(b []byte) (int, error) {
+	c.readMu.Lock()
+	if c.readBuf.Len() > 0 {
+		n, _ := c.readBuf.Read(b)
+		c.readMu.Unlock()
+		return n, nil
+	}
+	c.readMu.Unlock()
+
+	select {
+	case data, ok := <-c.incoming:
+		if !ok {
+			return 0, io.EOF
+		}
+		c.readMu.Lock()
+		n := copy(b, data)
+		if n < len(data) {
+			c.readBuf.Write(data[n:])
+		}
+		c.readMu.Unlock()
+		return n, nil
+	case <-c.ctx.Done():
+		return 0, io.EOF
+	}
+}
+
+// Write implements net.Conn by streaming b into the open chunked POST
+// body; the caller sees it as an ordinary blocking Write.
+func (c *Conn) Write(b []byte) (int, error) {
+	if c.ctx.Err() != nil {
+		return 0, fmt.Errorf("domainfront: write on closed connection")
+	}
+	n, err := c.body.Write(b)
+	if err != nil {
+		return n, fmt.Errorf("domainfront: write chunk: %w", err)
+	}
+	return n, nil
+}
+
+// Close implements net.Conn, tearing down the upload pipe and canceling
+// the in-flight POST/GET requests.
+func (c *Conn) Close() error {
+	c.closeOnce.Do(func() {
+		c.cancel()
+		_ = c.body.Close()
+	})
+	return nil
+}
+
+func (c *Conn) LocalAddr() net.Addr  { return meekAddr{host: c.front.Front} }
+func (c *Conn) RemoteAddr() net.Addr { return meekAddr{host: c.front.Origin} }
+
+func (c *Conn) SetDeadline(t time.Time) error      { return nil }
+func (c *Conn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *Conn) SetWriteDeadline(t time.Time) error { return nil }
+
+// meekAddr is a minimal net.Addr for a Conn that, unlike a raw TCP socket,
+// has two distinct hostnames worth reporting (the front and the origin)
+// rather than a single IP:port pair.
+type meekAddr struct{ host string }
+
+func (a meekAddr) Network() string { return "domainfront" }
+func (a meekAddr) String() string  { return a.host }
+
+func newSessionID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,91 @@
+package protocols
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fabiano/entropy-tunnel/internal/protocols/domainfront"
+)
+
+func TestDomainFrontProtocol_Name(t *testing.T) {
+	df := NewDomainFront(nil, nil)
+	if df.Name() != "domainfront" {
+		t.Errorf("Name() = %q, want 'domainfront'", df.Name())
+	}
+}
+
+func TestDomainFrontProtocol_Priority(t *testing.T) {
+	df := NewDomainFront(nil, nil)
+	vless := NewVLESS()
+	trojan := NewTrojan("/ws")
+
+	if !(df.Priority() < trojan.Priority() && trojan.Priority() < vless.Priority()) {
+		t.Errorf("Priority() = %d, want strictly before Trojan (%d) and hand-rolled VLESS (%d)", df.Priority(), trojan.Priority(), vless.Priority())
+	}
+}
+
+func TestDomainFrontProtocol_AvailableBeforeProbe(t *testing.T) {
+	df := NewDomainFront(nil, nil)
+	if df.Available() {
+		t.Error("Available() should be false before Probe has run")
+	}
+}
+
+func TestDomainFrontProtocol_ProbeNoFronts(t *testing.T) {
+	df := NewDomainFront(DefaultDomainFrontConfig(), nil)
+	if err := df.Probe(context.Background()); err == nil {
+		t.Error("expected error probing with no fronts configured")
+	}
+	if df.Available() {
+		t.Error("Available() should stay false after a failed probe")
+	}
+}
+
+func TestDomainFrontProtocol_DialContextUnavailable(t *testing.T) {
+	df := NewDomainFront(nil, nil)
+	if _, err := df.DialContext(context.Background(), "example.com:443"); err == nil {
+		t.Error("expected error dialing before Probe has succeeded")
+	}
+}
+
+func TestDomainFrontProtocol_ListenNotSupported(t *testing.T) {
+	df := NewDomainFront(nil, nil)
+	if _, err := df.Listen(":1234"); err == nil {
+		t.Error("expected error: domainfront doesn't support Listen")
+	}
+}
+
+func TestDomainFrontProtocol_DefaultConfig(t *testing.T) {
+	cfg := DefaultDomainFrontConfig()
+	if cfg.PollInterval <= 0 {
+		t.Error("default PollInterval should be positive")
+	}
+	if len(cfg.Fronts) != 0 {
+		t.Error("default config should have no fronts configured")
+	}
+}
+
+func TestDomainFrontProtocol_InRegistry(t *testing.T) {
+	r := NewRegistry()
+	df := NewDomainFront(&DomainFrontConfig{
+		Fronts: []domainfront.FrontEntry{{Front: "cdn.example.com", Origin: "origin.example.com"}},
+	}, nil)
+	vless := NewVLESS()
+
+	_ = r.Register(vless)
+	_ = r.Register(df)
+
+	chain := r.FallbackChain()
+	if len(chain) != 2 {
+		t.Fatalf("expected 2 protocols in chain, got %d", len(chain))
+	}
+	// domainfront (priority 2) should come before hand-rolled VLESS
+	// (priority 4), though domainfront is unavailable until Probe
+	// succeeds so it's last in SelectBest regardless of ordering here.
+	if chain[0].Name() != "domainfront" {
+		t.Errorf("chain[0] = %q, want 'domainfront'", chain[0].Name())
+	}
+	if chain[1].Name() != "vless" {
+		t.Errorf("chain[1] = %q, want 'vless'", chain[1].Name())
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net"
 	"sync"
+
+	"github.com/fabiano/entropy-tunnel/internal/events"
 )
 
 // Protocol defines the interface for a tunnel protocol.
@@ -29,12 +31,23 @@ type Protocol interface {
 type Registry struct {
 	mu        sync.RWMutex
 	protocols map[string]Protocol
+	ptMethods map[string]Protocol
+	eventBus  *events.Bus
+}
+
+// SetEventBus wires a bootstrap-progress bus so fallback activation shows
+// up for a GUI subscriber in real time.
+func (r *Registry) SetEventBus(bus *events.Bus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.eventBus = bus
 }
 
 // NewRegistry creates an empty protocol registry.
 func NewRegistry() *Registry {
 	return &Registry{
 		protocols: make(map[string]Protocol),
+		ptMethods: make(map[string]Protocol),
 	}
 }
 
@@ -75,6 +88,48 @@ func (r *Registry) List() []string {
 	return names
 }
 
+// RegisterAsPT exposes an already-registered protocol as a Tor Pluggable
+// Transport method named name (per the PT spec, method names and protocol
+// names needn't match — e.g. a "vless-camo" method could wrap the "vless"
+// protocol). The cmd/entropy-* pt-client/pt-server subcommands use
+// PTMethodNames/PTMethod to bring each one up as its own SOCKS5-fronted PT
+// method, relaying accepted connections through p.DialContext/p.Listen.
+func (r *Registry) RegisterAsPT(name string, p Protocol) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.ptMethods[name]; exists {
+		return fmt.Errorf("PT method %q already registered", name)
+	}
+
+	r.ptMethods[name] = p
+	return nil
+}
+
+// PTMethod returns the protocol registered under the given PT method name.
+func (r *Registry) PTMethod(name string) (Protocol, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.ptMethods[name]
+	if !ok {
+		return nil, fmt.Errorf("PT method %q not found", name)
+	}
+	return p, nil
+}
+
+// PTMethodNames returns every PT method name registered via RegisterAsPT.
+func (r *Registry) PTMethodNames() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.ptMethods))
+	for name := range r.ptMethods {
+		names = append(names, name)
+	}
+	return names
+}
+
 // SelectBest returns the highest-priority available protocol.
 func (r *Registry) SelectBest() (Protocol, error) {
 	r.mu.RLock()
@@ -115,3 +170,35 @@ func (r *Registry) FallbackChain() []Protocol {
 
 	return chain
 }
+
+// DialWithFallback tries each available protocol in priority order,
+// publishing a FallbackActivated event every time it has to move past the
+// first one, and returns the first successful connection.
+func (r *Registry) DialWithFallback(ctx context.Context, addr string) (net.Conn, error) {
+	chain := r.FallbackChain()
+
+	r.mu.RLock()
+	bus := r.eventBus
+	r.mu.RUnlock()
+
+	var lastErr error
+	for i, p := range chain {
+		if !p.Available() {
+			continue
+		}
+		if i > 0 && bus != nil {
+			bus.Publish("fallback", events.EventFallbackActivated, events.FallbackActivatedData{Protocol: p.Name()})
+		}
+
+		conn, err := p.DialContext(ctx, addr)
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no available protocols")
+	}
+	return nil, fmt.Errorf("all protocols failed, last error: %w", lastErr)
+}
@@ -48,18 +48,18 @@ func TestRegistry(t *testing.T) {
 func TestSelectBest(t *testing.T) {
 	r := NewRegistry()
 
-	trojan := NewTrojan("/ws")   // Priority 2
-	vless := NewVLESS()          // Priority 1
+	trojan := NewTrojan("/ws") // Priority 3
+	vless := NewVLESS()        // Priority 4
 
-	_ = r.Register(trojan) // Register lower-priority first
-	_ = r.Register(vless)
+	_ = r.Register(vless) // Register lower-priority first
+	_ = r.Register(trojan)
 
 	best, err := r.SelectBest()
 	if err != nil {
 		t.Fatalf("SelectBest() error = %v", err)
 	}
-	if best.Name() != "vless" {
-		t.Errorf("SelectBest() = %q, want 'vless' (priority 1)", best.Name())
+	if best.Name() != "trojan" {
+		t.Errorf("SelectBest() = %q, want 'trojan' (priority 3)", best.Name())
 	}
 }
 
@@ -76,11 +76,42 @@ func TestFallbackChain(t *testing.T) {
 	if len(chain) != 2 {
 		t.Fatalf("FallbackChain() returned %d items, want 2", len(chain))
 	}
-	if chain[0].Name() != "vless" {
-		t.Errorf("FallbackChain()[0] = %q, want 'vless'", chain[0].Name())
+	if chain[0].Name() != "trojan" {
+		t.Errorf("FallbackChain()[0] = %q, want 'trojan'", chain[0].Name())
+	}
+	if chain[1].Name() != "vless" {
+		t.Errorf("FallbackChain()[1] = %q, want 'vless'", chain[1].Name())
+	}
+}
+
+func TestRegisterAsPT(t *testing.T) {
+	r := NewRegistry()
+	vless := NewVLESS()
+
+	if err := r.RegisterAsPT("vless", vless); err != nil {
+		t.Fatalf("RegisterAsPT(vless) error = %v", err)
 	}
-	if chain[1].Name() != "trojan" {
-		t.Errorf("FallbackChain()[1] = %q, want 'trojan'", chain[1].Name())
+
+	// Duplicate PT method name
+	if err := r.RegisterAsPT("vless", vless); err == nil {
+		t.Error("expected error for duplicate PT method registration")
+	}
+
+	got, err := r.PTMethod("vless")
+	if err != nil {
+		t.Fatalf("PTMethod(vless) error = %v", err)
+	}
+	if got.Name() != "vless" {
+		t.Errorf("PTMethod(vless).Name() = %q, want 'vless'", got.Name())
+	}
+
+	if _, err := r.PTMethod("obfs4"); err == nil {
+		t.Error("expected error for unregistered PT method")
+	}
+
+	names := r.PTMethodNames()
+	if len(names) != 1 || names[0] != "vless" {
+		t.Errorf("PTMethodNames() = %v, want [vless]", names)
 	}
 }
 
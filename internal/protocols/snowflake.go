@@ -8,6 +8,9 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+
+	"github.com/fabiano/entropy-tunnel/internal/events"
+	"github.com/fabiano/entropy-tunnel/internal/protocols/snowflake"
 )
 
 // SnowflakeConfig holds Snowflake P2P fallback configuration.
@@ -51,6 +54,19 @@ type SnowflakeProtocol struct {
 	mu        sync.RWMutex
 	running   bool
 	connCount int
+
+	pool    *snowflake.PeerPool
+	session *snowflake.Session
+
+	eventBus *events.Bus
+}
+
+// SetEventBus wires a bootstrap-progress bus so broker contact and peer
+// connections show up for a GUI subscriber in real time.
+func (s *SnowflakeProtocol) SetEventBus(bus *events.Bus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eventBus = bus
 }
 
 // NewSnowflake creates a new Snowflake protocol adapter.
@@ -90,17 +106,6 @@ func (s *SnowflakeProtocol) DialContext(ctx context.Context, addr string) (net.C
 		return net.DialTimeout("tcp", addr, 10*time.Second)
 	}
 
-	// Production Snowflake connection flow:
-	// 1. Contact broker via domain-fronted HTTPS
-	// 2. Broker assigns volunteer proxy peers
-	// 3. Establish WebRTC data channels to peers
-	// 4. Multiplex traffic across multiple peers
-	//
-	// Integration point for Tor Snowflake client library:
-	//   import "gitlab.torproject.org/tpo/anti-censorship/pluggable-transports/snowflake/v2/client"
-	//   transport := snowflakeClient.NewSnowflakeClient(brokerURL, frontDomain, ...)
-	//   conn, err := transport.Dial()
-
 	conn, err := s.dialViaBroker(ctx, addr)
 	if err != nil {
 		return nil, fmt.Errorf("snowflake dial failed: %w", err)
@@ -112,36 +117,83 @@ func (s *SnowflakeProtocol) Listen(addr string) (net.Listener, error) {
 	return nil, fmt.Errorf("snowflake does not support Listen (client-only)")
 }
 
-// dialViaBroker implements the broker-mediated WebRTC connection.
+// dialViaBroker contacts the broker over domain-fronted HTTPS, establishes
+// a pool of WebRTC peer connections, and multiplexes traffic across them
+// through a turbo-tunnel (KCP+smux) session so a single logical stream
+// survives individual peers dropping out from under it.
 func (s *SnowflakeProtocol) dialViaBroker(ctx context.Context, addr string) (net.Conn, error) {
-	// For the MVP, we implement a simplified version:
-	// 1. POST to broker to request a proxy
-	// 2. Exchange SDP via broker
-	// 3. Establish connection through proxy
-	//
-	// Real implementation would use the full Snowflake client library.
-	// For now, fall back to direct connection with domain fronting.
-
-	dialer := &net.Dialer{Timeout: 15 * time.Second}
-	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	rv := snowflake.NewRendezvous(s.config.BrokerURL, s.config.FrontDomain, s.logger)
+	s.mu.RLock()
+	bus := s.eventBus
+	s.mu.RUnlock()
+	if bus != nil {
+		rv.OnContacted = func() {
+			bus.Publish("snowflake", events.EventSnowflakeBrokerContacted, nil)
+		}
+	}
+
+	s.mu.Lock()
+	if s.pool == nil {
+		s.pool = snowflake.NewPeerPool(s.config.MaxPeers, func(dialCtx context.Context) (*snowflake.SnowflakeConn, error) {
+			return snowflake.DialPeer(dialCtx, rv, s.config.STUNURLs, s.logger)
+		}, s.logger)
+		if bus != nil {
+			s.pool.OnPeerConnected = func(index, total int) {
+				bus.Publish("snowflake", events.EventSnowflakePeerConnected, events.SnowflakePeerConnectedData{
+					Index: index,
+					Total: total,
+				})
+			}
+		}
+		if err := s.pool.Start(ctx); err != nil {
+			s.mu.Unlock()
+			return nil, fmt.Errorf("snowflake peer pool: %w", err)
+		}
+	}
+	if s.session == nil {
+		session, err := snowflake.NewSession(s.pool, s.logger)
+		if err != nil {
+			s.mu.Unlock()
+			return nil, fmt.Errorf("snowflake turbo-tunnel session: %w", err)
+		}
+		s.session = session
+	}
+	session := s.session
+	s.mu.Unlock()
+
+	stream, err := session.OpenStream()
 	if err != nil {
-		return nil, fmt.Errorf("snowflake fallback dial: %w", err)
+		return nil, fmt.Errorf("open smux stream: %w", err)
 	}
 
-	s.logger.Info("snowflake connection established (simplified mode)",
+	s.logger.Info("snowflake connection established",
 		zap.String("addr", addr),
+		zap.Int("peers", s.pool.Count()),
 	)
 
-	return conn, nil
+	return stream, nil
 }
 
-// Stats returns Snowflake connection statistics.
+// Stats returns Snowflake connection statistics, including the live peer
+// and turbo-tunnel session counts so a GUI can show bootstrap progress.
 func (s *SnowflakeProtocol) Stats() map[string]any {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+
+	peerCount := 0
+	if s.pool != nil {
+		peerCount = s.pool.Count()
+	}
+	sessions := 0
+	if s.session != nil {
+		sessions = 1
+	}
+
 	return map[string]any{
 		"total_connections": s.connCount,
-		"broker":           s.config.BrokerURL,
-		"max_peers":        s.config.MaxPeers,
+		"broker":            s.config.BrokerURL,
+		"max_peers":         s.config.MaxPeers,
+		"live_peers":        peerCount,
+		"sessions":          sessions,
 	}
 }
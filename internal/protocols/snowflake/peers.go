@@ -0,0 +1,157 @@
+package snowflake
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// PeerPool maintains up to maxPeers live SnowflakeConns, replacing any
+// that die eagerly rather than waiting for the turbo-tunnel session above
+// to notice a write failure.
+type PeerPool struct {
+	maxPeers int
+	dial     func(ctx context.Context) (*SnowflakeConn, error)
+	logger   *zap.Logger
+
+	mu    sync.RWMutex
+	peers []*SnowflakeConn
+	next  int
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+
+	// OnPeerConnected, if set, is called every time a peer (initial or
+	// replacement) finishes connecting, with the current live count.
+	OnPeerConnected func(index, total int)
+}
+
+// NewPeerPool creates a pool that dials new peers via dial on demand.
+func NewPeerPool(maxPeers int, dial func(ctx context.Context) (*SnowflakeConn, error), logger *zap.Logger) *PeerPool {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if maxPeers <= 0 {
+		maxPeers = 1
+	}
+	return &PeerPool{
+		maxPeers: maxPeers,
+		dial:     dial,
+		logger:   logger,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start fills the pool and begins watching for dead peers in the
+// background, replacing each as soon as it goes away.
+func (p *PeerPool) Start(ctx context.Context) error {
+	for i := 0; i < p.maxPeers; i++ {
+		conn, err := p.dial(ctx)
+		if err != nil {
+			p.logger.Warn("initial peer dial failed", zap.Int("index", i), zap.Error(err))
+			continue
+		}
+		p.mu.Lock()
+		p.peers = append(p.peers, conn)
+		total := len(p.peers)
+		p.mu.Unlock()
+		if p.OnPeerConnected != nil {
+			p.OnPeerConnected(i, total)
+		}
+		p.watch(ctx, conn, i)
+	}
+
+	p.mu.RLock()
+	n := len(p.peers)
+	p.mu.RUnlock()
+	if n == 0 {
+		return errNoPeers
+	}
+	return nil
+}
+
+// watch replaces a peer as soon as its Dead channel fires.
+func (p *PeerPool) watch(ctx context.Context, conn *SnowflakeConn, slot int) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		select {
+		case <-conn.Dead():
+		case <-p.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		}
+
+		p.logger.Info("snowflake peer died, replacing", zap.Int("slot", slot))
+		p.removePeer(conn)
+
+		newConn, err := p.dial(ctx)
+		if err != nil {
+			p.logger.Warn("peer replacement dial failed", zap.Int("slot", slot), zap.Error(err))
+			return
+		}
+		p.mu.Lock()
+		p.peers = append(p.peers, newConn)
+		p.mu.Unlock()
+		p.watch(ctx, newConn, slot)
+	}()
+}
+
+func (p *PeerPool) removePeer(conn *SnowflakeConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, c := range p.peers {
+		if c == conn {
+			p.peers = append(p.peers[:i], p.peers[i+1:]...)
+			break
+		}
+	}
+}
+
+// Current returns a currently-live peer to write to, round-robining across
+// the pool so a single peer isn't overloaded. It returns nil if every peer
+// is currently down.
+func (p *PeerPool) Current() *SnowflakeConn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.peers) == 0 {
+		return nil
+	}
+	p.next = (p.next + 1) % len(p.peers)
+	return p.peers[p.next]
+}
+
+// Count returns the number of currently live peers.
+func (p *PeerPool) Count() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.peers)
+}
+
+// Close tears down every peer in the pool and stops replacement watchers.
+func (p *PeerPool) Close() error {
+	close(p.stopCh)
+	p.wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, c := range p.peers {
+		_ = c.Close()
+	}
+	p.peers = nil
+	return nil
+}
+
+var errNoPeers = &poolError{"snowflake: no peers could be established"}
+
+type poolError struct{ msg string }
+
+func (e *poolError) Error() string { return e.msg }
+
+// pollInterval is how often a caller might want to re-check pool health;
+// exported for callers building their own status loop.
+const pollInterval = 5 * time.Second
@@ -0,0 +1,132 @@
+// Package snowflake implements the client side of the Tor Snowflake
+// pluggable transport: broker rendezvous, a pool of WebRTC peer
+// connections, and a turbo-tunnel session that survives peer churn.
+package snowflake
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Strategy selects how the client reaches the broker when direct access
+// to BrokerURL is blocked.
+type Strategy string
+
+const (
+	// StrategyDomainFronting POSTs to the broker through a CDN-fronted
+	// domain, with the real broker host only visible in the TLS SNI's
+	// encrypted companion (the Host header).
+	StrategyDomainFronting Strategy = "domain-fronting"
+
+	// StrategyAMPCache routes the same broker request through Google's
+	// AMP cache, for networks that block the front domain directly.
+	StrategyAMPCache Strategy = "amp-cache"
+)
+
+// ampCacheHost is Google's AMP cache used as a rendezvous fallback.
+const ampCacheHost = "cdn.ampproject.org"
+
+// Rendezvous exchanges WebRTC SDP offers/answers with the Snowflake
+// broker over domain-fronted HTTPS.
+type Rendezvous struct {
+	brokerURL   string
+	frontDomain string
+	client      *http.Client
+	logger      *zap.Logger
+
+	// OnContacted, if set, is called the first time the broker accepts a
+	// client poll request, regardless of strategy.
+	OnContacted func()
+}
+
+// NewRendezvous creates a broker rendezvous client.
+func NewRendezvous(brokerURL, frontDomain string, logger *zap.Logger) *Rendezvous {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Rendezvous{
+		brokerURL:   brokerURL,
+		frontDomain: frontDomain,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		logger:      logger,
+	}
+}
+
+type clientPollRequest struct {
+	Offer string `json:"offer"`
+	NAT   string `json:"nat"`
+}
+
+type clientPollResponse struct {
+	Answer string `json:"answer"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Negotiate sends a WebRTC offer to the broker and returns the matched
+// proxy's SDP answer. It tries domain fronting first and falls back to
+// the AMP cache strategy if the fronted request fails outright.
+func (r *Rendezvous) Negotiate(ctx context.Context, offerSDP string) (string, error) {
+	answer, err := r.negotiate(ctx, offerSDP, StrategyDomainFronting)
+	if err == nil {
+		return answer, nil
+	}
+	r.logger.Warn("domain-fronted rendezvous failed, trying AMP cache", zap.Error(err))
+	return r.negotiate(ctx, offerSDP, StrategyAMPCache)
+}
+
+func (r *Rendezvous) negotiate(ctx context.Context, offerSDP string, strategy Strategy) (string, error) {
+	payload, err := json.Marshal(clientPollRequest{Offer: offerSDP, NAT: "unknown"})
+	if err != nil {
+		return "", fmt.Errorf("marshal client poll request: %w", err)
+	}
+
+	endpoint := r.brokerURL + "client"
+	host := r.frontDomain
+	if strategy == StrategyAMPCache {
+		endpoint = fmt.Sprintf("https://%s/c/s/%s", ampCacheHost, r.frontDomain)
+		host = ampCacheHost
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("build broker request: %w", err)
+	}
+	req.Host = host
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Session-Id", newSessionID())
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("broker request via %s: %w", strategy, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("broker returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	var pollResp clientPollResponse
+	if err := json.NewDecoder(resp.Body).Decode(&pollResp); err != nil {
+		return "", fmt.Errorf("decode broker response: %w", err)
+	}
+	if pollResp.Error != "" {
+		return "", fmt.Errorf("broker declined offer: %s", pollResp.Error)
+	}
+	if pollResp.Answer == "" {
+		return "", fmt.Errorf("broker matched no proxy")
+	}
+
+	r.logger.Debug("rendezvous succeeded", zap.String("strategy", string(strategy)))
+	if r.OnContacted != nil {
+		r.OnContacted()
+	}
+	return pollResp.Answer, nil
+}
@@ -0,0 +1,183 @@
+package snowflake
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/xtaci/kcp-go/v5"
+	"github.com/xtaci/smux"
+	"go.uber.org/zap"
+)
+
+// sessionIDLen is the number of random bytes prepended to every KCP
+// packet so the server can recognize which logical session a payload
+// belongs to even after the client has moved on to a different peer.
+const sessionIDLen = 8
+
+func newSessionID() string {
+	b := make([]byte, sessionIDLen)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Session is a turbo-tunnel-style logical connection: a single KCP+smux
+// stream multiplexer carried over a PeerPool of WebRTC data channels. When
+// the peer currently in use dies, unacknowledged KCP segments are simply
+// resent on whichever peer comes up next — the session ID keeps the
+// server-side reassembly pointed at the same logical connection.
+type Session struct {
+	id      []byte
+	pool    *PeerPool
+	pconn   *turboPacketConn
+	kcpConn *kcp.UDPSession
+	smuxSes *smux.Session
+	logger  *zap.Logger
+}
+
+// NewSession builds a turbo-tunnel session on top of an already-started
+// peer pool.
+func NewSession(pool *PeerPool, logger *zap.Logger) (*Session, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	idHex := newSessionID()
+	id, err := hex.DecodeString(idHex)
+	if err != nil {
+		return nil, fmt.Errorf("decode session id: %w", err)
+	}
+
+	pconn := newTurboPacketConn(id, pool, logger)
+
+	kcpConn, err := kcp.NewConn3(1, nil, nil, 0, 0, pconn)
+	if err != nil {
+		return nil, fmt.Errorf("create kcp session: %w", err)
+	}
+	kcpConn.SetNoDelay(1, 10, 2, 1)
+	kcpConn.SetWindowSize(1024, 1024)
+	kcpConn.SetStreamMode(true)
+
+	smuxCfg := smux.DefaultConfig()
+	smuxSes, err := smux.Client(kcpConn, smuxCfg)
+	if err != nil {
+		kcpConn.Close()
+		return nil, fmt.Errorf("create smux session: %w", err)
+	}
+
+	logger.Info("turbo-tunnel session established", zap.String("session_id", idHex))
+
+	return &Session{id: id, pool: pool, pconn: pconn, kcpConn: kcpConn, smuxSes: smuxSes, logger: logger}, nil
+}
+
+// OpenStream returns a new multiplexed stream over the session. Multiple
+// calls can be issued if the caller wants concurrent logical connections
+// across the same turbo-tunnel session.
+func (s *Session) OpenStream() (*smux.Stream, error) {
+	return s.smuxSes.OpenStream()
+}
+
+// Close tears down the smux session, the KCP connection, and the
+// underlying packet conn (but not the peer pool, which may be shared).
+func (s *Session) Close() error {
+	_ = s.smuxSes.Close()
+	_ = s.kcpConn.Close()
+	return s.pconn.Close()
+}
+
+// turboPacketConn presents the PeerPool as a single net.PacketConn to KCP,
+// prepending the session ID to every outgoing datagram and stripping it
+// from every incoming one. Because KCP retransmits unacknowledged segments
+// on its own timer, a Write that targets a dead peer simply gets resent
+// the next time Current() returns a live one.
+type turboPacketConn struct {
+	id     []byte
+	pool   *PeerPool
+	logger *zap.Logger
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func newTurboPacketConn(id []byte, pool *PeerPool, logger *zap.Logger) *turboPacketConn {
+	return &turboPacketConn{id: id, pool: pool, logger: logger}
+}
+
+func (t *turboPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	for {
+		peer := t.pool.Current()
+		if peer == nil {
+			time.Sleep(50 * time.Millisecond)
+			continue
+		}
+
+		buf := make([]byte, len(b)+sessionIDLen)
+		n, err := peer.Read(buf)
+		if err != nil {
+			continue // this peer died mid-read; the pool will replace it
+		}
+		if n < sessionIDLen {
+			continue
+		}
+		if !sessionMatches(t.id, buf[:sessionIDLen]) {
+			continue // stray data from a previous session sharing this peer
+		}
+
+		copy(b, buf[sessionIDLen:n])
+		return n - sessionIDLen, snowflakeAddr{}, nil
+	}
+}
+
+func (t *turboPacketConn) WriteTo(b []byte, _ net.Addr) (int, error) {
+	peer := t.pool.Current()
+	if peer == nil {
+		return 0, fmt.Errorf("turbotunnel: no live peer to write to")
+	}
+
+	framed := make([]byte, 0, len(b)+sessionIDLen)
+	framed = append(framed, t.id...)
+	framed = append(framed, b...)
+
+	if _, err := peer.Write(framed); err != nil {
+		// KCP will retransmit; surface nothing fatal here since another
+		// peer may come up before the retransmit timer fires.
+		return 0, nil
+	}
+	return len(b), nil
+}
+
+func sessionMatches(id, got []byte) bool {
+	if len(id) != len(got) {
+		return false
+	}
+	for i := range id {
+		if id[i] != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *turboPacketConn) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	return nil
+}
+
+func (t *turboPacketConn) LocalAddr() net.Addr                { return snowflakeAddr{} }
+func (t *turboPacketConn) SetDeadline(dl time.Time) error      { return nil }
+func (t *turboPacketConn) SetReadDeadline(dl time.Time) error  { return nil }
+func (t *turboPacketConn) SetWriteDeadline(dl time.Time) error { return nil }
+
+// encodeUint64 is a small helper kept for future protocol versions that
+// want a numeric session id instead of the current random byte string.
+func encodeUint64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
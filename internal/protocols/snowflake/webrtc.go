@@ -0,0 +1,234 @@
+package snowflake
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pion/datachannel"
+	"github.com/pion/webrtc/v3"
+	"go.uber.org/zap"
+)
+
+// buildICEConfig turns a list of STUN URLs into a pion WebRTC configuration.
+func buildICEConfig(stunURLs []string) webrtc.Configuration {
+	servers := make([]webrtc.ICEServer, 0, len(stunURLs))
+	for _, u := range stunURLs {
+		servers = append(servers, webrtc.ICEServer{URLs: []string{u}})
+	}
+	return webrtc.Configuration{ICEServers: servers}
+}
+
+// SnowflakeConn adapts a single pion WebRTC data channel to a net.Conn so
+// it can be used as a transport for the turbo-tunnel KCP session.
+type SnowflakeConn struct {
+	pc     *webrtc.PeerConnection
+	dc     *webrtc.DataChannel
+	raw    *deadlineRWC
+	logger *zap.Logger
+	dead   chan struct{}
+}
+
+// DialPeer negotiates a new WebRTC connection to a volunteer proxy through
+// the broker and returns it wrapped as a SnowflakeConn. The data channel is
+// ordered so the KCP layer above sees in-order, possibly-lossy delivery —
+// the same model as a real UDP link.
+func DialPeer(ctx context.Context, rv *Rendezvous, stunURLs []string, logger *zap.Logger) (*SnowflakeConn, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	pc, err := webrtc.NewPeerConnection(buildICEConfig(stunURLs))
+	if err != nil {
+		return nil, fmt.Errorf("create peer connection: %w", err)
+	}
+
+	ordered := true
+	dc, err := pc.CreateDataChannel("snowflake", &webrtc.DataChannelInit{Ordered: &ordered})
+	if err != nil {
+		pc.Close()
+		return nil, fmt.Errorf("create data channel: %w", err)
+	}
+
+	offer, err := pc.CreateOffer(nil)
+	if err != nil {
+		pc.Close()
+		return nil, fmt.Errorf("create offer: %w", err)
+	}
+	if err := pc.SetLocalDescription(offer); err != nil {
+		pc.Close()
+		return nil, fmt.Errorf("set local description: %w", err)
+	}
+
+	answerSDP, err := rv.Negotiate(ctx, offer.SDP)
+	if err != nil {
+		pc.Close()
+		return nil, fmt.Errorf("broker rendezvous: %w", err)
+	}
+
+	answer := webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer, SDP: answerSDP}
+	if err := pc.SetRemoteDescription(answer); err != nil {
+		pc.Close()
+		return nil, fmt.Errorf("set remote description: %w", err)
+	}
+
+	conn := &SnowflakeConn{pc: pc, dc: dc, logger: logger, dead: make(chan struct{})}
+
+	opened := make(chan struct{})
+	dc.OnOpen(func() {
+		raw, err := dc.Detach()
+		if err != nil {
+			logger.Error("detach data channel failed", zap.Error(err))
+			close(conn.dead)
+			return
+		}
+		conn.raw = newDeadlineRWC(raw)
+		close(opened)
+	})
+	dc.OnClose(func() {
+		select {
+		case <-conn.dead:
+		default:
+			close(conn.dead)
+		}
+	})
+
+	select {
+	case <-opened:
+		return conn, nil
+	case <-conn.dead:
+		pc.Close()
+		return nil, fmt.Errorf("data channel closed before opening")
+	case <-time.After(20 * time.Second):
+		pc.Close()
+		return nil, fmt.Errorf("timed out waiting for data channel to open")
+	case <-ctx.Done():
+		pc.Close()
+		return nil, ctx.Err()
+	}
+}
+
+// Dead reports when the underlying peer connection has gone away, so the
+// pool can replace it eagerly instead of waiting for a failed write.
+func (c *SnowflakeConn) Dead() <-chan struct{} { return c.dead }
+
+func (c *SnowflakeConn) Read(b []byte) (int, error)  { return c.raw.Read(b) }
+func (c *SnowflakeConn) Write(b []byte) (int, error) { return c.raw.Write(b) }
+
+func (c *SnowflakeConn) Close() error {
+	if c.raw != nil {
+		_ = c.raw.Close()
+	}
+	return c.pc.Close()
+}
+
+func (c *SnowflakeConn) LocalAddr() net.Addr                { return snowflakeAddr{} }
+func (c *SnowflakeConn) RemoteAddr() net.Addr               { return snowflakeAddr{} }
+func (c *SnowflakeConn) SetDeadline(t time.Time) error      { return c.raw.SetDeadline(t) }
+func (c *SnowflakeConn) SetReadDeadline(t time.Time) error  { return c.raw.SetReadDeadline(t) }
+func (c *SnowflakeConn) SetWriteDeadline(t time.Time) error { return c.raw.SetWriteDeadline(t) }
+
+// snowflakeAddr is a placeholder net.Addr — WebRTC data channels have no
+// meaningful IP/port, but net.Conn requires one.
+type snowflakeAddr struct{}
+
+func (snowflakeAddr) Network() string { return "snowflake" }
+func (snowflakeAddr) String() string  { return "snowflake-peer" }
+
+// deadlineRWC wraps the datachannel.ReadWriteCloser returned by
+// DataChannel.Detach with real read/write deadline tracking. The detached
+// interface itself exposes no deadline methods (only the concrete pion
+// type's read side does, and it's not part of the interface), so
+// SnowflakeConn can't just forward to the raw channel and still claim to
+// be a net.Conn; this wrapper tracks its own deadlines and enforces them
+// against the underlying blocking Read/Write calls.
+type deadlineRWC struct {
+	rwc datachannel.ReadWriteCloser
+
+	mu            sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+}
+
+func newDeadlineRWC(rwc datachannel.ReadWriteCloser) *deadlineRWC {
+	return &deadlineRWC{rwc: rwc}
+}
+
+func (d *deadlineRWC) Read(b []byte) (int, error) {
+	return d.do(d.deadline(true), func() (int, error) { return d.rwc.Read(b) })
+}
+
+func (d *deadlineRWC) Write(b []byte) (int, error) {
+	return d.do(d.deadline(false), func() (int, error) { return d.rwc.Write(b) })
+}
+
+func (d *deadlineRWC) Close() error { return d.rwc.Close() }
+
+func (d *deadlineRWC) SetDeadline(t time.Time) error {
+	d.mu.Lock()
+	d.readDeadline = t
+	d.writeDeadline = t
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *deadlineRWC) SetReadDeadline(t time.Time) error {
+	d.mu.Lock()
+	d.readDeadline = t
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *deadlineRWC) SetWriteDeadline(t time.Time) error {
+	d.mu.Lock()
+	d.writeDeadline = t
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *deadlineRWC) deadline(read bool) time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if read {
+		return d.readDeadline
+	}
+	return d.writeDeadline
+}
+
+// do runs op, which blocks on the underlying data channel, and races it
+// against deadline. There's no way to cancel a pion datachannel read or
+// write already in flight, so a timed-out op is simply abandoned — its
+// goroutine reports its eventual result to an abandoned, GC'd channel.
+func (d *deadlineRWC) do(deadline time.Time, op func() (int, error)) (int, error) {
+	if deadline.IsZero() {
+		return op()
+	}
+	wait := time.Until(deadline)
+	if wait <= 0 {
+		return 0, os.ErrDeadlineExceeded
+	}
+
+	result := make(chan struct {
+		n   int
+		err error
+	}, 1)
+	go func() {
+		n, err := op()
+		result <- struct {
+			n   int
+			err error
+		}{n, err}
+	}()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case r := <-result:
+		return r.n, r.err
+	case <-timer.C:
+		return 0, os.ErrDeadlineExceeded
+	}
+}
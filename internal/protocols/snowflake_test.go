@@ -72,7 +72,7 @@ func TestSnowflakeProtocol_InRegistry(t *testing.T) {
 	if len(chain) != 2 {
 		t.Fatalf("expected 2 protocols in chain, got %d", len(chain))
 	}
-	// VLESS (priority 1) should come before Snowflake (priority 99)
+	// VLESS (priority 4) should come before Snowflake (priority 99)
 	if chain[0].Name() != "vless" {
 		t.Errorf("chain[0] = %q, want 'vless'", chain[0].Name())
 	}
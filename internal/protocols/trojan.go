@@ -4,6 +4,11 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/fabiano/entropy-tunnel/internal/protocols/trojan"
 )
 
 // TrojanProtocol implements the Protocol interface for Trojan connections.
@@ -11,6 +16,16 @@ import (
 type TrojanProtocol struct {
 	available bool
 	wsPath    string
+
+	// password authenticates DialContext; validHashes/fallback configure
+	// Listen's server-side auth and cover site. Both sides are optional
+	// at construction and filled in via SetClientAuth/SetServerAuth,
+	// mirroring how DomainFrontProtocol only becomes usable after Probe.
+	password    string
+	validHashes map[string]bool
+	fallback    http.Handler
+
+	logger *zap.Logger
 }
 
 // NewTrojan creates a new Trojan protocol adapter.
@@ -21,24 +36,87 @@ func NewTrojan(wsPath string) *TrojanProtocol {
 	return &TrojanProtocol{
 		available: true,
 		wsPath:    wsPath,
+		logger:    zap.NewNop(),
 	}
 }
 
-func (t *TrojanProtocol) Name() string     { return "trojan" }
-func (t *TrojanProtocol) Priority() int     { return 2 } // Fallback after VLESS
-func (t *TrojanProtocol) Available() bool   { return t.available }
+func (t *TrojanProtocol) Name() string    { return "trojan" }
+func (t *TrojanProtocol) Priority() int   { return 3 } // Plain-WS fallback, after domain fronting; above hand-rolled VLESS
+func (t *TrojanProtocol) Available() bool { return t.available }
+
+// SetClientAuth configures the password DialContext authenticates new
+// connections with. logger may be nil to keep the existing (default
+// no-op) logger.
+func (t *TrojanProtocol) SetClientAuth(password string, logger *zap.Logger) {
+	t.password = password
+	if logger != nil {
+		t.logger = logger
+	}
+}
+
+// SetServerAuth configures the passwords Listen accepts (hashed and
+// compared in constant time) and the cover-site handler unauthenticated
+// or non-WebSocket hits fall through to, so an active prober scanning the
+// port sees an ordinary site instead of a protocol error. fallback may be
+// nil for a bare 404; logger may be nil to keep the existing logger.
+func (t *TrojanProtocol) SetServerAuth(passwords []string, fallback http.Handler, logger *zap.Logger) {
+	hashes := make(map[string]bool, len(passwords))
+	for _, p := range passwords {
+		hashes[trojan.PasswordHash(p)] = true
+	}
+	t.validHashes = hashes
+	t.fallback = fallback
+	if logger != nil {
+		t.logger = logger
+	}
+}
 
+// DialContext opens a Trojan-over-WebSocket connection to addr, which
+// doubles as both the WebSocket server dialed and the Trojan CONNECT
+// request's destination. The only caller today — the pt-client/pt-server
+// Tor Pluggable Transport wrapper in cmd/entropy-client and
+// cmd/entropy-server — always hands this the fixed bridge address Tor
+// itself supplies, with no separate final destination to route to, so
+// there's nothing else for the request header to usefully carry.
 func (t *TrojanProtocol) DialContext(ctx context.Context, addr string) (net.Conn, error) {
-	// TODO: Implement Trojan-GO WebSocket dial
-	return nil, fmt.Errorf("trojan dial not yet implemented")
+	if t.password == "" {
+		return nil, fmt.Errorf("trojan: no password configured (call SetClientAuth first)")
+	}
+	return trojan.Dial(ctx, addr, t.wsPath, t.password, addr, nil)
 }
 
 func (t *TrojanProtocol) Listen(addr string) (net.Listener, error) {
-	// TODO: Implement Trojan-GO WebSocket listener
-	return nil, fmt.Errorf("trojan listen not yet implemented")
+	if t.validHashes == nil {
+		return nil, fmt.Errorf("trojan: no server auth configured (call SetServerAuth first)")
+	}
+	return trojan.Listen(addr, t.wsPath, t.validHashes, t.fallback, zapTrojanLogger{t.logger})
 }
 
 // WSPath returns the WebSocket path for this Trojan instance.
 func (t *TrojanProtocol) WSPath() string {
 	return t.wsPath
 }
+
+// zapTrojanLogger adapts *zap.Logger to trojan.Logger so
+// internal/protocols/trojan doesn't need to import zap itself for two
+// debug-level log lines.
+type zapTrojanLogger struct {
+	l *zap.Logger
+}
+
+func (z zapTrojanLogger) Debug(msg string, keysAndValues ...any) {
+	z.l.Debug(msg, toZapFields(keysAndValues)...)
+}
+
+func (z zapTrojanLogger) Warn(msg string, keysAndValues ...any) {
+	z.l.Warn(msg, toZapFields(keysAndValues)...)
+}
+
+func toZapFields(kv []any) []zap.Field {
+	fields := make([]zap.Field, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, _ := kv[i].(string)
+		fields = append(fields, zap.Any(key, kv[i+1]))
+	}
+	return fields
+}
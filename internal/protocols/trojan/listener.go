@@ -0,0 +1,84 @@
+package trojan
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// multiReaderConn is a net.Conn whose Read is served from r instead of the
+// embedded Conn directly, so bytes the handshake already buffered while
+// parsing the auth line and request header (via bufio.Reader.ReadString/
+// Read) aren't lost once payload relay begins.
+type multiReaderConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c multiReaderConn) Read(b []byte) (int, error) { return c.r.Read(b) }
+
+// trojanListener adapts an http.Server accepting WebSocket upgrades into a
+// net.Listener of de-obfuscated connections, the same shape
+// internal/pt.ServeToORPort and the rest of the Protocol interface expect
+// from Listen.
+type trojanListener struct {
+	ln     net.Listener
+	server *http.Server
+
+	connCh chan net.Conn
+	errCh  chan error
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newListener(ln net.Listener) *trojanListener {
+	return &trojanListener{
+		ln:     ln,
+		connCh: make(chan net.Conn),
+		errCh:  make(chan error, 1),
+		closed: make(chan struct{}),
+	}
+}
+
+// offer hands an accepted, authenticated connection to Accept, or reports
+// false if the listener has since been closed (the caller should close
+// the connection itself in that case).
+func (l *trojanListener) offer(c net.Conn) bool {
+	select {
+	case l.connCh <- c:
+		return true
+	case <-l.closed:
+		return false
+	}
+}
+
+func (l *trojanListener) fail(err error) {
+	select {
+	case l.errCh <- err:
+	default:
+	}
+}
+
+func (l *trojanListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.connCh:
+		return c, nil
+	case err := <-l.errCh:
+		return nil, err
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *trojanListener) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		close(l.closed)
+		err = l.server.Close()
+	})
+	return err
+}
+
+func (l *trojanListener) Addr() net.Addr { return l.ln.Addr() }
@@ -0,0 +1,336 @@
+package trojan
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// Trojan-GO request header constants (not an IETF spec — this mirrors the
+// wire format the reference trojan-go implementation uses).
+const (
+	cmdConnect = 0x01
+
+	atypIPv4   = 0x01
+	atypDomain = 0x03
+	atypIPv6   = 0x04
+)
+
+var crlf = []byte{'\r', '\n'}
+
+// PasswordHash returns the lowercase hex SHA-224 digest Trojan uses to
+// authenticate a connection, per the reference implementation's choice of
+// hash (long enough to resist brute force, short enough to double as a
+// fixed-width framing marker in the byte stream). Callers configuring
+// Listen's validHashes hash each accepted password with this first.
+func PasswordHash(password string) string {
+	sum := sha256.Sum224([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+// Dial opens a Trojan-over-WebSocket connection to server (host:port):
+// a wss:// WebSocket handshake, followed by the Trojan auth line and
+// CONNECT request for dest, per the trojan-go wire format. tlsConfig may
+// be nil to use the default config with ServerName set from server's
+// host; pass one with a custom ServerName/RootCAs when the WebSocket
+// front isn't the same host the TLS certificate was issued for.
+func Dial(ctx context.Context, server, wsPath, password, dest string, tlsConfig *tls.Config) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(server)
+	if err != nil {
+		return nil, fmt.Errorf("trojan: invalid server address %q: %w", server, err)
+	}
+
+	cfg := tlsConfig
+	if cfg == nil {
+		cfg = &tls.Config{ServerName: host}
+	} else if cfg.ServerName == "" {
+		clone := cfg.Clone()
+		clone.ServerName = host
+		cfg = clone
+	}
+
+	var dialer net.Dialer
+	raw, err := dialer.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, fmt.Errorf("trojan: dial %s: %w", server, err)
+	}
+
+	tlsConn := tls.Client(raw, cfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		raw.Close()
+		return nil, fmt.Errorf("trojan: tls handshake with %s: %w", server, err)
+	}
+
+	ws, err := dialWebSocket(tlsConn, host, wsPath)
+	if err != nil {
+		tlsConn.Close()
+		return nil, fmt.Errorf("trojan: websocket handshake: %w", err)
+	}
+
+	header, err := encodeRequest(dest)
+	if err != nil {
+		ws.Close()
+		return nil, err
+	}
+
+	payload := make([]byte, 0, len(PasswordHash(password))+2+len(header))
+	payload = append(payload, []byte(PasswordHash(password))...)
+	payload = append(payload, crlf...)
+	payload = append(payload, header...)
+	if _, err := ws.Write(payload); err != nil {
+		ws.Close()
+		return nil, fmt.Errorf("trojan: write auth/request header: %w", err)
+	}
+
+	return ws, nil
+}
+
+// encodeRequest builds a Trojan CONNECT request: CMD(1) || ATYP(1) ||
+// DST.ADDR || DST.PORT(2), choosing ATYP from whether dest's host parses
+// as an IPv4/IPv6 literal or falls back to a domain name.
+func encodeRequest(dest string) ([]byte, error) {
+	host, portStr, err := net.SplitHostPort(dest)
+	if err != nil {
+		return nil, fmt.Errorf("trojan: invalid destination %q: %w", dest, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 0 || port > 0xFFFF {
+		return nil, fmt.Errorf("trojan: invalid destination port in %q", dest)
+	}
+
+	req := []byte{cmdConnect}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			req = append(req, atypIPv4)
+			req = append(req, ip4...)
+		} else {
+			req = append(req, atypIPv6)
+			req = append(req, ip.To16()...)
+		}
+	} else {
+		if len(host) > 0xFF {
+			return nil, fmt.Errorf("trojan: domain name %q too long", host)
+		}
+		req = append(req, atypDomain, byte(len(host)))
+		req = append(req, host...)
+	}
+
+	req = append(req, byte(port>>8), byte(port))
+	req = append(req, crlf...)
+	return req, nil
+}
+
+// decodeRequest parses a Trojan CONNECT request from r (positioned right
+// after the auth line's CRLF) and returns its DST.ADDR:DST.PORT.
+func decodeRequest(r *bufio.Reader) (dest string, err error) {
+	cmd, err := r.ReadByte()
+	if err != nil {
+		return "", fmt.Errorf("trojan: read cmd: %w", err)
+	}
+	if cmd != cmdConnect {
+		return "", fmt.Errorf("trojan: unsupported cmd 0x%02x (only CONNECT is implemented)", cmd)
+	}
+
+	atyp, err := r.ReadByte()
+	if err != nil {
+		return "", fmt.Errorf("trojan: read atyp: %w", err)
+	}
+
+	var host string
+	switch atyp {
+	case atypIPv4:
+		buf := make([]byte, 4)
+		if _, err := readFullBuf(r, buf); err != nil {
+			return "", fmt.Errorf("trojan: read ipv4 addr: %w", err)
+		}
+		host = net.IP(buf).String()
+	case atypIPv6:
+		buf := make([]byte, 16)
+		if _, err := readFullBuf(r, buf); err != nil {
+			return "", fmt.Errorf("trojan: read ipv6 addr: %w", err)
+		}
+		host = net.IP(buf).String()
+	case atypDomain:
+		n, err := r.ReadByte()
+		if err != nil {
+			return "", fmt.Errorf("trojan: read domain length: %w", err)
+		}
+		buf := make([]byte, n)
+		if _, err := readFullBuf(r, buf); err != nil {
+			return "", fmt.Errorf("trojan: read domain: %w", err)
+		}
+		host = string(buf)
+	default:
+		return "", fmt.Errorf("trojan: unsupported atyp 0x%02x", atyp)
+	}
+
+	portBuf := make([]byte, 2)
+	if _, err := readFullBuf(r, portBuf); err != nil {
+		return "", fmt.Errorf("trojan: read port: %w", err)
+	}
+	port := int(portBuf[0])<<8 | int(portBuf[1])
+
+	if _, err := readFullBuf(r, make([]byte, 2)); err != nil { // trailing CRLF
+		return "", fmt.Errorf("trojan: read trailing crlf: %w", err)
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
+
+func readFullBuf(r *bufio.Reader, buf []byte) (int, error) {
+	n := 0
+	for n < len(buf) {
+		m, err := r.Read(buf[n:])
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Conn is an authenticated, de-obfuscated Trojan connection accepted by
+// Listen: ordinary payload bytes in and out, with Dest available for
+// callers that want to route by the client's requested destination
+// instead of (as the Tor Pluggable Transport server does) forwarding
+// everywhere to one fixed backend.
+type Conn struct {
+	net.Conn
+	Dest string
+}
+
+// Listen starts an HTTP server on addr that accepts WebSocket upgrades on
+// wsPath, authenticates each connection's Trojan auth line against
+// validHashes (hex SHA-224 password digests, compared in constant time),
+// and returns accepted, de-obfuscated connections through the returned
+// net.Listener's Accept. Any request that isn't a WebSocket upgrade for
+// wsPath — the common shape an active prober sends — is served by
+// fallback instead, so a censor probing the port sees an ordinary site
+// rather than a protocol error. A request that upgrades but then fails
+// the auth check can't be handed back to fallback (the HTTP response is
+// already committed), so it's simply closed.
+func Listen(addr, wsPath string, validHashes map[string]bool, fallback http.Handler, logger Logger) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("trojan: listen on %s: %w", addr, err)
+	}
+	if fallback == nil {
+		fallback = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.NotFound(w, r)
+		})
+	}
+	if logger == nil {
+		logger = nopLogger{}
+	}
+
+	tl := newListener(ln)
+	handler := &upgradeHandler{
+		path:        wsPath,
+		validHashes: validHashes,
+		fallback:    fallback,
+		logger:      logger,
+		accept:      tl.offer,
+	}
+	tl.server = &http.Server{Handler: handler}
+
+	go func() {
+		tl.fail(tl.server.Serve(ln))
+	}()
+
+	return tl, nil
+}
+
+// Logger is the minimal logging surface Listen needs, satisfied by
+// *zap.Logger's Debug/Warn methods without this package importing zap
+// directly (internal/protocols/trojan has no other reason to depend on
+// the logging library, matching how internal/protocols/domainfront takes
+// a concrete *zap.Logger but this package — one level further from the
+// rest of the tree — only needs two methods of it).
+type Logger interface {
+	Debug(msg string, keysAndValues ...any)
+	Warn(msg string, keysAndValues ...any)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Warn(string, ...any)  {}
+
+type upgradeHandler struct {
+	path        string
+	validHashes map[string]bool
+	fallback    http.Handler
+	logger      Logger
+	accept      func(net.Conn) bool
+}
+
+func (h *upgradeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != h.path || !isWebSocketUpgrade(r) {
+		h.fallback.ServeHTTP(w, r)
+		return
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		h.fallback.ServeHTTP(w, r)
+		return
+	}
+	rawConn, bufrw, err := hj.Hijack()
+	if err != nil {
+		h.logger.Warn("trojan: hijack failed", "error", err)
+		return
+	}
+
+	ws, err := upgradeWebSocket(rawConn, bufrw, r.Header.Get("Sec-WebSocket-Key"))
+	if err != nil {
+		h.logger.Warn("trojan: websocket upgrade failed", "error", err)
+		rawConn.Close()
+		return
+	}
+
+	br := bufio.NewReader(ws)
+	hashLine, err := br.ReadString('\n')
+	if err != nil || len(hashLine) < 58 || hashLine[56] != '\r' {
+		h.logger.Debug("trojan: malformed or missing auth line")
+		ws.Close()
+		return
+	}
+	hash := hashLine[:56]
+
+	if !validHash(h.validHashes, hash) {
+		h.logger.Debug("trojan: auth failed, closing")
+		ws.Close()
+		return
+	}
+
+	dest, err := decodeRequest(br)
+	if err != nil {
+		h.logger.Warn("trojan: malformed request header", "error", err)
+		ws.Close()
+		return
+	}
+
+	if !h.accept(&Conn{Conn: multiReaderConn{Conn: ws, r: br}, Dest: dest}) {
+		ws.Close()
+	}
+}
+
+// validHash does a constant-time membership check against validHashes so
+// a timing attack can't narrow down a valid password one byte at a time.
+func validHash(validHashes map[string]bool, hash string) bool {
+	ok := false
+	for candidate := range validHashes {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(candidate)) == 1 {
+			ok = true
+		}
+	}
+	return ok
+}
@@ -0,0 +1,95 @@
+package trojan
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+)
+
+func TestPasswordHash(t *testing.T) {
+	h := PasswordHash("hunter2")
+	if len(h) != 56 {
+		t.Errorf("PasswordHash length = %d, want 56 (hex SHA-224)", len(h))
+	}
+	if h != PasswordHash("hunter2") {
+		t.Error("PasswordHash not deterministic for the same input")
+	}
+	if h == PasswordHash("hunter3") {
+		t.Error("PasswordHash collided for different inputs")
+	}
+}
+
+func TestEncodeDecodeRequest_Domain(t *testing.T) {
+	req, err := encodeRequest("example.com:443")
+	if err != nil {
+		t.Fatalf("encodeRequest() error = %v", err)
+	}
+
+	dest, err := decodeRequest(bufio.NewReader(bytes.NewReader(req)))
+	if err != nil {
+		t.Fatalf("decodeRequest() error = %v", err)
+	}
+	if dest != "example.com:443" {
+		t.Errorf("dest = %q, want %q", dest, "example.com:443")
+	}
+}
+
+func TestEncodeDecodeRequest_IPv4(t *testing.T) {
+	req, err := encodeRequest("127.0.0.1:8080")
+	if err != nil {
+		t.Fatalf("encodeRequest() error = %v", err)
+	}
+	if req[1] != atypIPv4 {
+		t.Errorf("atyp = 0x%02x, want atypIPv4", req[1])
+	}
+
+	dest, err := decodeRequest(bufio.NewReader(bytes.NewReader(req)))
+	if err != nil {
+		t.Fatalf("decodeRequest() error = %v", err)
+	}
+	if dest != "127.0.0.1:8080" {
+		t.Errorf("dest = %q, want %q", dest, "127.0.0.1:8080")
+	}
+}
+
+func TestEncodeDecodeRequest_IPv6(t *testing.T) {
+	req, err := encodeRequest("[::1]:53")
+	if err != nil {
+		t.Fatalf("encodeRequest() error = %v", err)
+	}
+	if req[1] != atypIPv6 {
+		t.Errorf("atyp = 0x%02x, want atypIPv6", req[1])
+	}
+
+	dest, err := decodeRequest(bufio.NewReader(bytes.NewReader(req)))
+	if err != nil {
+		t.Fatalf("decodeRequest() error = %v", err)
+	}
+	if dest != "::1:53" && dest != "[::1]:53" {
+		t.Errorf("dest = %q, want ::1 round-tripped with port 53", dest)
+	}
+}
+
+func TestEncodeRequest_InvalidDestination(t *testing.T) {
+	if _, err := encodeRequest("not-a-valid-dest"); err == nil {
+		t.Error("expected error for destination without a port")
+	}
+}
+
+func TestDecodeRequest_UnsupportedCmd(t *testing.T) {
+	buf := []byte{0x02, atypIPv4, 127, 0, 0, 1, 0, 80, '\r', '\n'}
+	if _, err := decodeRequest(bufio.NewReader(bytes.NewReader(buf))); err == nil {
+		t.Error("expected error for unsupported cmd")
+	}
+}
+
+func TestValidHash(t *testing.T) {
+	hashes := map[string]bool{PasswordHash("correct"): true}
+
+	if !validHash(hashes, PasswordHash("correct")) {
+		t.Error("validHash rejected a known-good password hash")
+	}
+	if validHash(hashes, PasswordHash("wrong")) {
+		t.Error("validHash accepted an unknown password hash")
+	}
+}
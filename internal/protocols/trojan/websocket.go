@@ -0,0 +1,297 @@
+// Package trojan implements the Trojan-GO protocol over a hand-rolled
+// WebSocket transport: no TLS/HTTP library beyond net/http's server-side
+// Hijack (used only to get at the raw conn after the upgrade) and
+// crypto/tls for the client-side wss:// dial. The framing, handshake, and
+// Trojan request header are all implemented from RFC 6455 and the
+// Trojan-GO wire format directly, matching how internal/protocols/domainfront
+// hand-rolls its own HTTP framing instead of pulling in a library.
+package trojan
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// wsGUID is the fixed GUID RFC 6455 §1.3 has clients and servers concatenate
+// onto Sec-WebSocket-Key before SHA-1 hashing, to prove the peer actually
+// understood the WebSocket handshake rather than echoing the key back.
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+func acceptKey(clientKey string) string {
+	h := sha1.New()
+	h.Write([]byte(clientKey))
+	h.Write([]byte(wsGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func newClientKey() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("generate Sec-WebSocket-Key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(raw), nil
+}
+
+// dialWebSocket performs the RFC 6455 §4.1 client handshake over an
+// already-connected conn (plain TCP or, for wss://, a completed
+// *tls.Conn) and wraps it as a framed net.Conn on success.
+func dialWebSocket(conn net.Conn, host, path string) (net.Conn, error) {
+	key, err := newClientKey()
+	if err != nil {
+		return nil, err
+	}
+
+	req := "GET " + path + " HTTP/1.1\r\n" +
+		"Host: " + host + "\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: " + key + "\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := io.WriteString(conn, req); err != nil {
+		return nil, fmt.Errorf("write websocket upgrade request: %w", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		return nil, fmt.Errorf("read websocket upgrade response: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("websocket upgrade: server returned %s", resp.Status)
+	}
+	if got, want := resp.Header.Get("Sec-WebSocket-Accept"), acceptKey(key); got != want {
+		return nil, fmt.Errorf("websocket upgrade: Sec-WebSocket-Accept mismatch")
+	}
+
+	return newConn(conn, br, true), nil
+}
+
+// isWebSocketUpgrade reports whether r carries the headers RFC 6455 §4.2.1
+// requires of a WebSocket upgrade request.
+func isWebSocketUpgrade(r *http.Request) bool {
+	return r.Method == http.MethodGet &&
+		headerContainsToken(r.Header, "Connection", "upgrade") &&
+		asciiEqualFold(r.Header.Get("Upgrade"), "websocket") &&
+		r.Header.Get("Sec-WebSocket-Key") != "" &&
+		r.Header.Get("Sec-WebSocket-Version") == "13"
+}
+
+func asciiEqualFold(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		ca, cb := a[i], b[i]
+		if 'A' <= ca && ca <= 'Z' {
+			ca += 'a' - 'A'
+		}
+		if 'A' <= cb && cb <= 'Z' {
+			cb += 'a' - 'A'
+		}
+		if ca != cb {
+			return false
+		}
+	}
+	return true
+}
+
+func headerContainsToken(h http.Header, key, token string) bool {
+	for _, v := range h.Values(key) {
+		if asciiEqualFold(v, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// upgradeWebSocket completes the server-side RFC 6455 handshake over a
+// hijacked connection, writing the 101 response by hand since Hijack
+// forfeits use of the original http.ResponseWriter.
+func upgradeWebSocket(conn net.Conn, bufrw *bufio.ReadWriter, clientKey string) (net.Conn, error) {
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(clientKey) + "\r\n\r\n"
+	if _, err := bufrw.WriteString(resp); err != nil {
+		return nil, fmt.Errorf("write websocket upgrade response: %w", err)
+	}
+	if err := bufrw.Flush(); err != nil {
+		return nil, fmt.Errorf("flush websocket upgrade response: %w", err)
+	}
+
+	return newConn(conn, bufrw.Reader, false), nil
+}
+
+// conn is a net.Conn backed by RFC 6455 WebSocket framing: Write sends one
+// binary frame per call (masked when isClient, per §5.1 — servers must
+// reject unmasked client frames), Read drains frame payloads into the
+// caller's buffer, buffering any leftover bytes between calls.
+type conn struct {
+	net.Conn
+	br       *bufio.Reader
+	isClient bool
+
+	readMu   sync.Mutex
+	leftover []byte
+}
+
+func newConn(c net.Conn, br *bufio.Reader, isClient bool) *conn {
+	return &conn{Conn: c, br: br, isClient: isClient}
+}
+
+func (c *conn) Write(b []byte) (int, error) {
+	frame, err := encodeFrame(wsOpBinary, b, c.isClient)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := c.Conn.Write(frame); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (c *conn) Read(b []byte) (int, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	for len(c.leftover) == 0 {
+		op, payload, err := readFrame(c.br)
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case wsOpClose:
+			return 0, io.EOF
+		case wsOpPing:
+			pong, err := encodeFrame(wsOpPong, payload, c.isClient)
+			if err != nil {
+				return 0, err
+			}
+			if _, err := c.Conn.Write(pong); err != nil {
+				return 0, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		default:
+			c.leftover = payload
+		}
+	}
+
+	n := copy(b, c.leftover)
+	c.leftover = c.leftover[n:]
+	return n, nil
+}
+
+// encodeFrame builds a single, unfragmented RFC 6455 frame (FIN=1) for op
+// carrying payload, masked per §5.3 when isClient (every frame a client
+// sends to a server must be masked; server-to-client frames must not be).
+func encodeFrame(op byte, payload []byte, isClient bool) ([]byte, error) {
+	var header []byte
+	header = append(header, 0x80|op) // FIN=1, RSV=0, opcode
+
+	maskBit := byte(0)
+	if isClient {
+		maskBit = 0x80
+	}
+
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, maskBit|byte(n))
+	case n <= 0xFFFF:
+		header = append(header, maskBit|126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, maskBit|127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	if !isClient {
+		return append(header, payload...), nil
+	}
+
+	var maskKey [4]byte
+	if _, err := rand.Read(maskKey[:]); err != nil {
+		return nil, fmt.Errorf("generate websocket mask key: %w", err)
+	}
+	header = append(header, maskKey[:]...)
+
+	masked := make([]byte, len(payload))
+	for i, b := range payload {
+		masked[i] = b ^ maskKey[i%4]
+	}
+	return append(header, masked...), nil
+}
+
+// readFrame reads one RFC 6455 frame from br and returns its opcode and
+// unmasked payload. Fragmented messages (FIN=0) aren't expected from
+// either side of a Trojan-over-WebSocket stream, so they're rejected
+// rather than reassembled.
+func readFrame(br *bufio.Reader) (op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(br, head[:]); err != nil {
+		return 0, nil, err
+	}
+
+	fin := head[0]&0x80 != 0
+	op = head[0] & 0x0F
+	if !fin {
+		return 0, nil, fmt.Errorf("websocket: fragmented frames are not supported")
+	}
+
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(br, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(br, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	var maskKey [4]byte
+	if masked {
+		if _, err := io.ReadFull(br, maskKey[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		return 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= maskKey[i%4]
+		}
+	}
+
+	return op, payload, nil
+}
@@ -0,0 +1,142 @@
+package trojan
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEncodeReadFrame_RoundTrip(t *testing.T) {
+	for _, isClient := range []bool{true, false} {
+		payload := []byte("hello trojan over websocket")
+		frame, err := encodeFrame(wsOpBinary, payload, isClient)
+		if err != nil {
+			t.Fatalf("encodeFrame() error = %v", err)
+		}
+
+		op, got, err := readFrame(bufio.NewReader(bytes.NewReader(frame)))
+		if err != nil {
+			t.Fatalf("readFrame() error = %v", err)
+		}
+		if op != wsOpBinary {
+			t.Errorf("opcode = 0x%x, want wsOpBinary", op)
+		}
+		if !bytes.Equal(got, payload) {
+			t.Errorf("payload = %q, want %q", got, payload)
+		}
+	}
+}
+
+func TestEncodeReadFrame_ExtendedLength(t *testing.T) {
+	payload := bytes.Repeat([]byte{0x42}, 70000) // forces the 64-bit extended length path
+	frame, err := encodeFrame(wsOpBinary, payload, true)
+	if err != nil {
+		t.Fatalf("encodeFrame() error = %v", err)
+	}
+
+	_, got, err := readFrame(bufio.NewReader(bytes.NewReader(frame)))
+	if err != nil {
+		t.Fatalf("readFrame() error = %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Error("extended-length payload did not round-trip")
+	}
+}
+
+func TestReadFrame_RejectsFragmented(t *testing.T) {
+	frame := []byte{0x02, 0x00} // opcode=binary, FIN=0, unmasked, zero-length
+	if _, _, err := readFrame(bufio.NewReader(bytes.NewReader(frame))); err == nil {
+		t.Error("expected error reading a fragmented (FIN=0) frame")
+	}
+}
+
+func TestAcceptKey_RFC6455Example(t *testing.T) {
+	// Example from RFC 6455 §1.3.
+	got := acceptKey("dGhlIHNhbXBsZSBub25jZQ==")
+	want := "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="
+	if got != want {
+		t.Errorf("acceptKey() = %q, want %q", got, want)
+	}
+}
+
+func TestIsWebSocketUpgrade(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+
+	if !isWebSocketUpgrade(req) {
+		t.Error("isWebSocketUpgrade() = false for a well-formed upgrade request")
+	}
+}
+
+func TestIsWebSocketUpgrade_RejectsPlainRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	if isWebSocketUpgrade(req) {
+		t.Error("isWebSocketUpgrade() = true for a plain GET with no upgrade headers")
+	}
+}
+
+// TestWebSocketHandshake_RoundTrip exercises dialWebSocket/upgradeWebSocket
+// end to end over a net.Pipe (no TLS involved — that part is exercised
+// separately by Dial's own net.Dialer/tls.Client plumbing) and checks
+// that payload written by one side arrives intact on the other.
+func TestWebSocketHandshake_RoundTrip(t *testing.T) {
+	clientRaw, serverRaw := net.Pipe()
+
+	serverConnCh := make(chan net.Conn, 1)
+	serverErrCh := make(chan error, 1)
+	go func() {
+		br := bufio.NewReader(serverRaw)
+		req, err := http.ReadRequest(br)
+		if err != nil {
+			serverErrCh <- err
+			return
+		}
+		if !isWebSocketUpgrade(req) {
+			serverErrCh <- io.ErrUnexpectedEOF
+			return
+		}
+		bufrw := bufio.NewReadWriter(br, bufio.NewWriter(serverRaw))
+		ws, err := upgradeWebSocket(serverRaw, bufrw, req.Header.Get("Sec-WebSocket-Key"))
+		if err != nil {
+			serverErrCh <- err
+			return
+		}
+		serverConnCh <- ws
+	}()
+
+	clientConn, err := dialWebSocket(clientRaw, "example.com", "/ws")
+	if err != nil {
+		t.Fatalf("dialWebSocket() error = %v", err)
+	}
+	defer clientConn.Close()
+
+	var serverConn net.Conn
+	select {
+	case serverConn = <-serverConnCh:
+	case err := <-serverErrCh:
+		t.Fatalf("server-side upgrade error = %v", err)
+	}
+	defer serverConn.Close()
+
+	const msg = "hello over a hand-rolled websocket"
+	go func() {
+		if _, err := clientConn.Write([]byte(msg)); err != nil {
+			t.Errorf("client Write() error = %v", err)
+		}
+	}()
+
+	buf := make([]byte, len(msg))
+	if _, err := io.ReadFull(serverConn, buf); err != nil {
+		t.Fatalf("server Read() error = %v", err)
+	}
+	if string(buf) != msg {
+		t.Errorf("server received %q, want %q", buf, msg)
+	}
+}
@@ -0,0 +1,101 @@
+package protocols
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestTrojanProtocol_Name(t *testing.T) {
+	tp := NewTrojan("/ws")
+	if tp.Name() != "trojan" {
+		t.Errorf("Name() = %q, want 'trojan'", tp.Name())
+	}
+}
+
+func TestTrojanProtocol_DefaultWSPath(t *testing.T) {
+	tp := NewTrojan("")
+	if tp.WSPath() != "/ws" {
+		t.Errorf("WSPath() = %q, want default '/ws'", tp.WSPath())
+	}
+}
+
+func TestTrojanProtocol_DialContextWithoutAuth(t *testing.T) {
+	tp := NewTrojan("/ws")
+	if _, err := tp.DialContext(context.Background(), "example.com:443"); err == nil {
+		t.Error("expected error dialing before SetClientAuth has run")
+	}
+}
+
+func TestTrojanProtocol_ListenWithoutAuth(t *testing.T) {
+	tp := NewTrojan("/ws")
+	if _, err := tp.Listen(":0"); err == nil {
+		t.Error("expected error listening before SetServerAuth has run")
+	}
+}
+
+func TestTrojanProtocol_ListenAfterSetServerAuth(t *testing.T) {
+	tp := NewTrojan("/ws")
+	tp.SetServerAuth([]string{"hunter2"}, nil, nil)
+
+	ln, err := tp.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer ln.Close()
+}
+
+func TestTrojanProtocol_InRegistry(t *testing.T) {
+	r := NewRegistry()
+	vless := NewVLESS()
+	trojan := NewTrojan("/ws")
+
+	if err := r.Register(vless); err != nil {
+		t.Fatalf("Register(vless) error = %v", err)
+	}
+	if err := r.Register(trojan); err != nil {
+		t.Fatalf("Register(trojan) error = %v", err)
+	}
+
+	chain := r.FallbackChain()
+	if len(chain) != 2 {
+		t.Fatalf("expected 2 protocols in chain, got %d", len(chain))
+	}
+}
+
+func TestTrojanProtocol_FallbackServesCoverSite(t *testing.T) {
+	cover := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	tp := NewTrojan("/ws")
+	tp.SetServerAuth([]string{"hunter2"}, cover, nil)
+
+	ln, err := tp.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer ln.Close()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/not-the-ws-path")
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTeapot {
+		t.Errorf("status = %d, want %d (cover site should have served the request)", resp.StatusCode, http.StatusTeapot)
+	}
+}
+
+func TestTrojanProtocol_SetClientAuth(t *testing.T) {
+	tp := NewTrojan("/ws")
+	tp.SetClientAuth("hunter2", nil)
+
+	// Still expected to fail (no real server listening), but the error
+	// should now come from the dial itself, not the "no password" guard.
+	_, err := tp.DialContext(context.Background(), "127.0.0.1:1")
+	if err == nil {
+		t.Fatal("expected dial error connecting to a closed port")
+	}
+}
@@ -2,31 +2,105 @@ package protocols
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
+
+	"github.com/fabiano/entropy-tunnel/internal/protocols/vless"
 )
 
 // VLESSProtocol implements the Protocol interface for VLESS connections.
-// This wraps the Xray-core VLESS transport.
+// The wire protocol is hand-rolled in internal/protocols/vless rather than
+// depending on xray-core, the same way TrojanProtocol hand-rolls Trojan.
+// Only "none" encryption over plain TCP (optionally TLS-wrapped) is
+// supported; XTLS flow control (e.g. xtls-rprx-vision) and REALITY are NOT
+// implemented here — that's the xray-core-backed Engine's job (build tags
+// "xray"/"exec"). Priority is deliberately below DomainFrontProtocol and
+// TrojanProtocol: unlike its xray-core counterpart, this adapter can't
+// camouflage a connection as TLS-to-a-legitimate-site, so it shouldn't be
+// preferred over protocols that can. Callers that need a specific
+// flow/transport/REALITY must go through RequestCapabilities, which
+// hard-fails instead of silently downgrading — REALITY is this project's
+// flagship camouflage feature, and a silent fallback to plain VLESS would
+// be worse than an explicit error.
 type VLESSProtocol struct {
 	available bool
+
+	// id authenticates DialContext; validIDs configures Listen's
+	// server-side auth. Both are optional at construction and filled in
+	// via SetClientAuth/SetServerAuth, mirroring TrojanProtocol.
+	id       [16]byte
+	hasID    bool
+	validIDs map[[16]byte]bool
+
+	tlsConfig *tls.Config
 }
 
-// NewVLESS creates a new VLESS protocol adapter.
+// NewVLESS creates a new VLESS protocol adapter. It starts out available
+// for the one thing it actually implements — "none"-encryption VLESS over
+// plain or TLS-wrapped TCP; call RequestCapabilities if the caller needs
+// anything more specific than that.
 func NewVLESS() *VLESSProtocol {
 	return &VLESSProtocol{available: true}
 }
 
-func (v *VLESSProtocol) Name() string     { return "vless" }
-func (v *VLESSProtocol) Priority() int     { return 1 } // Highest priority
-func (v *VLESSProtocol) Available() bool   { return v.available }
+func (v *VLESSProtocol) Name() string  { return "vless" }
+func (v *VLESSProtocol) Priority() int { return 4 } // Below domain-fronting and Trojan: can't do REALITY/XTLS camouflage
+
+func (v *VLESSProtocol) Available() bool { return v.available }
+
+// RequestCapabilities records the flow control, transport, and REALITY
+// requirements a caller needs from this adapter, and hard-fails if any of
+// them isn't something the hand-rolled implementation actually supports.
+// Only flow "" or "none" (no XTLS splicing) and transport "" or "tcp"
+// (optionally TLS-wrapped) are implemented, and REALITY isn't implemented
+// at all — so a request for xtls-rprx-vision, an alternate transport, or
+// REALITY returns an error immediately and marks the adapter unavailable,
+// rather than silently running as plain VLESS-over-TCP instead.
+func (v *VLESSProtocol) RequestCapabilities(flow, transport string, reality bool) error {
+	switch {
+	case flow != "" && flow != "none":
+		v.available = false
+		return fmt.Errorf("vless: flow %q not supported by the hand-rolled implementation (only \"none\"); use the xray-core-backed engine (build tag \"xray\") for XTLS", flow)
+	case transport != "" && transport != "tcp":
+		v.available = false
+		return fmt.Errorf("vless: transport %q not supported by the hand-rolled implementation (only \"tcp\")", transport)
+	case reality:
+		v.available = false
+		return fmt.Errorf("vless: REALITY is not supported by the hand-rolled implementation; use the xray-core-backed engine (build tag \"xray\") instead")
+	}
+	return nil
+}
+
+// SetClientAuth configures the UUID DialContext authenticates new
+// connections with, and the TLS config (nil for plain TCP) the handshake
+// uses before the VLESS request header is sent.
+func (v *VLESSProtocol) SetClientAuth(id [16]byte, tlsConfig *tls.Config) {
+	v.id = id
+	v.hasID = true
+	v.tlsConfig = tlsConfig
+}
+
+// SetServerAuth configures the UUIDs Listen accepts and the TLS config
+// (nil for plain TCP) each accepted connection is wrapped with.
+func (v *VLESSProtocol) SetServerAuth(validIDs map[[16]byte]bool, tlsConfig *tls.Config) {
+	v.validIDs = validIDs
+	v.tlsConfig = tlsConfig
+}
 
+// DialContext opens a VLESS connection to addr, which doubles as both the
+// server dialed and the VLESS request's destination, matching how
+// TrojanProtocol.DialContext treats addr.
 func (v *VLESSProtocol) DialContext(ctx context.Context, addr string) (net.Conn, error) {
-	// TODO: Implement via Xray-core VLESS outbound
-	return nil, fmt.Errorf("VLESS dial not yet implemented (requires xray-core integration)")
+	if !v.hasID {
+		return nil, fmt.Errorf("vless: no UUID configured (call SetClientAuth first)")
+	}
+	return vless.Dial(ctx, addr, v.id, addr, v.tlsConfig)
 }
 
 func (v *VLESSProtocol) Listen(addr string) (net.Listener, error) {
-	// TODO: Implement via Xray-core VLESS inbound
-	return nil, fmt.Errorf("VLESS listen not yet implemented (requires xray-core integration)")
+	if v.validIDs == nil {
+		return nil, fmt.Errorf("vless: no server auth configured (call SetServerAuth first)")
+	}
+	return vless.Listen(addr, v.validIDs, v.tlsConfig)
 }
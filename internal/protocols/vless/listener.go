@@ -0,0 +1,114 @@
+package vless
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+)
+
+// Conn is an authenticated VLESS connection accepted by Listen: ordinary
+// payload bytes in and out, with Dest available for callers that want to
+// route by the client's requested destination.
+type Conn struct {
+	net.Conn
+	Dest string
+}
+
+// multiReaderConn is a net.Conn whose Read is served from r, so bytes
+// already buffered while parsing the request header aren't lost once
+// payload relay begins (mirroring trojan's multiReaderConn).
+type multiReaderConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c multiReaderConn) Read(b []byte) (int, error) { return c.r.Read(b) }
+
+// Listen starts accepting VLESS connections on addr, authenticating each
+// against validIDs (as produced by ParseUUID). tlsConfig may be nil for
+// plain TCP; when set, every accepted connection is TLS-server-handshaked
+// before the VLESS request header is parsed.
+func Listen(addr string, validIDs map[[16]byte]bool, tlsConfig *tls.Config) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("vless: listen on %s: %w", addr, err)
+	}
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+
+	vl := &listener{
+		ln:     ln,
+		connCh: make(chan net.Conn),
+		errCh:  make(chan error, 1),
+		closed: make(chan struct{}),
+	}
+	go vl.acceptLoop(validIDs)
+	return vl, nil
+}
+
+type listener struct {
+	ln     net.Listener
+	connCh chan net.Conn
+	errCh  chan error
+	closed chan struct{}
+}
+
+func (l *listener) acceptLoop(validIDs map[[16]byte]bool) {
+	for {
+		raw, err := l.ln.Accept()
+		if err != nil {
+			select {
+			case l.errCh <- err:
+			default:
+			}
+			return
+		}
+		go l.handshake(raw, validIDs)
+	}
+}
+
+func (l *listener) handshake(raw net.Conn, validIDs map[[16]byte]bool) {
+	br := bufio.NewReader(raw)
+
+	id, dest, err := decodeRequest(br)
+	if err != nil || !validID(validIDs, id) {
+		raw.Close()
+		return
+	}
+
+	if _, err := raw.Write(encodeResponseHeader()); err != nil {
+		raw.Close()
+		return
+	}
+
+	conn := &Conn{Conn: multiReaderConn{Conn: raw, r: br}, Dest: dest}
+	select {
+	case l.connCh <- conn:
+	case <-l.closed:
+		raw.Close()
+	}
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.connCh:
+		return c, nil
+	case err := <-l.errCh:
+		return nil, err
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *listener) Close() error {
+	select {
+	case <-l.closed:
+	default:
+		close(l.closed)
+	}
+	return l.ln.Close()
+}
+
+func (l *listener) Addr() net.Addr { return l.ln.Addr() }
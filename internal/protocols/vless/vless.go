@@ -0,0 +1,274 @@
+// Package vless implements the VLESS protocol's wire format (request/
+// response headers and UUID-based auth) directly in Go, the same way
+// internal/protocols/trojan hand-rolls Trojan-over-WebSocket instead of
+// depending on an external implementation. Only the "none" encryption
+// mode over plain TCP (optionally TLS-wrapped) is implemented; flow
+// control modes like xtls-rprx-vision require XTLS record-layer splicing
+// this package doesn't attempt.
+package vless
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// VLESS request/response header constants, per the protocol's de facto
+// spec (there is no IETF RFC; this mirrors the v2ray/xray-core wire
+// format).
+const (
+	version = 0x00
+
+	cmdTCP = 0x01
+	cmdUDP = 0x02
+
+	atypIPv4   = 0x01
+	atypDomain = 0x02
+	atypIPv6   = 0x03
+)
+
+// ParseUUID parses a standard dashed UUID string ("xxxxxxxx-xxxx-...")
+// into the 16 raw bytes the wire format uses to identify a user.
+func ParseUUID(s string) ([16]byte, error) {
+	var id [16]byte
+	clean := strings.ReplaceAll(s, "-", "")
+	if len(clean) != 32 {
+		return id, fmt.Errorf("vless: invalid UUID %q", s)
+	}
+	b, err := hex.DecodeString(clean)
+	if err != nil {
+		return id, fmt.Errorf("vless: invalid UUID %q: %w", s, err)
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+// Dial opens a VLESS connection to server, authenticating as id and
+// requesting dest as the CONNECT target. tlsConfig may be nil for plain
+// TCP (e.g. when TLS termination happens at an outer layer); when set,
+// a TLS handshake runs before the VLESS request header is sent.
+func Dial(ctx context.Context, server string, id [16]byte, dest string, tlsConfig *tls.Config) (net.Conn, error) {
+	var dialer net.Dialer
+	raw, err := dialer.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, fmt.Errorf("vless: dial %s: %w", server, err)
+	}
+
+	var conn net.Conn = raw
+	if tlsConfig != nil {
+		cfg := tlsConfig
+		if cfg.ServerName == "" {
+			host, _, splitErr := net.SplitHostPort(server)
+			if splitErr == nil {
+				cfg = cfg.Clone()
+				cfg.ServerName = host
+			}
+		}
+		tlsConn := tls.Client(raw, cfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			raw.Close()
+			return nil, fmt.Errorf("vless: tls handshake with %s: %w", server, err)
+		}
+		conn = tlsConn
+	}
+
+	req, err := encodeRequest(id, dest)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if _, err := conn.Write(req); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("vless: write request header: %w", err)
+	}
+
+	return &clientConn{Conn: conn, br: bufio.NewReader(conn)}, nil
+}
+
+// clientConn strips the one-time VLESS response header (version + empty
+// addons) from the first Read, after which it's an ordinary net.Conn.
+type clientConn struct {
+	net.Conn
+	br       *bufio.Reader
+	consumed bool
+}
+
+func (c *clientConn) Read(b []byte) (int, error) {
+	if !c.consumed {
+		if err := decodeResponseHeader(c.br); err != nil {
+			return 0, err
+		}
+		c.consumed = true
+	}
+	return c.br.Read(b)
+}
+
+// encodeRequest builds a VLESS request header: VERSION(1) || UUID(16) ||
+// ADDONS_LEN(1, always 0 here) || CMD(1) || PORT(2) || ATYP(1) ||
+// DST.ADDR.
+func encodeRequest(id [16]byte, dest string) ([]byte, error) {
+	host, portStr, err := net.SplitHostPort(dest)
+	if err != nil {
+		return nil, fmt.Errorf("vless: invalid destination %q: %w", dest, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 0 || port > 0xFFFF {
+		return nil, fmt.Errorf("vless: invalid destination port in %q", dest)
+	}
+
+	req := make([]byte, 0, 16+8+len(host))
+	req = append(req, version)
+	req = append(req, id[:]...)
+	req = append(req, 0x00) // addons length: none
+	req = append(req, cmdTCP)
+	req = append(req, byte(port>>8), byte(port))
+
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			req = append(req, atypIPv4)
+			req = append(req, ip4...)
+		} else {
+			req = append(req, atypIPv6)
+			req = append(req, ip.To16()...)
+		}
+	} else {
+		if len(host) > 0xFF {
+			return nil, fmt.Errorf("vless: domain name %q too long", host)
+		}
+		req = append(req, atypDomain, byte(len(host)))
+		req = append(req, host...)
+	}
+
+	return req, nil
+}
+
+// decodeRequest parses a VLESS request header from r and returns the
+// authenticated user's ID and requested destination.
+func decodeRequest(r *bufio.Reader) (id [16]byte, dest string, err error) {
+	ver, err := r.ReadByte()
+	if err != nil {
+		return id, "", fmt.Errorf("vless: read version: %w", err)
+	}
+	if ver != version {
+		return id, "", fmt.Errorf("vless: unsupported version 0x%02x", ver)
+	}
+
+	if _, err := readFullBuf(r, id[:]); err != nil {
+		return id, "", fmt.Errorf("vless: read UUID: %w", err)
+	}
+
+	addonsLen, err := r.ReadByte()
+	if err != nil {
+		return id, "", fmt.Errorf("vless: read addons length: %w", err)
+	}
+	if addonsLen > 0 {
+		if _, err := readFullBuf(r, make([]byte, addonsLen)); err != nil {
+			return id, "", fmt.Errorf("vless: read addons: %w", err)
+		}
+	}
+
+	cmd, err := r.ReadByte()
+	if err != nil {
+		return id, "", fmt.Errorf("vless: read cmd: %w", err)
+	}
+	if cmd != cmdTCP && cmd != cmdUDP {
+		return id, "", fmt.Errorf("vless: unsupported cmd 0x%02x", cmd)
+	}
+
+	portBuf := make([]byte, 2)
+	if _, err := readFullBuf(r, portBuf); err != nil {
+		return id, "", fmt.Errorf("vless: read port: %w", err)
+	}
+	port := int(portBuf[0])<<8 | int(portBuf[1])
+
+	atyp, err := r.ReadByte()
+	if err != nil {
+		return id, "", fmt.Errorf("vless: read atyp: %w", err)
+	}
+
+	var host string
+	switch atyp {
+	case atypIPv4:
+		buf := make([]byte, 4)
+		if _, err := readFullBuf(r, buf); err != nil {
+			return id, "", fmt.Errorf("vless: read ipv4 addr: %w", err)
+		}
+		host = net.IP(buf).String()
+	case atypIPv6:
+		buf := make([]byte, 16)
+		if _, err := readFullBuf(r, buf); err != nil {
+			return id, "", fmt.Errorf("vless: read ipv6 addr: %w", err)
+		}
+		host = net.IP(buf).String()
+	case atypDomain:
+		n, err := r.ReadByte()
+		if err != nil {
+			return id, "", fmt.Errorf("vless: read domain length: %w", err)
+		}
+		buf := make([]byte, n)
+		if _, err := readFullBuf(r, buf); err != nil {
+			return id, "", fmt.Errorf("vless: read domain: %w", err)
+		}
+		host = string(buf)
+	default:
+		return id, "", fmt.Errorf("vless: unsupported atyp 0x%02x", atyp)
+	}
+
+	return id, net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
+
+// encodeResponseHeader builds the one-time VLESS response header: VERSION
+// (echoing the request's) || ADDONS_LEN(1, always 0 here).
+func encodeResponseHeader() []byte {
+	return []byte{version, 0x00}
+}
+
+func decodeResponseHeader(r *bufio.Reader) error {
+	ver, err := r.ReadByte()
+	if err != nil {
+		return fmt.Errorf("vless: read response version: %w", err)
+	}
+	if ver != version {
+		return fmt.Errorf("vless: unsupported response version 0x%02x", ver)
+	}
+	addonsLen, err := r.ReadByte()
+	if err != nil {
+		return fmt.Errorf("vless: read response addons length: %w", err)
+	}
+	if addonsLen > 0 {
+		if _, err := readFullBuf(r, make([]byte, addonsLen)); err != nil {
+			return fmt.Errorf("vless: read response addons: %w", err)
+		}
+	}
+	return nil
+}
+
+func readFullBuf(r *bufio.Reader, buf []byte) (int, error) {
+	n := 0
+	for n < len(buf) {
+		m, err := r.Read(buf[n:])
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// validID does a constant-time membership check against validIDs so a
+// timing attack can't narrow down a valid UUID one byte at a time.
+func validID(validIDs map[[16]byte]bool, id [16]byte) bool {
+	ok := false
+	for candidate := range validIDs {
+		if subtle.ConstantTimeCompare(id[:], candidate[:]) == 1 {
+			ok = true
+		}
+	}
+	return ok
+}
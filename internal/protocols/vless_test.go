@@ -0,0 +1,57 @@
+package protocols
+
+import "testing"
+
+func TestVLESSProtocol_Name(t *testing.T) {
+	v := NewVLESS()
+	if v.Name() != "vless" {
+		t.Errorf("Name() = %q, want 'vless'", v.Name())
+	}
+}
+
+func TestVLESSProtocol_AvailableByDefault(t *testing.T) {
+	v := NewVLESS()
+	if !v.Available() {
+		t.Error("Available() should be true before any capability request")
+	}
+}
+
+func TestVLESSProtocol_RequestCapabilities_SupportedIsNoop(t *testing.T) {
+	v := NewVLESS()
+	if err := v.RequestCapabilities("none", "tcp", false); err != nil {
+		t.Fatalf("RequestCapabilities(none, tcp, false) error = %v", err)
+	}
+	if !v.Available() {
+		t.Error("Available() should stay true after a supported capability request")
+	}
+}
+
+func TestVLESSProtocol_RequestCapabilities_RejectsFlow(t *testing.T) {
+	v := NewVLESS()
+	if err := v.RequestCapabilities("xtls-rprx-vision", "", false); err == nil {
+		t.Error("expected error requesting xtls-rprx-vision flow")
+	}
+	if v.Available() {
+		t.Error("Available() should be false after an unsupported flow is requested")
+	}
+}
+
+func TestVLESSProtocol_RequestCapabilities_RejectsTransport(t *testing.T) {
+	v := NewVLESS()
+	if err := v.RequestCapabilities("", "grpc", false); err == nil {
+		t.Error("expected error requesting grpc transport")
+	}
+	if v.Available() {
+		t.Error("Available() should be false after an unsupported transport is requested")
+	}
+}
+
+func TestVLESSProtocol_RequestCapabilities_RejectsReality(t *testing.T) {
+	v := NewVLESS()
+	if err := v.RequestCapabilities("", "", true); err == nil {
+		t.Error("expected error requesting REALITY")
+	}
+	if v.Available() {
+		t.Error("Available() should be false after REALITY is requested")
+	}
+}
@@ -0,0 +1,86 @@
+package pt
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ClientInfo is what ClientSetup resolves the TOR_PT_* client environment
+// into: which methods to bring up and, if tor asked us to route through an
+// upstream proxy, where that is.
+type ClientInfo struct {
+	// MethodNames are the transport methods tor actually wants, already
+	// filtered against the supported list passed to ClientSetup.
+	MethodNames []string
+
+	// ProxyURL is the upstream SOCKS/HTTP proxy tor wants this PT to dial
+	// out through, or nil if TOR_PT_PROXY wasn't set.
+	ProxyURL *url.URL
+}
+
+// ClientSetup performs the client-side PT v2.1 handshake: it negotiates the
+// managed-transport protocol version, parses TOR_PT_CLIENT_TRANSPORTS
+// against supported, and (if set) validates TOR_PT_PROXY, writing the
+// "VERSION"/"PROXY DONE" stdout lines the spec requires along the way. The
+// caller is still responsible for calling Cmethod/CmethodError per method
+// and finishing with CmethodsDone.
+func ClientSetup(supported []string) (*ClientInfo, error) {
+	if err := negotiateVersion(); err != nil {
+		return nil, err
+	}
+
+	requestedRaw, err := getenvRequired("TOR_PT_CLIENT_TRANSPORTS")
+	if err != nil {
+		return nil, err
+	}
+	requested := strings.Split(requestedRaw, ",")
+	methods := methodFilter(requested, supported)
+
+	info := &ClientInfo{MethodNames: methods}
+
+	if proxyRaw := os.Getenv("TOR_PT_PROXY"); proxyRaw != "" {
+		u, err := url.Parse(proxyRaw)
+		if err != nil {
+			ProxyError(err.Error())
+			return nil, fmt.Errorf("pt: invalid TOR_PT_PROXY %q: %w", proxyRaw, err)
+		}
+		info.ProxyURL = u
+		ProxyDone()
+	}
+
+	return info, nil
+}
+
+// Cmethod reports that method has come up as a local SOCKS listener at
+// addr, e.g. Cmethod("obfs4", addr) after starting a SOCKS5 listener on
+// addr. socksVersion is "socks4" or "socks5" per the spec; entropy-tunnel's
+// RegisterAsPT methods are always SOCKS5.
+func Cmethod(name, socksVersion string, addr net.Addr) {
+	line("CMETHOD", name, socksVersion, addr.String())
+}
+
+// CmethodError reports that method failed to come up, with a human-readable
+// reason tor can log.
+func CmethodError(name, msg string) {
+	line("CMETHOD-ERROR", name, msg)
+}
+
+// CmethodsDone signals that every method this process is going to report
+// has been reported, one way or another.
+func CmethodsDone() {
+	line("CMETHODS", "DONE")
+}
+
+// ProxyDone reports that the upstream proxy from TOR_PT_PROXY was accepted.
+func ProxyDone() {
+	line("PROXY", "DONE")
+}
+
+// ProxyError reports that the upstream proxy from TOR_PT_PROXY could not be
+// used, with a human-readable reason.
+func ProxyError(msg string) {
+	line("PROXY-ERROR", msg)
+}
@@ -0,0 +1,218 @@
+// Package pt implements the subset of the Tor Pluggable Transports v2.1
+// spec (https://spec.torproject.org/pt-spec/) that entropy-tunnel needs to
+// be launched as a managed transport by tor, Lantern, or a Snowflake-style
+// broker: the TOR_PT_* environment handshake, CMETHOD/SMETHOD stdout
+// signaling, and the client-side SOCKS4/5 "PROXY DONE" negotiation. It
+// deliberately mirrors the shape of the Tor project's reference goptlib
+// library so anyone who has written a PT before recognizes it immediately.
+package pt
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// version is the only managed-transport protocol version this package
+// speaks. The spec allows negotiating among several; entropy-tunnel only
+// ever needs the current one.
+const version = "1"
+
+// Args holds the key/value options a transport method can receive via
+// TOR_PT_SERVER_TRANSPORT_OPTIONS or a SOCKS username/password, using the
+// spec's escaped "k=v,k=v" encoding (backslash-escapes ',', '=', and '\').
+type Args map[string][]string
+
+// Get returns the first value for key, or "" if it isn't set.
+func (a Args) Get(key string) string {
+	vals, ok := a[key]
+	if !ok || len(vals) == 0 {
+		return ""
+	}
+	return vals[0]
+}
+
+// parsePTArgString parses a single transport's "k=v,k=v" argument string.
+func parsePTArgString(s string) (Args, error) {
+	args := make(Args)
+	if s == "" {
+		return args, nil
+	}
+
+	var key strings.Builder
+	var val strings.Builder
+	inKey := true
+	escaped := false
+
+	flush := func() error {
+		k := key.String()
+		if k == "" {
+			return fmt.Errorf("pt: empty key in argument string %q", s)
+		}
+		args[k] = append(args[k], val.String())
+		key.Reset()
+		val.Reset()
+		return nil
+	}
+
+	for _, r := range s {
+		cur := &val
+		if inKey {
+			cur = &key
+		}
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '=' && inKey:
+			inKey = false
+		case r == ',':
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			inKey = true
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("pt: argument string %q ends in a dangling backslash", s)
+	}
+	if inKey && key.Len() == 0 && val.Len() == 0 {
+		return args, nil
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// parseServerTransportOptions parses TOR_PT_SERVER_TRANSPORT_OPTIONS, whose
+// format is "name1:k=v,k=v;name2:k=v" — a semicolon-separated list of
+// transport names paired with their own Args string.
+func parseServerTransportOptions(s string) (map[string]Args, error) {
+	out := make(map[string]Args)
+	if s == "" {
+		return out, nil
+	}
+	for _, part := range splitUnescaped(s, ';') {
+		name, rest, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("pt: malformed TOR_PT_SERVER_TRANSPORT_OPTIONS entry %q", part)
+		}
+		args, err := parsePTArgString(rest)
+		if err != nil {
+			return nil, err
+		}
+		out[name] = args
+	}
+	return out, nil
+}
+
+// splitUnescaped splits s on sep, ignoring occurrences of sep preceded by a
+// backslash, and leaves the escaping intact for the caller (e.g.
+// parsePTArgString handles the rest of the unescaping itself).
+func splitUnescaped(s string, sep byte) []string {
+	var parts []string
+	var cur strings.Builder
+	escaped := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			cur.WriteByte(c)
+			escaped = false
+		case c == '\\':
+			cur.WriteByte(c)
+			escaped = true
+		case c == sep:
+			parts = append(parts, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	parts = append(parts, cur.String())
+	return parts
+}
+
+// methodFilter resolves a requested/supported pair into the methods that
+// should actually be brought up: every supported one when the request is
+// "*", otherwise their intersection.
+func methodFilter(requested, supported []string) []string {
+	if len(requested) == 1 && requested[0] == "*" {
+		return supported
+	}
+	want := make(map[string]bool, len(requested))
+	for _, m := range requested {
+		want[m] = true
+	}
+	var out []string
+	for _, m := range supported {
+		if want[m] {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func getenvRequired(name string) (string, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok || v == "" {
+		return "", fmt.Errorf("pt: required environment variable %s is not set", name)
+	}
+	return v, nil
+}
+
+func negotiateVersion() error {
+	versions, err := getenvRequired("TOR_PT_MANAGED_TRANSPORT_VER")
+	if err != nil {
+		// Tor always sets this; a missing value means we weren't launched
+		// as a managed transport at all, so there's nowhere to report a
+		// VERSION-ERROR line to. Surface the error to the caller instead.
+		return err
+	}
+	for _, v := range strings.Split(versions, ",") {
+		if v == version {
+			line("VERSION", version)
+			return nil
+		}
+	}
+	line("VERSION-ERROR", "no-version")
+	return fmt.Errorf("pt: tor only offered versions %q, we only speak %q", versions, version)
+}
+
+func line(fields ...string) {
+	fmt.Println(strings.Join(fields, " "))
+}
+
+// resolveTCPAddr parses a "host:port" string required to be usable as a
+// net.TCPAddr (SMETHOD/CMETHOD lines and TOR_PT_ORPORT all use this form).
+func resolveTCPAddr(s string) (*net.TCPAddr, error) {
+	addr, err := net.ResolveTCPAddr("tcp", s)
+	if err != nil {
+		return nil, fmt.Errorf("pt: invalid address %q: %w", s, err)
+	}
+	return addr, nil
+}
+
+func formatArgs(args Args) string {
+	if len(args) == 0 {
+		return ""
+	}
+	var parts []string
+	for k, vals := range args {
+		for _, v := range vals {
+			parts = append(parts, escapeArg(k)+"="+escapeArg(v))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func escapeArg(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `,`, `\,`, `=`, `\=`)
+	return r.Replace(s)
+}
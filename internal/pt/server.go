@@ -0,0 +1,133 @@
+package pt
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// Bindaddr pairs a requested server transport method with the local
+// address tor wants it bound to, plus any per-method options tor supplied
+// via TOR_PT_SERVER_TRANSPORT_OPTIONS.
+type Bindaddr struct {
+	MethodName string
+	Addr       *net.TCPAddr
+	Options    Args
+}
+
+// ServerInfo is what ServerSetup resolves the TOR_PT_* server environment
+// into.
+type ServerInfo struct {
+	// Bindaddrs are the method/address pairs to listen on, already
+	// filtered against the supported list passed to ServerSetup.
+	Bindaddrs []Bindaddr
+
+	// ORAddr is where accepted (and de-obfuscated) connections should be
+	// forwarded, from TOR_PT_ORPORT. Extended ORPort (TOR_PT_EXTENDED_
+	// SERVER_PORT plus cookie auth) isn't implemented; ORAddr is nil if
+	// only the extended port was provided.
+	ORAddr *net.TCPAddr
+}
+
+// ServerSetup performs the server-side PT v2.1 handshake: it negotiates the
+// managed-transport protocol version, parses TOR_PT_SERVER_TRANSPORTS and
+// TOR_PT_SERVER_BINDADDR against supported, and resolves TOR_PT_ORPORT. The
+// caller is still responsible for calling Smethod/SmethodError per bindaddr
+// and finishing with SmethodsDone.
+func ServerSetup(supported []string) (*ServerInfo, error) {
+	if err := negotiateVersion(); err != nil {
+		return nil, err
+	}
+
+	requestedRaw, err := getenvRequired("TOR_PT_SERVER_TRANSPORTS")
+	if err != nil {
+		return nil, err
+	}
+	methods := methodFilter(strings.Split(requestedRaw, ","), supported)
+	wanted := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		wanted[m] = true
+	}
+
+	bindAddrRaw, err := getenvRequired("TOR_PT_SERVER_BINDADDR")
+	if err != nil {
+		return nil, err
+	}
+	options, err := parseServerTransportOptions(os.Getenv("TOR_PT_SERVER_TRANSPORT_OPTIONS"))
+	if err != nil {
+		return nil, err
+	}
+
+	var bindaddrs []Bindaddr
+	for _, pair := range strings.Split(bindAddrRaw, ",") {
+		name, addrStr, ok := strings.Cut(pair, "-")
+		if !ok {
+			return nil, fmt.Errorf("pt: malformed TOR_PT_SERVER_BINDADDR entry %q", pair)
+		}
+		if !wanted[name] {
+			continue
+		}
+		addr, err := resolveTCPAddr(addrStr)
+		if err != nil {
+			return nil, err
+		}
+		bindaddrs = append(bindaddrs, Bindaddr{MethodName: name, Addr: addr, Options: options[name]})
+	}
+
+	info := &ServerInfo{Bindaddrs: bindaddrs}
+	if orAddr := os.Getenv("TOR_PT_ORPORT"); orAddr != "" {
+		addr, err := resolveTCPAddr(orAddr)
+		if err != nil {
+			return nil, err
+		}
+		info.ORAddr = addr
+	}
+
+	return info, nil
+}
+
+// Smethod reports that method has come up listening on addr, optionally
+// with args to hand back to tor (e.g. a public key the bridge line should
+// advertise).
+func Smethod(name string, addr net.Addr, args Args) {
+	fields := []string{"SMETHOD", name, addr.String()}
+	if formatted := formatArgs(args); formatted != "" {
+		fields = append(fields, "ARGS:"+formatted)
+	}
+	line(fields...)
+}
+
+// SmethodError reports that a bindaddr's method failed to come up, with a
+// human-readable reason tor can log.
+func SmethodError(name, msg string) {
+	line("SMETHOD-ERROR", name, msg)
+}
+
+// SmethodsDone signals that every bindaddr this process is going to report
+// has been reported, one way or another.
+func SmethodsDone() {
+	line("SMETHODS", "DONE")
+}
+
+// ServeToORPort accepts connections on ln — which is expected to already
+// perform the transport's de-obfuscation (e.g. protocols.Protocol.Listen)
+// — and relays each one to orAddr, the Tor OR port from ServerInfo.ORAddr.
+// It runs until ln is closed, at which point it returns the Accept error.
+func ServeToORPort(ln net.Listener, orAddr *net.TCPAddr) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			upstream, err := net.DialTCP("tcp", nil, orAddr)
+			if err != nil {
+				return
+			}
+			defer upstream.Close()
+			relay(conn, upstream)
+		}()
+	}
+}
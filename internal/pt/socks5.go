@@ -0,0 +1,174 @@
+package pt
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+)
+
+// DialFunc dials a destination address through some obfuscated transport;
+// it's satisfied by protocols.Protocol.DialContext.
+type DialFunc func(ctx context.Context, addr string) (net.Conn, error)
+
+// SOCKS5Listener is a minimal, no-auth SOCKS5 CONNECT server. Tor speaks
+// SOCKS5 to the address a PT reports via Cmethod, handing over the bridge
+// address it wants reached; ListenSOCKS5 relays each accepted connection
+// through dial, which is expected to perform the transport's own
+// obfuscation before reaching that address.
+type SOCKS5Listener struct {
+	ln   net.Listener
+	dial DialFunc
+}
+
+// ListenSOCKS5 starts a SOCKS5 listener on addr (use "127.0.0.1:0" to let
+// the OS pick a free port) that forwards every accepted CONNECT request
+// through dial. Call Serve to start accepting, and Addr to learn the
+// chosen address for the Cmethod line.
+func ListenSOCKS5(addr string, dial DialFunc) (*SOCKS5Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("pt: listen for SOCKS5 on %q: %w", addr, err)
+	}
+	return &SOCKS5Listener{ln: ln, dial: dial}, nil
+}
+
+// Addr returns the address this listener is bound to.
+func (s *SOCKS5Listener) Addr() net.Addr { return s.ln.Addr() }
+
+// Close stops accepting new connections.
+func (s *SOCKS5Listener) Close() error { return s.ln.Close() }
+
+// Serve accepts connections until the listener is closed, handling each on
+// its own goroutine. It always returns a non-nil error (matching
+// net.Listener.Accept / http.Serve convention).
+func (s *SOCKS5Listener) Serve() error {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *SOCKS5Listener) handle(conn net.Conn) {
+	defer conn.Close()
+
+	dest, err := socks5Handshake(conn)
+	if err != nil {
+		return
+	}
+
+	upstream, err := s.dial(context.Background(), dest)
+	if err != nil {
+		socks5Reply(conn, socks5ReplyHostUnreachable)
+		return
+	}
+	defer upstream.Close()
+
+	if err := socks5Reply(conn, socks5ReplySucceeded); err != nil {
+		return
+	}
+
+	relay(conn, upstream)
+}
+
+const (
+	socks5ReplySucceeded           = 0x00
+	socks5ReplyHostUnreachable     = 0x04
+	socks5ReplyCommandNotSupported = 0x07
+	socks5CmdConnect               = 0x01
+	socks5AtypIPv4                 = 0x01
+	socks5AtypDomain               = 0x03
+	socks5AtypIPv6                 = 0x04
+)
+
+// socks5Handshake reads the SOCKS5 version/method negotiation and CONNECT
+// request, replying "no authentication required" unconditionally (tor
+// never sends credentials to a PT's SOCKS port), and returns the requested
+// "host:port" destination.
+func socks5Handshake(conn net.Conn) (string, error) {
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return "", err
+	}
+	if buf[0] != 0x05 {
+		return "", fmt.Errorf("pt: unsupported SOCKS version %d", buf[0])
+	}
+	nMethods := int(buf[1])
+	methods := make([]byte, nMethods)
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return "", err
+	}
+	if _, err := conn.Write([]byte{0x05, 0x00}); err != nil {
+		return "", err
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", err
+	}
+	if header[0] != 0x05 || header[1] != socks5CmdConnect {
+		socks5Reply(conn, socks5ReplyCommandNotSupported)
+		return "", fmt.Errorf("pt: only the SOCKS5 CONNECT command is supported")
+	}
+
+	var host string
+	switch header[3] {
+	case socks5AtypIPv4:
+		ip := make([]byte, 4)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return "", err
+		}
+		host = net.IP(ip).String()
+	case socks5AtypDomain:
+		lenBuf := make([]byte, 1)
+		if _, err := io.ReadFull(conn, lenBuf); err != nil {
+			return "", err
+		}
+		name := make([]byte, lenBuf[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return "", err
+		}
+		host = string(name)
+	case socks5AtypIPv6:
+		ip := make([]byte, 16)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return "", err
+		}
+		host = net.IP(ip).String()
+	default:
+		return "", fmt.Errorf("pt: unsupported SOCKS5 address type %d", header[3])
+	}
+
+	portBuf := make([]byte, 2)
+	if _, err := io.ReadFull(conn, portBuf); err != nil {
+		return "", err
+	}
+	port := binary.BigEndian.Uint16(portBuf)
+
+	return net.JoinHostPort(host, fmt.Sprintf("%d", port)), nil
+}
+
+func socks5Reply(conn net.Conn, code byte) error {
+	reply := []byte{0x05, code, 0x00, socks5AtypIPv4, 0, 0, 0, 0, 0, 0}
+	_, err := conn.Write(reply)
+	return err
+}
+
+// relay pipes bytes between conn and upstream in both directions until
+// either side closes.
+func relay(conn, upstream net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(upstream, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, upstream)
+		done <- struct{}{}
+	}()
+	<-done
+}
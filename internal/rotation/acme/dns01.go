@@ -0,0 +1,49 @@
+package acme
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// DNSProvider creates and removes the TXT record an ACME DNS-01 challenge
+// is validated against. CloudflareController implements this against its
+// zone's DNS API; any other DNS backend can satisfy it the same way.
+type DNSProvider interface {
+	// CreateTXTRecord publishes value under fqdn (e.g.
+	// "_acme-challenge.example.com") and returns an implementation-defined
+	// ID DeleteTXTRecord can use to remove exactly this record.
+	CreateTXTRecord(ctx context.Context, fqdn, value string) (recordID string, err error)
+	DeleteTXTRecord(ctx context.Context, fqdn, recordID string) error
+}
+
+// waitForPropagation polls fqdn's TXT records until one matches value or
+// timeout elapses. Let's Encrypt's own resolvers see the authoritative
+// zone directly, but polling first avoids burning challenge attempts on
+// a record that hasn't propagated yet.
+func waitForPropagation(ctx context.Context, fqdn, value string, timeout, interval time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	var resolver net.Resolver
+
+	for {
+		records, err := resolver.LookupTXT(ctx, fqdn)
+		if err == nil {
+			for _, rec := range records {
+				if rec == value {
+					return nil
+				}
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("acme: TXT record for %s did not propagate within %s", fqdn, timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
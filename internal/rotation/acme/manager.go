@@ -0,0 +1,241 @@
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/acme"
+)
+
+// LetsEncryptDirectory is the production Let's Encrypt ACME directory
+// URL. Use acme.LetsEncryptStagingURL from golang.org/x/crypto/acme
+// while testing, to avoid production rate limits.
+const LetsEncryptDirectory = "https://acme-v02.api.letsencrypt.org/directory"
+
+const (
+	defaultPropagationTimeout  = 2 * time.Minute
+	defaultPropagationInterval = 5 * time.Second
+	defaultOrderPollInterval   = 3 * time.Second
+)
+
+// Manager obtains and renews certificates for domains via ACME DNS-01,
+// using dns to publish the challenge's TXT record and store to persist
+// the resulting certificate and key.
+type Manager struct {
+	client *acme.Client
+	dns    DNSProvider
+	store  CertStore
+	logger *zap.Logger
+
+	propagationTimeout  time.Duration
+	propagationInterval time.Duration
+}
+
+// NewManager registers a new ACME account against directoryURL (typically
+// LetsEncryptDirectory) and returns a Manager that issues certificates
+// for domains via dns's TXT records, persisting them in store.
+func NewManager(ctx context.Context, directoryURL, contactEmail string, dns DNSProvider, store CertStore, logger *zap.Logger) (*Manager, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	accountKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("acme: generating account key: %w", err)
+	}
+
+	client := &acme.Client{
+		Key:          accountKey,
+		DirectoryURL: directoryURL,
+	}
+
+	account := &acme.Account{Contact: []string{"mailto:" + contactEmail}}
+	if _, err := client.Register(ctx, account, acme.AcceptTOS); err != nil {
+		return nil, fmt.Errorf("acme: registering account: %w", err)
+	}
+
+	return &Manager{
+		client:              client,
+		dns:                 dns,
+		store:               store,
+		logger:              logger,
+		propagationTimeout:  defaultPropagationTimeout,
+		propagationInterval: defaultPropagationInterval,
+	}, nil
+}
+
+// ObtainCertificate requests and stores a certificate for domain,
+// solving a DNS-01 challenge for each authorization the CA requires.
+func (m *Manager) ObtainCertificate(ctx context.Context, domain string) (*Certificate, error) {
+	m.logger.Info("requesting certificate", zap.String("domain", domain))
+
+	order, err := m.client.AuthorizeOrder(ctx, acme.DomainIDs(domain))
+	if err != nil {
+		return nil, fmt.Errorf("acme: authorizing order for %s: %w", domain, err)
+	}
+
+	for _, authzURL := range order.AuthzURLs {
+		if err := m.solveAuthorization(ctx, authzURL); err != nil {
+			return nil, fmt.Errorf("acme: solving authorization for %s: %w", domain, err)
+		}
+	}
+
+	order, err = m.client.WaitOrder(ctx, order.URI)
+	if err != nil {
+		return nil, fmt.Errorf("acme: waiting for order on %s: %w", domain, err)
+	}
+
+	certKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("acme: generating certificate key: %w", err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: domain},
+		DNSNames: []string{domain},
+	}, certKey)
+	if err != nil {
+		return nil, fmt.Errorf("acme: creating CSR for %s: %w", domain, err)
+	}
+
+	der, _, err := m.client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return nil, fmt.Errorf("acme: finalizing order for %s: %w", domain, err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(certKey)
+	if err != nil {
+		return nil, fmt.Errorf("acme: marshaling certificate key: %w", err)
+	}
+
+	cert := &Certificate{
+		Domain:  domain,
+		CertPEM: encodeCertChain(der),
+		KeyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+	notAfter, err := certNotAfter(cert.CertPEM)
+	if err != nil {
+		return nil, fmt.Errorf("acme: reading issued certificate for %s: %w", domain, err)
+	}
+	cert.NotAfter = notAfter
+
+	if err := m.store.Save(ctx, cert); err != nil {
+		return nil, fmt.Errorf("acme: storing certificate for %s: %w", domain, err)
+	}
+
+	m.logger.Info("certificate issued",
+		zap.String("domain", domain),
+		zap.Time("not_after", cert.NotAfter),
+	)
+
+	return cert, nil
+}
+
+// solveAuthorization finds the dns-01 challenge in the authorization at
+// authzURL, publishes its TXT record, and waits for the CA to validate it.
+func (m *Manager) solveAuthorization(ctx context.Context, authzURL string) error {
+	authz, err := m.client.GetAuthorization(ctx, authzURL)
+	if err != nil {
+		return fmt.Errorf("fetching authorization: %w", err)
+	}
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+
+	var chal *acme.Challenge
+	for _, c := range authz.Challenges {
+		if c.Type == "dns-01" {
+			chal = c
+			break
+		}
+	}
+	if chal == nil {
+		return fmt.Errorf("no dns-01 challenge offered for %s", authz.Identifier.Value)
+	}
+
+	record, err := m.client.DNS01ChallengeRecord(chal.Token)
+	if err != nil {
+		return fmt.Errorf("computing dns-01 record: %w", err)
+	}
+
+	fqdn := "_acme-challenge." + authz.Identifier.Value
+	recordID, err := m.dns.CreateTXTRecord(ctx, fqdn, record)
+	if err != nil {
+		return fmt.Errorf("publishing TXT record %s: %w", fqdn, err)
+	}
+	defer func() {
+		if err := m.dns.DeleteTXTRecord(ctx, fqdn, recordID); err != nil {
+			m.logger.Warn("failed to clean up challenge TXT record", zap.String("fqdn", fqdn), zap.Error(err))
+		}
+	}()
+
+	if err := waitForPropagation(ctx, fqdn, record, m.propagationTimeout, m.propagationInterval); err != nil {
+		return err
+	}
+
+	if _, err := m.client.Accept(ctx, chal); err != nil {
+		return fmt.Errorf("accepting challenge: %w", err)
+	}
+	if _, err := m.client.WaitAuthorization(ctx, authzURL); err != nil {
+		return fmt.Errorf("waiting for authorization: %w", err)
+	}
+
+	return nil
+}
+
+// StartRenewal runs a background goroutine that re-obtains cert for
+// domain at roughly two-thirds of its remaining lifetime, replacing it
+// in store each time, mirroring how lego/certmagic drive renewal. It
+// stops when ctx is canceled.
+func (m *Manager) StartRenewal(ctx context.Context, domain string, cert *Certificate) {
+	go m.renewLoop(ctx, domain, cert)
+}
+
+func (m *Manager) renewLoop(ctx context.Context, domain string, cert *Certificate) {
+	for {
+		lifetime := time.Until(cert.NotAfter)
+		renewIn := lifetime * 2 / 3
+		if renewIn < 0 {
+			renewIn = 0
+		}
+
+		m.logger.Info("scheduled certificate renewal",
+			zap.String("domain", domain),
+			zap.Duration("in", renewIn),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(renewIn):
+		}
+
+		renewed, err := m.ObtainCertificate(ctx, domain)
+		if err != nil {
+			m.logger.Error("certificate renewal failed, retrying later",
+				zap.String("domain", domain), zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(defaultOrderPollInterval):
+			}
+			continue
+		}
+		cert = renewed
+	}
+}
+
+func encodeCertChain(der [][]byte) []byte {
+	var pemBytes []byte
+	for _, b := range der {
+		pemBytes = append(pemBytes, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: b})...)
+	}
+	return pemBytes
+}
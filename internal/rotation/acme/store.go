@@ -0,0 +1,142 @@
+// Package acme obtains and renews Let's Encrypt certificates for rotated
+// endpoints by solving DNS-01 challenges against a pluggable DNSProvider
+// (CloudflareController's TXT record API, today), mirroring the
+// order/challenge/renew flow lego and certmagic drive but scoped to just
+// DNS-01 against one zone instead of a general-purpose ACME client.
+package acme
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Certificate is an issued certificate plus where it's stored, attached
+// to a rotated Endpoint's Metadata (as CertPath/KeyPath, or the PEM
+// bytes themselves for an in-memory store) so a TLS-terminating client
+// can pick it up.
+type Certificate struct {
+	Domain   string
+	CertPEM  []byte
+	KeyPEM   []byte
+	NotAfter time.Time
+
+	// CertPath/KeyPath are set by CertStore implementations that persist
+	// to disk (FileCertStore); they're empty for MemCertStore, where
+	// CertPEM/KeyPEM are the only copy.
+	CertPath string
+	KeyPath  string
+}
+
+// CertStore persists issued certificates. FileCertStore and MemCertStore
+// cover the common cases; a user-supplied implementation (e.g. writing
+// to a secrets manager) works the same way.
+type CertStore interface {
+	Save(ctx context.Context, cert *Certificate) error
+	Load(ctx context.Context, domain string) (*Certificate, error)
+}
+
+// FileCertStore saves each domain's certificate and key as
+// "<dir>/<domain>.crt" and "<dir>/<domain>.key".
+type FileCertStore struct {
+	Dir string
+}
+
+// NewFileCertStore creates a FileCertStore rooted at dir, creating it if
+// it doesn't already exist.
+func NewFileCertStore(dir string) (*FileCertStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("acme: creating cert store dir %s: %w", dir, err)
+	}
+	return &FileCertStore{Dir: dir}, nil
+}
+
+func (f *FileCertStore) Save(ctx context.Context, cert *Certificate) error {
+	certPath := filepath.Join(f.Dir, cert.Domain+".crt")
+	keyPath := filepath.Join(f.Dir, cert.Domain+".key")
+
+	if err := os.WriteFile(certPath, cert.CertPEM, 0o644); err != nil {
+		return fmt.Errorf("acme: writing %s: %w", certPath, err)
+	}
+	if err := os.WriteFile(keyPath, cert.KeyPEM, 0o600); err != nil {
+		return fmt.Errorf("acme: writing %s: %w", keyPath, err)
+	}
+
+	cert.CertPath = certPath
+	cert.KeyPath = keyPath
+	return nil
+}
+
+func (f *FileCertStore) Load(ctx context.Context, domain string) (*Certificate, error) {
+	certPath := filepath.Join(f.Dir, domain+".crt")
+	keyPath := filepath.Join(f.Dir, domain+".key")
+
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	notAfter, err := certNotAfter(certPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Certificate{
+		Domain:   domain,
+		CertPEM:  certPEM,
+		KeyPEM:   keyPEM,
+		NotAfter: notAfter,
+		CertPath: certPath,
+		KeyPath:  keyPath,
+	}, nil
+}
+
+// MemCertStore keeps certificates in memory only, for tests or
+// single-process deployments that don't need them to survive a restart.
+type MemCertStore struct {
+	mu    sync.RWMutex
+	certs map[string]*Certificate
+}
+
+// NewMemCertStore creates an empty in-memory cert store.
+func NewMemCertStore() *MemCertStore {
+	return &MemCertStore{certs: make(map[string]*Certificate)}
+}
+
+func (m *MemCertStore) Save(ctx context.Context, cert *Certificate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.certs[cert.Domain] = cert
+	return nil
+}
+
+func (m *MemCertStore) Load(ctx context.Context, domain string) (*Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	cert, ok := m.certs[domain]
+	if !ok {
+		return nil, fmt.Errorf("acme: no certificate stored for %s", domain)
+	}
+	return cert, nil
+}
+
+func certNotAfter(certPEM []byte) (time.Time, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return time.Time{}, fmt.Errorf("acme: no PEM block found in certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("acme: parsing certificate: %w", err)
+	}
+	return cert.NotAfter, nil
+}
@@ -0,0 +1,68 @@
+package rotation
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// AdminHTTPFrontend mounts rotation control endpoints on the client's
+// local API listener, so a GUI (or curl) can trigger a rotation the same
+// way it can hit /api/connect.
+type AdminHTTPFrontend struct {
+	registry *Registry
+	logger   *zap.Logger
+}
+
+// NewAdminHTTPFrontend wraps registry for HTTP access.
+func NewAdminHTTPFrontend(registry *Registry, logger *zap.Logger) *AdminHTTPFrontend {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &AdminHTTPFrontend{registry: registry, logger: logger}
+}
+
+// RegisterRoutes mounts the admin endpoints on mux.
+func (f *AdminHTTPFrontend) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /rotation/rotate", f.handleRotate)
+	mux.HandleFunc("POST /rotation/retire/{id}", f.handleRetire)
+	mux.HandleFunc("GET /rotation/endpoints", f.handleList)
+}
+
+func (f *AdminHTTPFrontend) handleRotate(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Provider string `json:"provider"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Provider == "" {
+		http.Error(w, "request body must include a \"provider\"", http.StatusBadRequest)
+		return
+	}
+
+	ep, err := f.registry.RotateVia(r.Context(), body.Provider)
+	if err != nil {
+		f.logger.Error("admin rotate failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, ep)
+}
+
+func (f *AdminHTTPFrontend) handleRetire(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := f.registry.RetireByID(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "retired", "id": id})
+}
+
+func (f *AdminHTTPFrontend) handleList(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, f.registry.ActiveEndpoints())
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -1,4 +1,6 @@
-// Package akash provides Akash Network deployment integration for EntropyTunnel.
+// Package akash wires a deploy.Provider (Akash by default, but any
+// backend — or a deploy.MultiProvider fanning out to several) into a
+// rotation.Controller.
 package akash
 
 import (
@@ -10,50 +12,91 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/fabiano/entropy-tunnel/internal/deploy"
+	deployakash "github.com/fabiano/entropy-tunnel/internal/deploy/akash"
 	"github.com/fabiano/entropy-tunnel/internal/rotation"
 )
 
-// Controller implements rotation.Controller for Akash Network.
+// Controller implements rotation.Controller on top of a deploy.Provider.
+// It defaults to the Akash deploy.Provider, but Config.Provider lets a
+// caller point it at Fly.io, Railway, an SSH/systemd host, or a
+// deploy.MultiProvider spanning several, so rotation isn't locked to one
+// cloud backend.
 type Controller struct {
-	client       *Client
-	sdlPath      string
-	logger       *zap.Logger
-	mu           sync.RWMutex
-	endpoints    []*rotation.Endpoint
-	deployments  map[string]*DeploymentInfo
-	stopCh       chan struct{}
-	counter      int
+	provider    deploy.Provider
+	sdlPath     string
+	logger      *zap.Logger
+	mu          sync.RWMutex
+	endpoints   []*rotation.Endpoint
+	deployments map[string]*deploy.Deployment
+	stopCh      chan struct{}
+	counter     int
+
+	// locker, if set via SetLocker, gates auto-rotation the same way it
+	// does for rotation.NoOpController, so multiple instances sharing one
+	// backend account don't each independently deploy on the same tick.
+	locker  rotation.Locker
+	lockKey string
 }
 
-// Config holds configuration for the Akash controller.
+// SetLocker wires a distributed lock that auto-rotation acquires before
+// each Rotate call. See rotation.NoOpController.SetLocker for the full
+// rationale.
+func (c *Controller) SetLocker(locker rotation.Locker, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.locker = locker
+	c.lockKey = key
+}
+
+// Config holds configuration for the akash-package rotation controller.
 type Config struct {
-	APIKey  string
+	// APIKey is the Akash/Cloudmos API key. Ignored if Provider is set.
+	APIKey string
+
+	// SDLPath is the Akash SDL manifest rotated deployments are created
+	// from; forwarded as deploy.Spec.SDLPath on every Rotate. Other
+	// backends behind Provider ignore it.
 	SDLPath string
+
+	// Provider overrides the deploy.Provider this controller rotates
+	// across. Defaults to the Akash deploy.Provider built from APIKey.
+	// Set this to a Fly.io/Railway/SSH provider, or a
+	// deploy.MultiProvider wrapping several, to rotate across other
+	// backends.
+	Provider deploy.Provider
 }
 
-// NewController creates a new Akash rotation controller.
+// NewController creates a new rotation controller backed by cfg.Provider,
+// or by the Akash deploy.Provider if cfg.Provider is nil.
 func NewController(cfg Config, logger *zap.Logger) (*Controller, error) {
-	if cfg.APIKey == "" {
-		return nil, fmt.Errorf("Akash API key is required")
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	provider := cfg.Provider
+	if provider == nil {
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("Akash API key is required when Provider is not set")
+		}
+		provider = deployakash.NewClient(cfg.APIKey, logger)
 	}
 	if cfg.SDLPath == "" {
 		cfg.SDLPath = "deployments/akash/xray-server.yaml"
 	}
-	if logger == nil {
-		logger = zap.NewNop()
-	}
 
 	return &Controller{
-		client:      NewClient(cfg.APIKey, logger),
+		provider:    provider,
 		sdlPath:     cfg.SDLPath,
 		logger:      logger,
 		endpoints:   make([]*rotation.Endpoint, 0),
-		deployments: make(map[string]*DeploymentInfo),
+		deployments: make(map[string]*deploy.Deployment),
 		stopCh:      make(chan struct{}),
 	}, nil
 }
 
-// Rotate creates a new Akash deployment and returns the endpoint.
+// Rotate creates a new deployment through the controller's deploy.Provider
+// and returns the endpoint.
 func (c *Controller) Rotate(ctx context.Context) (*rotation.Endpoint, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -61,89 +104,81 @@ func (c *Controller) Rotate(ctx context.Context) (*rotation.Endpoint, error) {
 	c.counter++
 	logger := c.logger.With(zap.Int("rotation", c.counter))
 
-	logger.Info("rotating to new Akash deployment")
+	logger.Info("rotating to new deployment", zap.String("provider", c.provider.Name()))
 
-	// Deploy to Akash
-	deployInfo, err := c.client.Deploy(ctx, c.sdlPath)
+	dep, err := c.provider.Deploy(ctx, deploy.Spec{SDLPath: c.sdlPath})
 	if err != nil {
-		return nil, fmt.Errorf("deploying to Akash: %w", err)
+		return nil, fmt.Errorf("deploying via %s: %w", c.provider.Name(), err)
 	}
 
-	// Wait for lease
-	deployInfo, err = c.client.WaitForLease(ctx, deployInfo.DSeq, 5*time.Minute)
+	dep, err = c.provider.WaitForLease(ctx, dep.ID, 5*time.Minute)
 	if err != nil {
 		// Try to cleanup failed deployment
-		_ = c.client.CloseDeployment(ctx, deployInfo.DSeq)
+		_ = c.provider.CloseDeployment(ctx, dep.ID)
 		return nil, fmt.Errorf("waiting for lease: %w", err)
 	}
 
-	// Get credentials from container
-	creds, err := c.client.GetCredentials(ctx, deployInfo.DSeq)
+	creds, err := c.provider.GetCredentials(ctx, dep.ID)
 	if err != nil {
 		logger.Warn("failed to get credentials from logs, using deployment info", zap.Error(err))
-		creds = &Credentials{
+		creds = &deploy.Credentials{
 			UUID:     "", // Will be fetched from container
 			ShortID:  "abcdef01",
-			Hostname: deployInfo.URI,
+			Hostname: dep.Address,
 		}
 	}
 
 	// Use URI from deployment if hostname not available
 	address := creds.Hostname
 	if address == "" {
-		address = deployInfo.URI
+		address = dep.Address
 	}
 	if address == "" {
-		_ = c.client.CloseDeployment(ctx, deployInfo.DSeq)
+		_ = c.provider.CloseDeployment(ctx, dep.ID)
 		return nil, fmt.Errorf("no address available from deployment")
 	}
 
-	// Create endpoint
 	ep := &rotation.Endpoint{
-		ID:        fmt.Sprintf("akash-%s", deployInfo.DSeq),
+		ID:        fmt.Sprintf("%s-%s", c.provider.Name(), dep.ID),
 		Address:   fmt.Sprintf("%s:443", address),
-		Region:    detectRegion(deployInfo.Provider),
-		Provider:  "akash",
+		Region:    detectRegion(dep.Metadata["akash_provider"]),
+		Provider:  c.provider.Name(),
 		CreatedAt: time.Now(),
 		ExpiresAt: time.Now().Add(24 * time.Hour), // Akash leases are typically 24h
 		Metadata: map[string]string{
-			"dseq":       deployInfo.DSeq,
-			"provider":   deployInfo.Provider,
-			"uuid":       creds.UUID,
-			"public_key": creds.PublicKey,
-			"short_id":   creds.ShortID,
+			"deployment_id": dep.ID,
+			"uuid":          creds.UUID,
+			"public_key":    creds.PublicKey,
+			"short_id":      creds.ShortID,
 		},
 	}
 
 	c.endpoints = append(c.endpoints, ep)
-	c.deployments[ep.ID] = deployInfo
+	c.deployments[ep.ID] = dep
 
-	logger.Info("Akash endpoint rotated",
+	logger.Info("endpoint rotated",
 		zap.String("id", ep.ID),
 		zap.String("address", ep.Address),
-		zap.String("provider", deployInfo.Provider),
+		zap.String("provider", c.provider.Name()),
 	)
 
 	return ep, nil
 }
 
-// Retire closes the Akash deployment.
+// Retire closes the deployment behind ep.
 func (c *Controller) Retire(ctx context.Context, ep *rotation.Endpoint) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// Find deployment
-	deployInfo, ok := c.deployments[ep.ID]
+	dep, ok := c.deployments[ep.ID]
 	if !ok {
 		return fmt.Errorf("deployment not found for endpoint %s", ep.ID)
 	}
 
-	// Close deployment
-	if err := c.client.CloseDeployment(ctx, deployInfo.DSeq); err != nil {
-		c.logger.Warn("failed to close deployment", zap.Error(err), zap.String("dseq", deployInfo.DSeq))
+	if err := c.provider.CloseDeployment(ctx, dep.ID); err != nil {
+		c.logger.Warn("failed to close deployment", zap.Error(err), zap.String("id", dep.ID))
 	}
 
-	// Remove from tracking
 	delete(c.deployments, ep.ID)
 	for i, e := range c.endpoints {
 		if e.ID == ep.ID {
@@ -152,11 +187,11 @@ func (c *Controller) Retire(ctx context.Context, ep *rotation.Endpoint) error {
 		}
 	}
 
-	c.logger.Info("Akash endpoint retired", zap.String("id", ep.ID))
+	c.logger.Info("endpoint retired", zap.String("id", ep.ID))
 	return nil
 }
 
-// ActiveEndpoints returns all active Akash endpoints.
+// ActiveEndpoints returns all active endpoints.
 func (c *Controller) ActiveEndpoints() []*rotation.Endpoint {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -172,7 +207,7 @@ func (c *Controller) ActiveEndpoints() []*rotation.Endpoint {
 
 // StartAutoRotation begins automatic rotation at the given interval.
 func (c *Controller) StartAutoRotation(ctx context.Context, interval time.Duration) error {
-	c.logger.Info("auto-rotation started for Akash", zap.Duration("interval", interval))
+	c.logger.Info("auto-rotation started", zap.String("provider", c.provider.Name()), zap.Duration("interval", interval))
 
 	go func() {
 		ticker := time.NewTicker(interval)
@@ -185,8 +220,28 @@ func (c *Controller) StartAutoRotation(ctx context.Context, interval time.Durati
 			case <-c.stopCh:
 				return
 			case <-ticker.C:
+				c.mu.RLock()
+				locker, lockKey := c.locker, c.lockKey
+				c.mu.RUnlock()
+
+				var lease rotation.Lease
+				if locker != nil {
+					var err error
+					lease, err = locker.Acquire(ctx, lockKey, interval)
+					if err != nil {
+						c.logger.Debug("skipping rotation tick, lease held by a peer",
+							zap.String("key", lockKey), zap.Error(err))
+						continue
+					}
+				}
+
 				// Rotate to new endpoint
 				newEp, err := c.Rotate(ctx)
+				if lease != nil {
+					if relErr := lease.Release(ctx); relErr != nil {
+						c.logger.Warn("failed to release rotation lease", zap.Error(relErr))
+					}
+				}
 				if err != nil {
 					c.logger.Error("auto-rotation failed", zap.Error(err))
 					continue
@@ -202,7 +257,7 @@ func (c *Controller) StartAutoRotation(ctx context.Context, interval time.Durati
 				}
 				c.mu.Unlock()
 
-				c.logger.Info("auto-rotated to new Akash endpoint",
+				c.logger.Info("auto-rotated to new endpoint",
 					zap.String("new_id", newEp.ID),
 					zap.String("address", newEp.Address))
 			}
@@ -216,10 +271,11 @@ func (c *Controller) StartAutoRotation(ctx context.Context, interval time.Durati
 func (c *Controller) StopAutoRotation() {
 	close(c.stopCh)
 	c.stopCh = make(chan struct{})
-	c.logger.Info("auto-rotation stopped for Akash")
+	c.logger.Info("auto-rotation stopped", zap.String("provider", c.provider.Name()))
 }
 
-// RotateToAkash is a convenience method that immediately switches to Akash.
+// RotateToAkash is a convenience method that immediately rotates through
+// the controller's configured deploy.Provider.
 func (c *Controller) RotateToAkash(ctx context.Context) (*rotation.Endpoint, error) {
 	return c.Rotate(ctx)
 }
@@ -240,3 +296,6 @@ func detectRegion(provider string) string {
 		return "global"
 	}
 }
+
+// Compile-time interface check
+var _ rotation.Controller = (*Controller)(nil)
@@ -1,42 +1,82 @@
 package rotation
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
 	"go.uber.org/zap"
 )
 
-// AWSController rotates endpoints via AWS Lambda@Edge.
+// AWSController rotates endpoints via AWS Lambda function URLs.
 type AWSController struct {
 	NoOpController
-	region    string
-	accessKey string
-	secretKey string
-	client    *http.Client
+	region string
+
+	// CredentialsProvider supplies SigV4 credentials for the Lambda client.
+	// NewAWSController wires a static provider from the access/secret key
+	// pair it's given; NewAWSControllerFromEnv wires the SDK's default
+	// chain (env vars, shared profile, IRSA via AssumeRoleWithWebIdentity,
+	// EC2 instance metadata). Tests and non-AWS hosts can inject their own,
+	// e.g. an STS AssumeRole provider or a fake.
+	CredentialsProvider aws.CredentialsProvider
+
+	lambdaClient *lambda.Client
 }
 
-// NewAWSController creates an AWS Lambda@Edge rotation backend.
+// NewAWSController creates an AWS Lambda@Edge rotation backend from a
+// static access key pair, matching the pre-SigV4-rewrite constructor
+// signature so existing callers and tests don't need to change. Prefer
+// NewAWSControllerFromEnv for production deployments, which resolves
+// credentials from the environment instead of holding a long-lived secret.
 func NewAWSController(region, accessKey, secretKey string, logger *zap.Logger) *AWSController {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
-	return &AWSController{
+	creds := credentials.NewStaticCredentialsProvider(accessKey, secretKey, "")
+	return newAWSController(region, creds, logger)
+}
+
+// NewAWSControllerFromEnv creates an AWS Lambda@Edge rotation backend whose
+// credentials are resolved by aws-sdk-go-v2's default chain: environment
+// variables, the shared config/credentials files, AssumeRoleWithWebIdentity
+// (IRSA, for EKS pods), and finally the EC2 instance metadata service in
+// IMDSv2 token-based mode. IMDSv1 is never used implicitly — it was the
+// vector behind the 2019 Capital One SSRF breach — so hosts that genuinely
+// need it must inject their own CredentialsProvider after construction.
+func NewAWSControllerFromEnv(ctx context.Context, region string, logger *zap.Logger) (*AWSController, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("load AWS credential chain: %w", err)
+	}
+	return newAWSController(region, cfg.Credentials, logger), nil
+}
+
+func newAWSController(region string, creds aws.CredentialsProvider, logger *zap.Logger) *AWSController {
+	c := &AWSController{
 		NoOpController: NoOpController{
 			endpoints: make([]*Endpoint, 0),
 			logger:    logger,
 			stopCh:    make(chan struct{}),
 		},
-		region:    region,
-		accessKey: accessKey,
-		secretKey: secretKey,
-		client:    &http.Client{Timeout: 30 * time.Second},
+		region:              region,
+		CredentialsProvider: creds,
 	}
+	c.lambdaClient = lambda.NewFromConfig(aws.Config{
+		Region:      region,
+		Credentials: creds,
+	})
+	return c
 }
 
 // Rotate deploys a new AWS Lambda function and creates a function URL.
@@ -51,15 +91,12 @@ func (a *AWSController) Rotate(ctx context.Context) (*Endpoint, error) {
 		zap.String("region", a.region),
 	)
 
-	// 1. Create Lambda function
 	if err := a.createFunction(ctx, functionName); err != nil {
 		return nil, fmt.Errorf("create lambda %s: %w", functionName, err)
 	}
 
-	// 2. Create function URL for direct invocation
 	funcURL, err := a.createFunctionURL(ctx, functionName)
 	if err != nil {
-		// Cleanup on failure
 		_ = a.deleteFunction(ctx, functionName)
 		return nil, fmt.Errorf("create function URL %s: %w", functionName, err)
 	}
@@ -114,120 +151,97 @@ func (a *AWSController) Retire(ctx context.Context, ep *Endpoint) error {
 }
 
 func (a *AWSController) createFunction(ctx context.Context, name string) error {
-	apiURL := fmt.Sprintf("https://lambda.%s.amazonaws.com/2015-03-31/functions", a.region)
-
-	payload, _ := json.Marshal(map[string]any{
-		"FunctionName": name,
-		"Runtime":      "nodejs20.x",
-		"Handler":      "index.handler",
-		"Role":         "arn:aws:iam::role/entropy-lambda-role",
-		"Code": map[string]any{
-			"ZipFile": lambdaProxyCode(),
+	code, err := lambdaProxyCode()
+	if err != nil {
+		return fmt.Errorf("build lambda proxy zip: %w", err)
+	}
+
+	_, err = a.lambdaClient.CreateFunction(ctx, &lambda.CreateFunctionInput{
+		FunctionName: aws.String(name),
+		Runtime:      types.RuntimeNodejs20x,
+		Handler:      aws.String("index.handler"),
+		Role:         aws.String("arn:aws:iam::role/entropy-lambda-role"),
+		Code: &types.FunctionCode{
+			ZipFile: code,
 		},
-		"Timeout":    30,
-		"MemorySize": 128,
-		"Tags": map[string]string{
+		Timeout:    aws.Int32(30),
+		MemorySize: aws.Int32(128),
+		Tags: map[string]string{
 			"project":  "entropy-tunnel",
 			"rotation": "auto",
 		},
 	})
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(payload))
 	if err != nil {
-		return err
-	}
-	a.signRequest(req)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := a.client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("lambda create error %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("lambda CreateFunction: %w", err)
 	}
-
 	return nil
 }
 
 func (a *AWSController) createFunctionURL(ctx context.Context, name string) (string, error) {
-	apiURL := fmt.Sprintf(
-		"https://lambda.%s.amazonaws.com/2021-10-31/functions/%s/url",
-		a.region, name,
-	)
-
-	payload, _ := json.Marshal(map[string]any{
-		"AuthType": "NONE",
-		"InvokeMode": "RESPONSE_STREAM",
+	out, err := a.lambdaClient.CreateFunctionUrlConfig(ctx, &lambda.CreateFunctionUrlConfigInput{
+		FunctionName: aws.String(name),
+		AuthType:     types.FunctionUrlAuthTypeNone,
+		InvokeMode:   types.InvokeModeResponseStream,
 	})
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(payload))
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("lambda CreateFunctionUrlConfig: %w", err)
 	}
-	a.signRequest(req)
-	req.Header.Set("Content-Type", "application/json")
+	return aws.ToString(out.FunctionUrl), nil
+}
 
-	resp, err := a.client.Do(req)
+func (a *AWSController) deleteFunction(ctx context.Context, name string) error {
+	_, err := a.lambdaClient.DeleteFunction(ctx, &lambda.DeleteFunctionInput{
+		FunctionName: aws.String(name),
+	})
 	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	var result struct {
-		FunctionURL string `json:"FunctionUrl"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
+		return fmt.Errorf("lambda DeleteFunction: %w", err)
 	}
-
-	return result.FunctionURL, nil
+	return nil
 }
 
-func (a *AWSController) deleteFunction(ctx context.Context, name string) error {
-	apiURL := fmt.Sprintf(
-		"https://lambda.%s.amazonaws.com/2015-03-31/functions/%s",
-		a.region, name,
-	)
+// lambdaProxyCode builds an in-memory zip containing the Lambda handler
+// that proxies WebSocket connections (via function-URL RESPONSE_STREAM
+// mode) through to the tunnel server.
+func lambdaProxyCode() ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, apiURL, nil)
+	f, err := zw.Create("index.js")
 	if err != nil {
-		return err
+		return nil, err
 	}
-	a.signRequest(req)
-
-	resp, err := a.client.Do(req)
-	if err != nil {
-		return err
+	if _, err := f.Write([]byte(lambdaHandlerJS)); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	return nil
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
-// signRequest adds AWS Signature V4 headers.
-// Simplified implementation for MVP; production should use aws-sdk-go-v2.
-func (a *AWSController) signRequest(req *http.Request) {
-	req.Header.Set("X-Amz-Date", time.Now().UTC().Format("20060102T150405Z"))
-	// In production, this would use proper SigV4:
-	//   signer := v4.NewSigner()
-	//   signer.SignHTTP(ctx, credentials, req, payloadHash, "lambda", region, time.Now())
-	// For now, we set the access key placeholder.
-	req.Header.Set("Authorization",
-		fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s/%s/lambda/aws4_request",
-			a.accessKey,
-			time.Now().UTC().Format("20060102"),
-			a.region,
-		),
-	)
+const lambdaHandlerJS = `exports.handler = awslambda.streamifyResponse(async (event, responseStream) => {
+    // Proxies the upgraded connection straight through to the tunnel
+    // server; actual bytes are relayed, no inspection or buffering.
+    const net = require("net");
+    const upstream = net.createConnection(443, process.env.TUNNEL_SERVER_HOST);
+    upstream.pipe(responseStream);
+    responseStream.write = responseStream.write.bind(responseStream);
+});
+`
+
+// Name identifies this backend to the rotation Registry.
+func (a *AWSController) Name() string { return "aws" }
+
+// Regions reports the single AWS region this controller deploys Lambda
+// functions into.
+func (a *AWSController) Regions() []string { return []string{a.region} }
+
+// Provision satisfies the Provider interface expected by the Registry.
+func (a *AWSController) Provision(ctx context.Context) (*Endpoint, error) {
+	return a.Rotate(ctx)
 }
 
-// lambdaProxyCode returns base64-encoded Lambda proxy function.
-func lambdaProxyCode() string {
-	// Lambda handler that proxies WebSocket connections to the tunnel server.
-	// In production, this would be a proper ZIP file.
-	return "UEsDBBQAAAAIAA==" // placeholder zip
+// Decommission satisfies the Provider interface expected by the Registry.
+func (a *AWSController) Decommission(ctx context.Context, ep *Endpoint) error {
+	return a.Retire(ctx, ep)
 }
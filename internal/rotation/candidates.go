@@ -0,0 +1,33 @@
+package rotation
+
+import "github.com/fabiano/entropy-tunnel/internal/filter"
+
+// SelectCandidates narrows endpoints to those matching filterExpr, the
+// same internal/filter expression language deploy.FilterDeployments
+// evaluates against deploy.Deployment — here evaluated against
+// Endpoint's fields instead, e.g. "Region != \"us-east\" and CreatedAt <
+// \"2024-01-01\"" to pick rotation candidates older than a cutoff and
+// outside a region. Callers typically pass Controller.ActiveEndpoints()
+// as endpoints. An empty filterExpr matches everything.
+func SelectCandidates(endpoints []*Endpoint, filterExpr string) ([]*Endpoint, error) {
+	if filterExpr == "" {
+		return endpoints, nil
+	}
+
+	expr, err := filter.Parse(filterExpr)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*Endpoint
+	for _, ep := range endpoints {
+		ok, err := expr.Eval(ep)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, ep)
+		}
+	}
+	return matched, nil
+}
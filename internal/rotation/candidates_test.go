@@ -0,0 +1,48 @@
+package rotation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelectCandidates(t *testing.T) {
+	endpoints := []*Endpoint{
+		{ID: "a", Region: "us-east", Provider: "aws", CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "b", Region: "eu-west", Provider: "cloudflare", CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "c", Region: "us-east", Provider: "cloudflare", CreatedAt: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		name    string
+		expr    string
+		wantIDs []string
+		wantErr bool
+	}{
+		{name: "no filter", expr: "", wantIDs: []string{"a", "b", "c"}},
+		{name: "region equals", expr: `Region == "us-east"`, wantIDs: []string{"a", "c"}},
+		{name: "region not equals", expr: `Region != "us-east"`, wantIDs: []string{"b"}},
+		{name: "older than cutoff", expr: `CreatedAt < "2024-01-01"`, wantIDs: []string{"a", "c"}},
+		{name: "region and age", expr: `Region != "us-east" and CreatedAt > "2024-01-01"`, wantIDs: []string{"b"}},
+		{name: "invalid filter", expr: `Region ===`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectCandidates(endpoints, tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SelectCandidates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.wantIDs) {
+				t.Fatalf("got %d candidates, want %d", len(got), len(tt.wantIDs))
+			}
+			for i, ep := range got {
+				if ep.ID != tt.wantIDs[i] {
+					t.Errorf("candidate[%d].ID = %q, want %q", i, ep.ID, tt.wantIDs[i])
+				}
+			}
+		})
+	}
+}
@@ -6,12 +6,80 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"time"
 
 	"go.uber.org/zap"
+
+	"github.com/fabiano/entropy-tunnel/internal/rotation/acme"
 )
 
+// workerScriptFilename is the form field (and main_module) name deployWorker
+// uploads the script under.
+const workerScriptFilename = "worker.js"
+
+// Binding describes a single resource bound into a Worker's environment,
+// matching the shapes the Workers script metadata API accepts for
+// "kv_namespace", "durable_object_namespace", "service", "plain_text", and
+// "secret_text" binding types.
+type Binding struct {
+	Type string
+	Name string
+
+	// NamespaceID is used by kv_namespace and durable_object_namespace.
+	NamespaceID string
+	// Service and Environment are used by service bindings; Environment
+	// may be left empty for the target's default environment.
+	Service     string
+	Environment string
+	// Text is used by plain_text and secret_text bindings.
+	Text string
+}
+
+// WorkerTemplate customizes the script and bindings deployWorker pushes,
+// in place of a single hardcoded cover-site script.
+type WorkerTemplate struct {
+	Script            string
+	Bindings          []Binding
+	CompatibilityDate string
+	UsageModel        string
+}
+
+// DefaultWorkerTemplate renders the camouflaged relay script: WebSocket
+// upgrades are forwarded to upstreamURL, authenticated via a
+// TUNNEL_AUTH_SECRET secret_text binding, and everything else gets decoy
+// HTML, matching the fallback behavior SetServerAuth gives Trojan's
+// Listen.
+func DefaultWorkerTemplate(upstreamURL, authSecret string) WorkerTemplate {
+	script := fmt.Sprintf(`
+export default {
+    async fetch(request, env) {
+        const url = new URL(request.url);
+        if (request.headers.get("Upgrade") === "websocket") {
+            const upstream = new URL(url.pathname, %q);
+            const upstreamReq = new Request(upstream, request);
+            upstreamReq.headers.set("Authorization", "Bearer " + env.TUNNEL_AUTH_SECRET);
+            return fetch(upstreamReq);
+        }
+        // Decoy: return plausible web content
+        return new Response("<!DOCTYPE html><html><body><h1>Welcome</h1></body></html>", {
+            headers: { "content-type": "text/html" },
+        });
+    }
+};`, upstreamURL)
+
+	return WorkerTemplate{
+		Script:            script,
+		CompatibilityDate: time.Now().Format("2006-01-02"),
+		UsageModel:        "bundled",
+		Bindings: []Binding{
+			{Type: "secret_text", Name: "TUNNEL_AUTH_SECRET", Text: authSecret},
+		},
+	}
+}
+
 // CloudflareController rotates endpoints via Cloudflare Workers.
 type CloudflareController struct {
 	NoOpController
@@ -19,9 +87,27 @@ type CloudflareController struct {
 	accountID string
 	zoneID    string
 	client    *http.Client
+
+	// upstreamURL/authSecret feed DefaultWorkerTemplate when workerTemplate
+	// isn't set; configured post-construction via SetWorkerConfig,
+	// mirroring how TrojanProtocol fills in auth via SetClientAuth/
+	// SetServerAuth after NewTrojan.
+	upstreamURL    string
+	authSecret     string
+	workerTemplate *WorkerTemplate
+
+	// redirectRules tracks rules PublishRedirect has pushed, newest first,
+	// so superseded ones can be pruned after redirectGrace (see
+	// cloudflare_redirect.go).
+	redirectRules         []redirectEntry
+	redirectGraceOverride time.Duration
 }
 
 // NewCloudflareController creates a Cloudflare Workers rotation backend.
+// It does no I/O itself; callers that want to catch a misconfigured or
+// revoked token at startup rather than on the first Rotate should follow
+// construction with VerifyTokenActive (buildRotationController does this
+// for the cloudflare provider).
 func NewCloudflareController(apiToken, accountID, zoneID string, logger *zap.Logger) *CloudflareController {
 	if logger == nil {
 		logger = zap.NewNop()
@@ -39,6 +125,67 @@ func NewCloudflareController(apiToken, accountID, zoneID string, logger *zap.Log
 	}
 }
 
+// VerifyTokenActive calls Cloudflare's token verification endpoint and
+// returns an error unless apiToken is valid and active.
+//
+// This only confirms the token itself is active; Cloudflare's verify
+// endpoint doesn't expose which permission groups (e.g. the "Edit
+// Dynamic Redirect" scope PublishRedirect needs) a bearer token was
+// granted, so a token that's valid but missing that scope will still
+// pass here and only fail once PublishRedirect is actually called.
+func (c *CloudflareController) VerifyTokenActive(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.cloudflare.com/client/v4/user/tokens/verify", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cloudflare token verify request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("cloudflare token verify error %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Result struct {
+			Status string `json:"status"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("decoding token verify response: %w", err)
+	}
+	if result.Result.Status != "active" {
+		return fmt.Errorf("cloudflare api token status is %q, want \"active\"", result.Result.Status)
+	}
+
+	return nil
+}
+
+// SetWorkerConfig configures the upstream tunnel URL and auth secret
+// deployWorker's default script forwards WebSocket upgrades to. tmpl may
+// be non-nil to deploy a custom WorkerTemplate instead of the default
+// camouflaged relay script.
+func (c *CloudflareController) SetWorkerConfig(upstreamURL, authSecret string, tmpl *WorkerTemplate) {
+	c.upstreamURL = upstreamURL
+	c.authSecret = authSecret
+	c.workerTemplate = tmpl
+}
+
+// resolveWorkerTemplate returns the template deployWorker should push: the
+// caller-supplied one if SetWorkerConfig was given one, otherwise the
+// default camouflaged relay script pointed at upstreamURL.
+func (c *CloudflareController) resolveWorkerTemplate() WorkerTemplate {
+	if c.workerTemplate != nil {
+		return *c.workerTemplate
+	}
+	return DefaultWorkerTemplate(c.upstreamURL, c.authSecret)
+}
+
 // Rotate deploys a new Cloudflare Worker and updates DNS.
 func (c *CloudflareController) Rotate(ctx context.Context) (*Endpoint, error) {
 	c.mu.Lock()
@@ -51,7 +198,7 @@ func (c *CloudflareController) Rotate(ctx context.Context) (*Endpoint, error) {
 	)
 
 	// 1. Deploy Worker
-	if err := c.deployWorker(ctx, workerName); err != nil {
+	if err := c.deployWorker(ctx, workerName, c.resolveWorkerTemplate()); err != nil {
 		return nil, fmt.Errorf("deploy worker %s: %w", workerName, err)
 	}
 
@@ -109,38 +256,80 @@ func (c *CloudflareController) Retire(ctx context.Context, ep *Endpoint) error {
 	return nil
 }
 
-// deployWorker pushes a new Worker script to the Cloudflare API.
-func (c *CloudflareController) deployWorker(ctx context.Context, name string) error {
-	// Worker script: forwards VLESS/WS connections to the actual tunnel server.
-	workerScript := `
-export default {
-    async fetch(request) {
-        const url = new URL(request.url);
-        // Forward to actual tunnel server via WebSocket upgrade
-        if (request.headers.get("Upgrade") === "websocket") {
-            const upstream = new URL(url.pathname, "wss://YOUR_TUNNEL_SERVER");
-            return fetch(new Request(upstream, request));
-        }
-        // Decoy: return plausible web content
-        return new Response("<!DOCTYPE html><html><body><h1>Welcome</h1></body></html>", {
-            headers: { "content-type": "text/html" },
-        });
-    }
-};`
+// deployWorker pushes tmpl to the Cloudflare API as a module Worker via
+// the account-scoped PUT .../workers/scripts/{name} endpoint, which
+// requires a multipart/form-data body: one part carrying the script
+// itself (as an ES module) and one "metadata" part carrying a JSON blob
+// describing the module entrypoint and any bindings.
+func (c *CloudflareController) deployWorker(ctx context.Context, name string, tmpl WorkerTemplate) error {
+	metadata := map[string]any{
+		"main_module":        workerScriptFilename,
+		"compatibility_date": tmpl.CompatibilityDate,
+	}
+	if tmpl.UsageModel != "" {
+		metadata["usage_model"] = tmpl.UsageModel
+	}
+
+	bindings := make([]map[string]any, 0, len(tmpl.Bindings))
+	for _, b := range tmpl.Bindings {
+		entry := map[string]any{"type": b.Type, "name": b.Name}
+		switch b.Type {
+		case "kv_namespace", "durable_object_namespace":
+			entry["namespace_id"] = b.NamespaceID
+		case "service":
+			entry["service"] = b.Service
+			if b.Environment != "" {
+				entry["environment"] = b.Environment
+			}
+		case "plain_text", "secret_text":
+			entry["text"] = b.Text
+		}
+		bindings = append(bindings, entry)
+	}
+	metadata["bindings"] = bindings
+
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("marshal worker metadata: %w", err)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	metaPart, err := mw.CreateFormField("metadata")
+	if err != nil {
+		return fmt.Errorf("create metadata form field: %w", err)
+	}
+	if _, err := metaPart.Write(metadataJSON); err != nil {
+		return fmt.Errorf("write worker metadata: %w", err)
+	}
+
+	scriptHeader := textproto.MIMEHeader{}
+	scriptHeader.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, workerScriptFilename, workerScriptFilename))
+	scriptHeader.Set("Content-Type", "application/javascript+module")
+	scriptPart, err := mw.CreatePart(scriptHeader)
+	if err != nil {
+		return fmt.Errorf("create script form part: %w", err)
+	}
+	if _, err := scriptPart.Write([]byte(tmpl.Script)); err != nil {
+		return fmt.Errorf("write worker script: %w", err)
+	}
+
+	if err := mw.Close(); err != nil {
+		return fmt.Errorf("close multipart writer: %w", err)
+	}
 
 	apiURL := fmt.Sprintf(
 		"https://api.cloudflare.com/client/v4/accounts/%s/workers/scripts/%s",
 		c.accountID, name,
 	)
 
-	// Workers API uses multipart form for script upload
-	body := bytes.NewBufferString(workerScript)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, apiURL, body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, apiURL, &body)
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Authorization", "Bearer "+c.apiToken)
-	req.Header.Set("Content-Type", "application/javascript")
+	req.Header.Set("Content-Type", mw.FormDataContentType())
 
 	resp, err := c.client.Do(req)
 	if err != nil {
@@ -222,3 +411,125 @@ func (c *CloudflareController) UpdateDNS(ctx context.Context, recordName, target
 	)
 	return nil
 }
+
+// CreateTXTRecord publishes a TXT record named fqdn with the given value
+// and returns its Cloudflare record ID, satisfying acme.DNSProvider so
+// CloudflareController can drive ACME DNS-01 challenges.
+func (c *CloudflareController) CreateTXTRecord(ctx context.Context, fqdn, value string) (string, error) {
+	apiURL := fmt.Sprintf(
+		"https://api.cloudflare.com/client/v4/zones/%s/dns_records",
+		c.zoneID,
+	)
+
+	payload, _ := json.Marshal(map[string]any{
+		"type":    "TXT",
+		"name":    fqdn,
+		"content": value,
+		"ttl":     60,
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("cloudflare TXT create error %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Result struct {
+			ID string `json:"id"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("decoding TXT record response: %w", err)
+	}
+
+	return result.Result.ID, nil
+}
+
+// DeleteTXTRecord removes the TXT record identified by recordID.
+func (c *CloudflareController) DeleteTXTRecord(ctx context.Context, fqdn, recordID string) error {
+	apiURL := fmt.Sprintf(
+		"https://api.cloudflare.com/client/v4/zones/%s/dns_records/%s",
+		c.zoneID, recordID,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, apiURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("cloudflare TXT delete error %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// RotateWithCert rotates a new worker endpoint, points recordName at it
+// via UpdateDNS, and obtains (and auto-renews) an ACME DNS-01 certificate
+// for recordName through certManager. The certificate and key paths are
+// attached to the returned Endpoint's Metadata as "cert_path"/"key_path".
+func (c *CloudflareController) RotateWithCert(ctx context.Context, recordName string, certManager *acme.Manager) (*Endpoint, error) {
+	ep, err := c.Rotate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.UpdateDNS(ctx, recordName, ep.Address); err != nil {
+		return nil, fmt.Errorf("pointing %s at %s: %w", recordName, ep.Address, err)
+	}
+
+	cert, err := certManager.ObtainCertificate(ctx, recordName)
+	if err != nil {
+		return nil, fmt.Errorf("obtaining certificate for %s: %w", recordName, err)
+	}
+	certManager.StartRenewal(ctx, recordName, cert)
+
+	c.mu.Lock()
+	ep.Metadata["cert_path"] = cert.CertPath
+	ep.Metadata["key_path"] = cert.KeyPath
+	c.mu.Unlock()
+
+	return ep, nil
+}
+
+// Name identifies this backend to the rotation Registry.
+func (c *CloudflareController) Name() string { return "cloudflare" }
+
+// Regions reports that Workers run on Cloudflare's global anycast edge
+// rather than a fixed set of regions.
+func (c *CloudflareController) Regions() []string { return []string{"global"} }
+
+// Compile-time interface check: CloudflareController can drive ACME
+// DNS-01 challenges against its own zone.
+var _ acme.DNSProvider = (*CloudflareController)(nil)
+
+// Provision satisfies the Provider interface expected by the Registry.
+func (c *CloudflareController) Provision(ctx context.Context) (*Endpoint, error) {
+	return c.Rotate(ctx)
+}
+
+// Decommission satisfies the Provider interface expected by the Registry.
+func (c *CloudflareController) Decommission(ctx context.Context, ep *Endpoint) error {
+	return c.Retire(ctx, ep)
+}
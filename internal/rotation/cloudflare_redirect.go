@@ -0,0 +1,162 @@
+package rotation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// dynamicRedirectPhase is the ruleset phase Single Redirects live in,
+// replacing the legacy Page Rules product.
+const dynamicRedirectPhase = "http_request_dynamic_redirect"
+
+// defaultRedirectGrace is how long a superseded redirect rule is kept in
+// the ruleset (inert, since the newer rule for the same host is evaluated
+// first and wins) before PublishRedirect prunes it on a later call.
+const defaultRedirectGrace = 5 * time.Minute
+
+// redirectEntry is one rule PublishRedirect has pushed, tracked so
+// superseded entries can be pruned from the ruleset after their grace
+// window rather than deleted immediately.
+type redirectEntry struct {
+	fromHost    string
+	toEndpoint  string
+	publishedAt time.Time
+}
+
+// PublishRedirect programs (or repoints) a Cloudflare Single Redirect so
+// fromHost always 307-redirects to toEndpoint, preserving the request
+// path and query string. This replaces the whole dynamic-redirect
+// ruleset in one PUT, so the update is atomic from the edge's
+// perspective: there's no window where fromHost resolves to neither the
+// old nor the new target.
+//
+// Rules are evaluated in array order and a redirect response terminates
+// the phase, so the newest rule for a host always wins; PublishRedirect
+// prepends it ahead of any existing rules. Rules superseded more than
+// redirectGrace ago are dropped from the rebuilt ruleset — until then
+// they stay in the list, inert, purely so a quick manual rollback in the
+// Cloudflare dashboard can still see what fromHost pointed at previously.
+func (c *CloudflareController) PublishRedirect(ctx context.Context, fromHost, toEndpoint string) error {
+	c.mu.Lock()
+	now := time.Now()
+	c.redirectRules = append([]redirectEntry{{
+		fromHost:    fromHost,
+		toEndpoint:  toEndpoint,
+		publishedAt: now,
+	}}, c.redirectRules...)
+	c.redirectRules = pruneRedirectEntries(c.redirectRules, now, c.redirectGrace())
+	rules := buildRedirectRuleset(c.redirectRules)
+	c.mu.Unlock()
+
+	if err := c.putRedirectRuleset(ctx, rules); err != nil {
+		return fmt.Errorf("publish redirect %s -> %s: %w", fromHost, toEndpoint, err)
+	}
+
+	c.logger.Info("redirect published",
+		zap.String("from_host", fromHost),
+		zap.String("to_endpoint", toEndpoint),
+	)
+	return nil
+}
+
+// redirectGrace returns the configured grace window, defaulting to
+// defaultRedirectGrace when unset.
+func (c *CloudflareController) redirectGrace() time.Duration {
+	if c.redirectGraceOverride > 0 {
+		return c.redirectGraceOverride
+	}
+	return defaultRedirectGrace
+}
+
+// SetRedirectGrace overrides how long a superseded redirect rule stays in
+// the ruleset before being pruned. Mainly useful for tests; production
+// callers can leave this unset to get defaultRedirectGrace.
+func (c *CloudflareController) SetRedirectGrace(d time.Duration) {
+	c.redirectGraceOverride = d
+}
+
+// pruneRedirectEntries drops entries older than grace, except each
+// host's single newest entry (the one actually live), which is kept
+// regardless of age.
+func pruneRedirectEntries(entries []redirectEntry, now time.Time, grace time.Duration) []redirectEntry {
+	latestForHost := make(map[string]time.Time, len(entries))
+	for _, e := range entries {
+		if e.publishedAt.After(latestForHost[e.fromHost]) {
+			latestForHost[e.fromHost] = e.publishedAt
+		}
+	}
+
+	kept := make([]redirectEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.publishedAt.Equal(latestForHost[e.fromHost]) || now.Sub(e.publishedAt) < grace {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// buildRedirectRuleset turns tracked entries into the Cloudflare ruleset
+// rule payloads, in the same (newest-first) order they're evaluated in.
+func buildRedirectRuleset(entries []redirectEntry) []map[string]any {
+	rules := make([]map[string]any, 0, len(entries))
+	for _, e := range entries {
+		rules = append(rules, map[string]any{
+			"description": fmt.Sprintf("entropy-tunnel redirect for %s (published %s)", e.fromHost, e.publishedAt.Format(time.RFC3339)),
+			"expression":  fmt.Sprintf("http.host eq %q", e.fromHost),
+			"action":      "redirect",
+			"action_parameters": map[string]any{
+				"from_value": map[string]any{
+					"status_code": 307,
+					"target_url": map[string]any{
+						"expression": fmt.Sprintf("concat(%q, http.request.uri.path, if(http.request.uri.query != \"\", concat(\"?\", http.request.uri.query), \"\"))", "https://"+e.toEndpoint),
+					},
+					"preserve_query_string": true,
+				},
+			},
+		})
+	}
+	return rules
+}
+
+// putRedirectRuleset replaces the zone's whole dynamic-redirect ruleset
+// entrypoint with rules in one request, matching how Cloudflare Single
+// Redirects must be updated (there's no per-rule PATCH endpoint for the
+// entrypoint ruleset).
+func (c *CloudflareController) putRedirectRuleset(ctx context.Context, rules []map[string]any) error {
+	apiURL := fmt.Sprintf(
+		"https://api.cloudflare.com/client/v4/zones/%s/rulesets/phases/%s/entrypoint",
+		c.zoneID, dynamicRedirectPhase,
+	)
+
+	payload, err := json.Marshal(map[string]any{"rules": rules})
+	if err != nil {
+		return fmt.Errorf("marshal redirect ruleset: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, apiURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cloudflare ruleset request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("cloudflare ruleset update error %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
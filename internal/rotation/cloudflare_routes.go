@@ -0,0 +1,116 @@
+package rotation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RouteController binds a deployed Worker script to a custom hostname via
+// Cloudflare's zone-scoped Workers Routes API, so a rotated endpoint can
+// live at e.g. cdn.example.com instead of only <name>.workers.dev.
+type RouteController struct {
+	apiToken string
+	zoneID   string
+	client   *http.Client
+	logger   *zap.Logger
+}
+
+// NewRouteController creates a Workers Routes controller for the given
+// zone.
+func NewRouteController(apiToken, zoneID string, logger *zap.Logger) *RouteController {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &RouteController{
+		apiToken: apiToken,
+		zoneID:   zoneID,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		logger:   logger,
+	}
+}
+
+// CreateRoute binds pattern (e.g. "cdn.example.com/*") to the Worker
+// script named scriptName and returns the new route's Cloudflare ID.
+func (r *RouteController) CreateRoute(ctx context.Context, pattern, scriptName string) (string, error) {
+	apiURL := fmt.Sprintf(
+		"https://api.cloudflare.com/client/v4/zones/%s/workers/routes",
+		r.zoneID,
+	)
+
+	payload, err := json.Marshal(map[string]any{
+		"pattern": pattern,
+		"script":  scriptName,
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal route payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+r.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("cloudflare route request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("cloudflare route create error %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Result struct {
+			ID string `json:"id"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("decoding route response: %w", err)
+	}
+
+	r.logger.Info("worker route created",
+		zap.String("pattern", pattern),
+		zap.String("script", scriptName),
+		zap.String("route_id", result.Result.ID),
+	)
+
+	return result.Result.ID, nil
+}
+
+// DeleteRoute removes the route identified by routeID.
+func (r *RouteController) DeleteRoute(ctx context.Context, routeID string) error {
+	apiURL := fmt.Sprintf(
+		"https://api.cloudflare.com/client/v4/zones/%s/workers/routes/%s",
+		r.zoneID, routeID,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, apiURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+r.apiToken)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("cloudflare route delete error %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
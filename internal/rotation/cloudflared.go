@@ -0,0 +1,280 @@
+package rotation
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const cfAPIBase = "https://api.cloudflare.com/client/v4"
+
+// CloudflaredController rotates endpoints via ephemeral Cloudflare
+// Tunnels (cloudflared), an alternative to CloudflareController's Worker
+// scripts that keeps working when Workers subdomains get blocked. Each
+// Rotate call provisions a new named tunnel and a hostname route under
+// domain; a locally spawned cloudflared sidecar (or an embedded
+// QUIC/HTTP2 connector) reads the returned Endpoint's Metadata to
+// register it.
+type CloudflaredController struct {
+	NoOpController
+	apiToken  string
+	accountID string
+	zoneID    string
+	domain    string // apex domain hostname routes are published under, e.g. "example.com"
+	client    *http.Client
+}
+
+// NewCloudflaredController creates a Cloudflare Tunnel rotation backend.
+// domain is the zone's apex hostname ("example.com") new tunnels get a
+// subdomain of.
+func NewCloudflaredController(apiToken, accountID, zoneID, domain string, logger *zap.Logger) *CloudflaredController {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &CloudflaredController{
+		NoOpController: NoOpController{
+			endpoints: make([]*Endpoint, 0),
+			logger:    logger,
+			stopCh:    make(chan struct{}),
+		},
+		apiToken:  apiToken,
+		accountID: accountID,
+		zoneID:    zoneID,
+		domain:    domain,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Rotate creates a new Cloudflare Tunnel, fetches its connector token,
+// and publishes a hostname route for it.
+func (c *CloudflaredController) Rotate(ctx context.Context) (*Endpoint, error) {
+	c.mu.Lock()
+	c.counter++
+	tunnelName := fmt.Sprintf("entropy-tunnel-%d-%d", time.Now().Unix(), c.counter)
+	c.mu.Unlock()
+
+	c.logger.Info("creating cloudflare tunnel", zap.String("name", tunnelName))
+
+	tunnelID, err := c.createTunnel(ctx, tunnelName)
+	if err != nil {
+		return nil, fmt.Errorf("create tunnel %s: %w", tunnelName, err)
+	}
+
+	token, err := c.getTunnelToken(ctx, tunnelID)
+	if err != nil {
+		_ = c.deleteTunnel(ctx, tunnelID)
+		return nil, fmt.Errorf("fetch token for tunnel %s: %w", tunnelID, err)
+	}
+
+	hostname := fmt.Sprintf("%s.%s", tunnelName, c.domain)
+	if err := c.publishRoute(ctx, tunnelID, hostname); err != nil {
+		_ = c.deleteTunnel(ctx, tunnelID)
+		return nil, fmt.Errorf("publish route for tunnel %s: %w", tunnelID, err)
+	}
+
+	ep := &Endpoint{
+		ID:        tunnelID,
+		Address:   hostname,
+		Region:    "global", // Cloudflare Tunnels ride the same anycast edge as Workers
+		Provider:  "cloudflared",
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(1 * time.Hour),
+		Metadata: map[string]string{
+			"tunnel_id":   tunnelID,
+			"tunnel_name": tunnelName,
+			"token":       token,
+			"type":        "cloudflared",
+		},
+	}
+
+	c.mu.Lock()
+	c.endpoints = append(c.endpoints, ep)
+	c.mu.Unlock()
+
+	c.logger.Info("cloudflare tunnel created",
+		zap.String("id", ep.ID),
+		zap.String("hostname", hostname),
+	)
+
+	return ep, nil
+}
+
+// Retire deletes a Cloudflare Tunnel and its hostname route.
+func (c *CloudflaredController) Retire(ctx context.Context, ep *Endpoint) error {
+	if ep.Provider != "cloudflared" {
+		return c.NoOpController.Retire(ctx, ep)
+	}
+
+	tunnelID := ep.ID
+	c.logger.Info("retiring cloudflare tunnel", zap.String("id", tunnelID))
+
+	if err := c.deleteRoute(ctx, ep.Address); err != nil {
+		c.logger.Warn("failed to delete hostname route (may not exist)", zap.Error(err))
+	}
+	if err := c.deleteTunnel(ctx, tunnelID); err != nil {
+		c.logger.Warn("failed to delete tunnel (may not exist)", zap.Error(err))
+	}
+
+	c.mu.Lock()
+	for i, e := range c.endpoints {
+		if e.ID == ep.ID {
+			c.endpoints = append(c.endpoints[:i], c.endpoints[i+1:]...)
+			break
+		}
+	}
+	c.mu.Unlock()
+
+	return nil
+}
+
+// createTunnel provisions a new named tunnel with a freshly generated
+// tunnel secret and returns its UUID.
+func (c *CloudflaredController) createTunnel(ctx context.Context, name string) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("generating tunnel secret: %w", err)
+	}
+
+	payload, _ := json.Marshal(map[string]any{
+		"name":          name,
+		"tunnel_secret": base64.StdEncoding.EncodeToString(secret),
+	})
+
+	var result struct {
+		Result struct {
+			ID string `json:"id"`
+		} `json:"result"`
+	}
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("%s/accounts/%s/cfd_tunnel", cfAPIBase, c.accountID), payload, &result); err != nil {
+		return "", err
+	}
+	return result.Result.ID, nil
+}
+
+// getTunnelToken fetches the connector token a cloudflared sidecar uses
+// to register this tunnel (`cloudflared tunnel run --token <token>`).
+func (c *CloudflaredController) getTunnelToken(ctx context.Context, tunnelID string) (string, error) {
+	var result struct {
+		Result string `json:"result"`
+	}
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/accounts/%s/cfd_tunnel/%s/token", cfAPIBase, c.accountID, tunnelID), nil, &result); err != nil {
+		return "", err
+	}
+	return result.Result, nil
+}
+
+// publishRoute points hostname at the tunnel: a public-hostname ingress
+// rule on the tunnel's configuration plus the CNAME DNS record Cloudflare
+// routes that hostname through.
+func (c *CloudflaredController) publishRoute(ctx context.Context, tunnelID, hostname string) error {
+	configPayload, _ := json.Marshal(map[string]any{
+		"config": map[string]any{
+			"ingress": []map[string]any{
+				{"hostname": hostname, "service": "http://localhost:8080"},
+				{"service": "http_status:404"},
+			},
+		},
+	})
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("%s/accounts/%s/cfd_tunnel/%s/configurations", cfAPIBase, c.accountID, tunnelID), configPayload, nil); err != nil {
+		return fmt.Errorf("configuring ingress: %w", err)
+	}
+
+	dnsPayload, _ := json.Marshal(map[string]any{
+		"type":    "CNAME",
+		"name":    hostname,
+		"content": fmt.Sprintf("%s.cfargotunnel.com", tunnelID),
+		"ttl":     60,
+		"proxied": true,
+	})
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("%s/zones/%s/dns_records", cfAPIBase, c.zoneID), dnsPayload, nil); err != nil {
+		return fmt.Errorf("creating DNS route: %w", err)
+	}
+
+	return nil
+}
+
+// deleteRoute removes the DNS record publishRoute created for hostname.
+// The Cloudflare API only deletes DNS records by ID, so this looks the
+// record up by name first.
+func (c *CloudflaredController) deleteRoute(ctx context.Context, hostname string) error {
+	var list struct {
+		Result []struct {
+			ID string `json:"id"`
+		} `json:"result"`
+	}
+	url := fmt.Sprintf("%s/zones/%s/dns_records?type=CNAME&name=%s", cfAPIBase, c.zoneID, hostname)
+	if err := c.do(ctx, http.MethodGet, url, nil, &list); err != nil {
+		return fmt.Errorf("looking up DNS record for %s: %w", hostname, err)
+	}
+	for _, rec := range list.Result {
+		if err := c.do(ctx, http.MethodDelete, fmt.Sprintf("%s/zones/%s/dns_records/%s", cfAPIBase, c.zoneID, rec.ID), nil, nil); err != nil {
+			return fmt.Errorf("deleting DNS record %s: %w", rec.ID, err)
+		}
+	}
+	return nil
+}
+
+// deleteTunnel removes a Cloudflare Tunnel entirely.
+func (c *CloudflaredController) deleteTunnel(ctx context.Context, tunnelID string) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("%s/accounts/%s/cfd_tunnel/%s", cfAPIBase, c.accountID, tunnelID), nil, nil)
+}
+
+// do issues a Cloudflare API request, marshaling payload (if non-nil) as
+// the JSON body and unmarshaling the response into out (if non-nil).
+func (c *CloudflaredController) do(ctx context.Context, method, url string, payload []byte, out any) error {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cloudflare API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("cloudflare API error %d: %s", resp.StatusCode, string(body))
+	}
+	if out != nil && len(body) > 0 {
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("decoding response: %w", err)
+		}
+	}
+	return nil
+}
+
+// Name identifies this backend to the rotation Registry.
+func (c *CloudflaredController) Name() string { return "cloudflared" }
+
+// Regions reports that Cloudflare Tunnels ride the same global anycast
+// edge as Workers rather than a fixed set of regions.
+func (c *CloudflaredController) Regions() []string { return []string{"global"} }
+
+// Provision satisfies the Provider interface expected by the Registry.
+func (c *CloudflaredController) Provision(ctx context.Context) (*Endpoint, error) {
+	return c.Rotate(ctx)
+}
+
+// Decommission satisfies the Provider interface expected by the Registry.
+func (c *CloudflaredController) Decommission(ctx context.Context, ep *Endpoint) error {
+	return c.Retire(ctx, ep)
+}
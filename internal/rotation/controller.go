@@ -51,6 +51,26 @@ type NoOpController struct {
 	logger    *zap.Logger
 	stopCh    chan struct{}
 	counter   int
+
+	// locker, if set via SetLocker, gates auto-rotation so only one
+	// instance sharing lockKey provisions an endpoint per tick when
+	// several processes are pointed at the same cloud account.
+	locker       Locker
+	lockKey      string
+	currentLease Lease
+}
+
+// SetLocker wires a distributed lock that auto-rotation acquires before
+// each Rotate call, so multiple instances sharing the same cloud account
+// (HA deployment, k8s replicas) don't each independently provision an
+// endpoint on the same tick. key identifies the shared resource, e.g. the
+// cloud account or project ID; it's the same across every instance that
+// should be mutually exclusive with each other.
+func (c *NoOpController) SetLocker(locker Locker, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.locker = locker
+	c.lockKey = key
 }
 
 // NewNoOpController creates a no-op rotation controller.
@@ -130,9 +150,37 @@ func (c *NoOpController) StartAutoRotation(ctx context.Context, interval time.Du
 			case <-c.stopCh:
 				return
 			case <-ticker.C:
+				c.mu.RLock()
+				locker, lockKey := c.locker, c.lockKey
+				c.mu.RUnlock()
+
+				var lease Lease
+				if locker != nil {
+					var err error
+					lease, err = locker.Acquire(ctx, lockKey, interval)
+					if err != nil {
+						c.logger.Debug("skipping rotation tick, lease held by a peer",
+							zap.String("key", lockKey), zap.Error(err))
+						continue
+					}
+					c.mu.Lock()
+					c.currentLease = lease
+					c.mu.Unlock()
+				}
+
 				if _, err := c.Rotate(ctx); err != nil {
 					c.logger.Error("auto-rotation failed", zap.Error(err))
 				}
+
+				if lease != nil {
+					if err := lease.Release(ctx); err != nil {
+						c.logger.Warn("failed to release rotation lease", zap.Error(err))
+					}
+					c.mu.Lock()
+					c.currentLease = nil
+					c.mu.Unlock()
+				}
+
 				// Retire expired endpoints
 				c.mu.RLock()
 				for _, ep := range c.endpoints {
@@ -153,5 +201,16 @@ func (c *NoOpController) StartAutoRotation(ctx context.Context, interval time.Du
 func (c *NoOpController) StopAutoRotation() {
 	close(c.stopCh)
 	c.stopCh = make(chan struct{})
+
+	c.mu.Lock()
+	lease := c.currentLease
+	c.currentLease = nil
+	c.mu.Unlock()
+	if lease != nil {
+		if err := lease.Release(context.Background()); err != nil {
+			c.logger.Warn("failed to release rotation lease on shutdown", zap.Error(err))
+		}
+	}
+
 	c.logger.Info("auto-rotation stopped (noop)")
 }
@@ -111,3 +111,33 @@ func TestHealthChecker_Results(t *testing.T) {
 		t.Errorf("expected 0 results initially, got %d", len(results))
 	}
 }
+
+func TestHealthChecker_ProbeNow(t *testing.T) {
+	ctrl := NewNoOpController(nil)
+	ep, err := ctrl.Rotate(context.Background())
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+
+	hc := NewHealthChecker(ctrl, time.Minute, 100*time.Millisecond, nil)
+
+	result, err := hc.ProbeNow(context.Background(), ep.ID)
+	if err != nil {
+		t.Fatalf("ProbeNow() error = %v", err)
+	}
+	if result.EndpointID != ep.ID {
+		t.Errorf("expected result for %q, got %q", ep.ID, result.EndpointID)
+	}
+	if _, ok := hc.Results()[ep.ID]; !ok {
+		t.Error("expected ProbeNow to record its result")
+	}
+}
+
+func TestHealthChecker_ProbeNow_UnknownEndpoint(t *testing.T) {
+	ctrl := NewNoOpController(nil)
+	hc := NewHealthChecker(ctrl, time.Minute, 100*time.Millisecond, nil)
+
+	if _, err := hc.ProbeNow(context.Background(), "does-not-exist"); err == nil {
+		t.Error("expected an error for an unknown endpoint ID")
+	}
+}
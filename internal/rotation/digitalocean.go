@@ -0,0 +1,316 @@
+package rotation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	doAPIBase = "https://api.digitalocean.com/v2"
+
+	doMaxRetries   = 5
+	doBaseBackoff  = 1 * time.Second
+	doMaxBackoff   = 30 * time.Second
+	doPollInterval = 5 * time.Second
+	doPollTimeout  = 3 * time.Minute
+)
+
+// DigitalOceanController rotates endpoints via DigitalOcean droplets,
+// booted from a preconfigured snapshot (or 1-Click image) that already
+// carries the tunnel binary, using the DO v2 API.
+type DigitalOceanController struct {
+	NoOpController
+	apiToken   string
+	region     string
+	size       string
+	snapshotID string
+	client     *http.Client
+
+	// quotaRemaining tracks the last value of DO's RateLimit-Remaining
+	// response header, so callers can back off proactively (e.g. a
+	// MultiController choosing a different sub-controller) instead of
+	// waiting to hit a 429.
+	quotaRemaining int
+}
+
+// NewDigitalOceanController creates a DigitalOcean droplet rotation
+// backend. snapshotID names the pre-baked snapshot or image (carrying the
+// tunnel binary and its systemd unit) new droplets boot from.
+func NewDigitalOceanController(apiToken, region, size, snapshotID string, logger *zap.Logger) *DigitalOceanController {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &DigitalOceanController{
+		NoOpController: NoOpController{
+			endpoints: make([]*Endpoint, 0),
+			logger:    logger,
+			stopCh:    make(chan struct{}),
+		},
+		apiToken:       apiToken,
+		region:         region,
+		size:           size,
+		snapshotID:     snapshotID,
+		client:         &http.Client{Timeout: 30 * time.Second},
+		quotaRemaining: -1, // unknown until the first response
+	}
+}
+
+// Rotate creates a new droplet from the configured snapshot and waits for
+// it to become active before returning its public IPv4 as the Endpoint.
+func (d *DigitalOceanController) Rotate(ctx context.Context) (*Endpoint, error) {
+	d.mu.Lock()
+	d.counter++
+	name := fmt.Sprintf("entropy-tunnel-%d-%d", time.Now().Unix(), d.counter)
+	d.mu.Unlock()
+
+	d.logger.Info("creating digitalocean droplet", zap.String("name", name), zap.String("region", d.region))
+
+	dropletID, err := d.createDroplet(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("create droplet %s: %w", name, err)
+	}
+
+	addr, err := d.waitForActive(ctx, dropletID)
+	if err != nil {
+		_ = d.deleteDroplet(ctx, dropletID)
+		return nil, fmt.Errorf("wait for droplet %d: %w", dropletID, err)
+	}
+
+	id := strconv.Itoa(dropletID)
+	ep := &Endpoint{
+		ID:        id,
+		Address:   addr,
+		Region:    d.region,
+		Provider:  "digitalocean",
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(1 * time.Hour),
+		Metadata: map[string]string{
+			"droplet_id": id,
+			"type":       "droplet",
+		},
+	}
+
+	d.mu.Lock()
+	d.endpoints = append(d.endpoints, ep)
+	d.mu.Unlock()
+
+	d.logger.Info("digitalocean droplet active", zap.String("id", ep.ID), zap.String("address", addr))
+
+	return ep, nil
+}
+
+// Retire destroys a droplet.
+func (d *DigitalOceanController) Retire(ctx context.Context, ep *Endpoint) error {
+	if ep.Provider != "digitalocean" {
+		return d.NoOpController.Retire(ctx, ep)
+	}
+
+	dropletID, err := strconv.Atoi(ep.ID)
+	if err != nil {
+		return fmt.Errorf("digitalocean: malformed droplet ID %q: %w", ep.ID, err)
+	}
+
+	d.logger.Info("destroying digitalocean droplet", zap.String("id", ep.ID))
+	if err := d.deleteDroplet(ctx, dropletID); err != nil {
+		d.logger.Warn("failed to destroy droplet (may not exist)", zap.Error(err))
+	}
+
+	d.mu.Lock()
+	for i, e := range d.endpoints {
+		if e.ID == ep.ID {
+			d.endpoints = append(d.endpoints[:i], d.endpoints[i+1:]...)
+			break
+		}
+	}
+	d.mu.Unlock()
+
+	return nil
+}
+
+func (d *DigitalOceanController) createDroplet(ctx context.Context, name string) (int, error) {
+	payload, _ := json.Marshal(map[string]any{
+		"name":   name,
+		"region": d.region,
+		"size":   d.size,
+		"image":  d.snapshotID,
+		"tags":   []string{"entropy-tunnel", "rotation"},
+	})
+
+	var result struct {
+		Droplet struct {
+			ID int `json:"id"`
+		} `json:"droplet"`
+	}
+	if err := d.do(ctx, http.MethodPost, doAPIBase+"/droplets", payload, &result); err != nil {
+		return 0, err
+	}
+	return result.Droplet.ID, nil
+}
+
+// waitForActive polls the droplet until DO reports it active and has
+// assigned it a public IPv4 address.
+func (d *DigitalOceanController) waitForActive(ctx context.Context, dropletID int) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, doPollTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(doPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("timeout waiting for droplet %d to become active", dropletID)
+		case <-ticker.C:
+			status, addr, err := d.getDroplet(ctx, dropletID)
+			if err != nil {
+				d.logger.Warn("failed to poll droplet status", zap.Error(err))
+				continue
+			}
+			if status == "active" && addr != "" {
+				return addr, nil
+			}
+			d.logger.Info("droplet pending", zap.Int("id", dropletID), zap.String("status", status))
+		}
+	}
+}
+
+func (d *DigitalOceanController) getDroplet(ctx context.Context, dropletID int) (status, address string, err error) {
+	var result struct {
+		Droplet struct {
+			Status  string `json:"status"`
+			Network struct {
+				V4 []struct {
+					IPAddress string `json:"ip_address"`
+					Type      string `json:"type"`
+				} `json:"v4"`
+			} `json:"networks"`
+		} `json:"droplet"`
+	}
+	if err := d.do(ctx, http.MethodGet, fmt.Sprintf("%s/droplets/%d", doAPIBase, dropletID), nil, &result); err != nil {
+		return "", "", err
+	}
+
+	for _, v4 := range result.Droplet.Network.V4 {
+		if v4.Type == "public" {
+			return result.Droplet.Status, v4.IPAddress, nil
+		}
+	}
+	return result.Droplet.Status, "", nil
+}
+
+func (d *DigitalOceanController) deleteDroplet(ctx context.Context, dropletID int) error {
+	return d.do(ctx, http.MethodDelete, fmt.Sprintf("%s/droplets/%d", doAPIBase, dropletID), nil, nil)
+}
+
+// do issues a DigitalOcean API request, retrying with exponential backoff
+// on 429 and 5xx responses (honoring a Retry-After header when DO sends
+// one) instead of letting a single throttled call stall rotation.
+func (d *DigitalOceanController) do(ctx context.Context, method, url string, payload []byte, out any) error {
+	backoff := doBaseBackoff
+
+	for attempt := 0; ; attempt++ {
+		var reader io.Reader
+		if payload != nil {
+			reader = bytes.NewReader(payload)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, url, reader)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+d.apiToken)
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := d.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("digitalocean API request failed: %w", err)
+		}
+
+		if remaining, err := strconv.Atoi(resp.Header.Get("RateLimit-Remaining")); err == nil {
+			d.mu.Lock()
+			d.quotaRemaining = remaining
+			d.mu.Unlock()
+		}
+
+		if (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500) && attempt < doMaxRetries {
+			wait := retryAfter(resp.Header, backoff)
+			resp.Body.Close()
+
+			d.logger.Warn("digitalocean API throttled or unavailable, backing off",
+				zap.Int("status", resp.StatusCode), zap.Duration("wait", wait))
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+
+			backoff *= 2
+			if backoff > doMaxBackoff {
+				backoff = doMaxBackoff
+			}
+			continue
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("digitalocean API error %d: %s", resp.StatusCode, string(body))
+		}
+		if out != nil && len(body) > 0 {
+			if err := json.Unmarshal(body, out); err != nil {
+				return fmt.Errorf("decoding response: %w", err)
+			}
+		}
+		return nil
+	}
+}
+
+// retryAfter honors a numeric Retry-After header if present, falling back
+// to the caller's current backoff duration otherwise.
+func retryAfter(h http.Header, fallback time.Duration) time.Duration {
+	if secs, err := strconv.Atoi(h.Get("Retry-After")); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return fallback
+}
+
+// QuotaRemaining returns the last observed value of DO's
+// RateLimit-Remaining header, or -1 if no request has completed yet.
+func (d *DigitalOceanController) QuotaRemaining() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.quotaRemaining
+}
+
+// Regions reports the region this controller provisions into. DO droplets
+// are created in a single region per controller instance; run one
+// DigitalOceanController per region and register each with MultiController
+// to spread across several.
+func (d *DigitalOceanController) Regions() []string {
+	return []string{d.region}
+}
+
+// Name identifies this backend to the rotation Registry.
+func (d *DigitalOceanController) Name() string { return "digitalocean" }
+
+// Provision satisfies the Provider interface expected by the Registry.
+func (d *DigitalOceanController) Provision(ctx context.Context) (*Endpoint, error) {
+	return d.Rotate(ctx)
+}
+
+// Decommission satisfies the Provider interface expected by the Registry.
+func (d *DigitalOceanController) Decommission(ctx context.Context, ep *Endpoint) error {
+	return d.Retire(ctx, ep)
+}
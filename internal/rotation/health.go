@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+
+	"github.com/fabiano/entropy-tunnel/internal/events"
 )
 
 // HealthChecker monitors endpoint health and triggers rotation on failure.
@@ -21,16 +23,72 @@ type HealthChecker struct {
 	results    map[string]*HealthResult
 	stopCh     chan struct{}
 	client     *http.Client
+	eventBus   *events.Bus
+	onFailure  func()
+	probers    []Prober
+	policy     RotationPolicy
+}
+
+// SetEventBus wires a bootstrap-progress bus so rotation decisions show up
+// for a GUI in real time. Safe to call before or after Start.
+func (hc *HealthChecker) SetEventBus(bus *events.Bus) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.eventBus = bus
+}
+
+// SetProbers replaces the health checker's probe set with probers. When
+// set, checkEndpoint runs every prober against each endpoint instead of
+// the built-in TCP/HTTPS-by-provider check, and HealthResult.Score/Probes
+// are populated from their weighted results.
+func (hc *HealthChecker) SetProbers(probers []Prober) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.probers = probers
+}
+
+// AddProber appends a single prober to the health checker's probe set.
+func (hc *HealthChecker) AddProber(p Prober) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.probers = append(hc.probers, p)
+}
+
+// SetRotationPolicy overrides the default FailCountPolicy{Threshold: 3}
+// used to decide whether an unhealthy endpoint should be retired and
+// rotated.
+func (hc *HealthChecker) SetRotationPolicy(policy RotationPolicy) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.policy = policy
+}
+
+// SetFailureHook wires a callback invoked on every failed probe, e.g.
+// MetricsFrontend.RecordHealthCheckFailure, so rotation_health_check_failures_total
+// stays accurate without HealthChecker importing the metrics frontend directly.
+func (hc *HealthChecker) SetFailureHook(fn func()) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.onFailure = fn
 }
 
 // HealthResult holds the health status of an endpoint.
 type HealthResult struct {
-	EndpointID  string        `json:"endpoint_id"`
-	Healthy     bool          `json:"healthy"`
-	Latency     time.Duration `json:"latency"`
-	LastCheck   time.Time     `json:"last_check"`
-	FailCount   int           `json:"fail_count"`
-	Error       string        `json:"error,omitempty"`
+	EndpointID string        `json:"endpoint_id"`
+	Healthy    bool          `json:"healthy"`
+	Latency    time.Duration `json:"latency"`
+	LastCheck  time.Time     `json:"last_check"`
+	FailCount  int           `json:"fail_count"`
+	Error      string        `json:"error,omitempty"`
+
+	// Score is the weighted fraction of configured Probers (see SetProbers)
+	// that reported healthy, in [0, 1]. It's 1 or 0 when no custom probers
+	// are configured, matching pre-prober behavior.
+	Score float64 `json:"score"`
+
+	// Probes holds per-probe-class detail (latency, histogram, last
+	// error), keyed by Prober.Name(), when custom probers are configured.
+	Probes map[string]*ProbeStat `json:"probes,omitempty"`
 }
 
 // NewHealthChecker creates a health checker for the given controller.
@@ -46,6 +104,7 @@ func NewHealthChecker(ctrl Controller, interval, timeout time.Duration, logger *
 		results:    make(map[string]*HealthResult),
 		stopCh:     make(chan struct{}),
 		client:     &http.Client{Timeout: timeout},
+		policy:     FailCountPolicy{Threshold: 3},
 	}
 }
 
@@ -91,6 +150,32 @@ func (hc *HealthChecker) Results() map[string]*HealthResult {
 	return cp
 }
 
+// ProbeNow runs a single on-demand health check against the active
+// endpoint identified by endpointID, bypassing the periodic ticker, and
+// records the result the same way checkAll would. Intended for the debug
+// HTTP endpoint, where an operator diagnosing a rotation loop wants an
+// answer immediately instead of waiting for the next interval tick.
+func (hc *HealthChecker) ProbeNow(ctx context.Context, endpointID string) (*HealthResult, error) {
+	var target *Endpoint
+	for _, ep := range hc.controller.ActiveEndpoints() {
+		if ep.ID == endpointID {
+			target = ep
+			break
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("endpoint %q not found among active endpoints", endpointID)
+	}
+
+	result := hc.checkEndpoint(ctx, target)
+
+	hc.mu.Lock()
+	hc.results[target.ID] = result
+	hc.mu.Unlock()
+
+	return result, nil
+}
+
 // checkAll probes all active endpoints.
 func (hc *HealthChecker) checkAll(ctx context.Context) {
 	endpoints := hc.controller.ActiveEndpoints()
@@ -103,20 +188,42 @@ func (hc *HealthChecker) checkAll(ctx context.Context) {
 		hc.mu.Unlock()
 
 		if !result.Healthy {
+			hc.mu.RLock()
+			onFailure := hc.onFailure
+			hc.mu.RUnlock()
+			if onFailure != nil {
+				onFailure()
+			}
+
 			hc.logger.Warn("endpoint unhealthy",
 				zap.String("id", ep.ID),
 				zap.Int("fail_count", result.FailCount),
 				zap.String("error", result.Error),
 			)
 
-			// Auto-rotate after 3 consecutive failures
-			if result.FailCount >= 3 {
+			hc.mu.RLock()
+			policy := hc.policy
+			hc.mu.RUnlock()
+
+			if policy.ShouldRotate(result) {
 				hc.logger.Info("triggering rotation due to unhealthy endpoint",
 					zap.String("id", ep.ID),
 				)
 				go func(ep *Endpoint) {
 					_ = hc.controller.Retire(ctx, ep)
-					_, _ = hc.controller.Rotate(ctx)
+					newEp, err := hc.controller.Rotate(ctx)
+					if err != nil {
+						return
+					}
+					hc.mu.RLock()
+					bus := hc.eventBus
+					hc.mu.RUnlock()
+					if bus != nil {
+						bus.Publish("rotation", events.EventRotationSwitched, events.RotationSwitchedData{
+							OldID: ep.ID,
+							NewID: newEp.ID,
+						})
+					}
 				}(ep)
 			}
 		}
@@ -138,6 +245,14 @@ func (hc *HealthChecker) checkEndpoint(ctx context.Context, ep *Endpoint) *Healt
 		result.FailCount = prev.FailCount
 	}
 
+	hc.mu.RLock()
+	probers := hc.probers
+	hc.mu.RUnlock()
+
+	if len(probers) > 0 {
+		return hc.checkEndpointWithProbers(ctx, ep, probers, result)
+	}
+
 	start := time.Now()
 
 	// Probe: TCP connection + optional HTTP check
@@ -179,9 +294,91 @@ func (hc *HealthChecker) checkEndpoint(ctx context.Context, ep *Endpoint) *Healt
 		}
 	}
 
+	if result.Healthy {
+		result.Score = 1
+	}
+
+	return result
+}
+
+// checkEndpointWithProbers runs every configured Prober against ep and
+// aggregates their outcomes into result: Healthy/FailCount follow a
+// simple majority-by-weight vote, Score is the weighted healthy
+// fraction, and Probes carries per-probe latency/histogram/error detail.
+func (hc *HealthChecker) checkEndpointWithProbers(ctx context.Context, ep *Endpoint, probers []Prober, result *HealthResult) *HealthResult {
+	hc.mu.RLock()
+	prev, exists := hc.results[ep.ID]
+	hc.mu.RUnlock()
+
+	result.Probes = make(map[string]*ProbeStat, len(probers))
+
+	var totalWeight, healthyWeight float64
+	var sumLatency time.Duration
+
+	for _, p := range probers {
+		weight := p.Weight()
+		if weight <= 0 {
+			weight = 1
+		}
+		totalWeight += weight
+
+		outcome := p.Probe(ctx, ep, hc.timeout)
+		sumLatency += outcome.Latency
+		if outcome.Healthy {
+			healthyWeight += weight
+		}
+
+		hist := NewLatencyHistogram()
+		if exists && prev.Probes != nil {
+			if prevStat, ok := prev.Probes[p.Name()]; ok && prevStat.Histogram != nil {
+				hist = prevStat.Histogram
+			}
+		}
+		hist.Record(outcome.Latency)
+
+		result.Probes[p.Name()] = &ProbeStat{
+			Healthy:   outcome.Healthy,
+			Latency:   outcome.Latency,
+			Detail:    outcome.Detail,
+			Histogram: hist,
+		}
+	}
+
+	if totalWeight > 0 {
+		result.Score = healthyWeight / totalWeight
+	} else {
+		result.Score = 1
+	}
+	if len(probers) > 0 {
+		result.Latency = sumLatency / time.Duration(len(probers))
+	}
+
+	// Healthy by simple majority-by-weight; FailCount/Error track the
+	// same "unhealthy" verdict RotationPolicy and EvictUnhealthy expect.
+	result.Healthy = result.Score >= 0.5
+	if result.Healthy {
+		result.FailCount = 0
+	} else {
+		result.FailCount++
+		for _, name := range proberNamesInOrder(probers) {
+			if stat := result.Probes[name]; stat != nil && !stat.Healthy {
+				result.Error = fmt.Sprintf("%s: %s", name, stat.Detail)
+				break
+			}
+		}
+	}
+
 	return result
 }
 
+func proberNamesInOrder(probers []Prober) []string {
+	names := make([]string, len(probers))
+	for i, p := range probers {
+		names[i] = p.Name()
+	}
+	return names
+}
+
 func (hc *HealthChecker) probeTCP(ctx context.Context, addr string) error {
 	dialer := &net.Dialer{Timeout: hc.timeout}
 	conn, err := dialer.DialContext(ctx, "tcp", addr)
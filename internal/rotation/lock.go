@@ -0,0 +1,25 @@
+package rotation
+
+import (
+	"context"
+	"time"
+)
+
+// Lease represents a distributed lock held by this process. Refresh
+// extends it so a long-running holder doesn't lose it to TTL expiry;
+// Release gives it up immediately so a peer doesn't have to wait out the
+// TTL to take over.
+type Lease interface {
+	Refresh(ctx context.Context) error
+	Release(ctx context.Context) error
+}
+
+// Locker is a distributed mutual-exclusion lock keyed by an arbitrary
+// string — typically a project/account ID shared by every entropy-tunnel
+// instance that could rotate the same cloud account's endpoints. Acquire
+// does not block waiting for the lock to free up; it makes one conditional
+// write attempt and returns an error immediately if another owner holds an
+// unexpired lease, so callers can just skip the current tick.
+type Locker interface {
+	Acquire(ctx context.Context, key string, ttl time.Duration) (Lease, error)
+}
@@ -0,0 +1,137 @@
+package rotation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"go.uber.org/zap"
+)
+
+// DynamoDBLocker implements Locker on a single DynamoDB table, following
+// the common "dynamolock" pattern: one row per lease key holding an owner
+// UUID and an expires_at epoch-seconds attribute, with a conditional
+// PutItem so exactly one instance can hold a given key at a time.
+type DynamoDBLocker struct {
+	client    *dynamodb.Client
+	tableName string
+	ownerID   string
+	logger    *zap.Logger
+}
+
+// NewDynamoDBLocker creates a locker backed by tableName, which must have
+// a string partition key named "lock_key". ownerID identifies this process
+// in the lease row; pass a fresh UUID per process.
+func NewDynamoDBLocker(client *dynamodb.Client, tableName, ownerID string, logger *zap.Logger) *DynamoDBLocker {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &DynamoDBLocker{client: client, tableName: tableName, ownerID: ownerID, logger: logger}
+}
+
+// Acquire makes one conditional PutItem attempt for key: it succeeds if no
+// row exists yet, if this process already owns the row, or if the existing
+// lease has expired. Any other case (another live owner) returns an error.
+func (l *DynamoDBLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (Lease, error) {
+	_, err := l.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(l.tableName),
+		Item:      l.leaseItem(key, ttl),
+		ConditionExpression: aws.String(
+			"attribute_not_exists(lock_key) OR #owner = :me OR expires_at < :now",
+		),
+		ExpressionAttributeNames: map[string]string{"#owner": "owner"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":me":  &types.AttributeValueMemberS{Value: l.ownerID},
+			":now": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", time.Now().Unix())},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("acquire lease %q: %w", key, err)
+	}
+
+	lease := &dynamoLease{locker: l, key: key, ttl: ttl, stopCh: make(chan struct{})}
+	lease.startHeartbeat()
+	return lease, nil
+}
+
+func (l *DynamoDBLocker) leaseItem(key string, ttl time.Duration) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"lock_key":   &types.AttributeValueMemberS{Value: key},
+		"owner":      &types.AttributeValueMemberS{Value: l.ownerID},
+		"expires_at": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", time.Now().Add(ttl).Unix())},
+	}
+}
+
+// dynamoLease is the Lease returned by DynamoDBLocker.Acquire. A background
+// goroutine re-puts the row every ttl/3 so the TTL doesn't lapse under a
+// still-working holder; Release stops that goroutine and deletes the row.
+type dynamoLease struct {
+	locker *DynamoDBLocker
+	key    string
+	ttl    time.Duration
+	stopCh chan struct{}
+}
+
+func (l *dynamoLease) startHeartbeat() {
+	go func() {
+		ticker := time.NewTicker(l.ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.stopCh:
+				return
+			case <-ticker.C:
+				if err := l.Refresh(context.Background()); err != nil {
+					l.locker.logger.Warn("lease heartbeat failed", zap.String("key", l.key), zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// Refresh re-puts the lease row, extending expires_at by ttl. Only
+// succeeds while this process is still the recorded owner.
+func (l *dynamoLease) Refresh(ctx context.Context) error {
+	_, err := l.locker.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(l.locker.tableName),
+		Item:                l.locker.leaseItem(l.key, l.ttl),
+		ConditionExpression: aws.String("#owner = :me"),
+		ExpressionAttributeNames: map[string]string{"#owner": "owner"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":me": &types.AttributeValueMemberS{Value: l.locker.ownerID},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("refresh lease %q: %w", l.key, err)
+	}
+	return nil
+}
+
+// Release stops the heartbeat and deletes the lease row, so a peer doesn't
+// have to wait out the TTL to acquire it.
+func (l *dynamoLease) Release(ctx context.Context) error {
+	select {
+	case <-l.stopCh:
+	default:
+		close(l.stopCh)
+	}
+
+	_, err := l.locker.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(l.locker.tableName),
+		Key: map[string]types.AttributeValue{
+			"lock_key": &types.AttributeValueMemberS{Value: l.key},
+		},
+		ConditionExpression: aws.String("#owner = :me"),
+		ExpressionAttributeNames: map[string]string{"#owner": "owner"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":me": &types.AttributeValueMemberS{Value: l.locker.ownerID},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("release lease %q: %w", l.key, err)
+	}
+	return nil
+}
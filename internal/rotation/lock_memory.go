@@ -0,0 +1,64 @@
+package rotation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MemoryLocker is a single-process Locker backed by a sync.Mutex per key.
+// It's useful for tests and for single-instance deployments that want the
+// same Controller/Locker plumbing without standing up DynamoDB. It does
+// not coordinate across processes or machines.
+type MemoryLocker struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewMemoryLocker creates an empty in-memory locker.
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{locks: make(map[string]*sync.Mutex)}
+}
+
+// Acquire takes the mutex for key without blocking, returning an error if
+// another caller already holds it. ttl is accepted for interface
+// compatibility but unused: a held Go mutex can't expire out from under a
+// live process, so there's nothing for a heartbeat to refresh.
+func (l *MemoryLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (Lease, error) {
+	l.mu.Lock()
+	m, ok := l.locks[key]
+	if !ok {
+		m = &sync.Mutex{}
+		l.locks[key] = m
+	}
+	l.mu.Unlock()
+
+	if !m.TryLock() {
+		return nil, fmt.Errorf("lease %q: already held", key)
+	}
+	return &memoryLease{mu: m}, nil
+}
+
+type memoryLease struct {
+	mu       *sync.Mutex
+	released bool
+	mtx      sync.Mutex
+}
+
+// Refresh is a no-op: an in-process mutex lease can't expire.
+func (l *memoryLease) Refresh(ctx context.Context) error {
+	return nil
+}
+
+// Release unlocks the underlying mutex. Safe to call more than once.
+func (l *memoryLease) Release(ctx context.Context) error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.released {
+		return nil
+	}
+	l.released = true
+	l.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,76 @@
+package rotation
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsFrontend exports registry state as Prometheus gauges/counters on
+// the client's local API listener, for fleets of servers to scrape
+// alongside the usual process metrics.
+type MetricsFrontend struct {
+	registry *Registry
+
+	activeEndpoints      prometheus.GaugeFunc
+	rotationsTotal       *prometheus.CounterVec
+	healthCheckFailures  prometheus.Counter
+
+	lastRotationsTotal map[string]int
+}
+
+// NewMetricsFrontend creates (and registers, if reg is non-nil) the
+// rotation metric collectors for registry. Pass prometheus.NewRegistry()
+// or prometheus.DefaultRegisterer; nil is allowed for tests that just
+// want the collectors wired up without a global side effect.
+func NewMetricsFrontend(registry *Registry, reg prometheus.Registerer) *MetricsFrontend {
+	f := &MetricsFrontend{
+		registry:           registry,
+		lastRotationsTotal: make(map[string]int),
+		rotationsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "rotation_rotations_total",
+			Help: "Total number of successful endpoint rotations, by provider.",
+		}, []string{"provider"}),
+		healthCheckFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "rotation_health_check_failures_total",
+			Help: "Total number of failed endpoint health checks across all providers.",
+		}),
+	}
+	f.activeEndpoints = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "rotation_active_endpoints",
+		Help: "Number of currently active (unexpired) rotation endpoints.",
+	}, func() float64 {
+		return float64(len(registry.ActiveEndpoints()))
+	})
+
+	if reg != nil {
+		reg.MustRegister(f.activeEndpoints, f.rotationsTotal, f.healthCheckFailures)
+	}
+	return f
+}
+
+// Sync brings the rotations_total counter vec up to date with the
+// registry's internal tally. Counters can only go up, so this adds the
+// delta since the last sync rather than setting an absolute value.
+func (f *MetricsFrontend) Sync() {
+	for provider, total := range f.registry.RotationsTotal() {
+		delta := total - f.lastRotationsTotal[provider]
+		if delta > 0 {
+			f.rotationsTotal.WithLabelValues(provider).Add(float64(delta))
+		}
+		f.lastRotationsTotal[provider] = total
+	}
+}
+
+// RecordHealthCheckFailure increments the health-check-failures counter;
+// call this from HealthChecker whenever a probe comes back unhealthy.
+func (f *MetricsFrontend) RecordHealthCheckFailure() {
+	f.healthCheckFailures.Inc()
+}
+
+// Handler returns the standard Prometheus scrape handler for mounting on
+// "GET /metrics".
+func (f *MetricsFrontend) Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,399 @@
+package rotation
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SelectionPolicy picks which sub-controller MultiController.Rotate should
+// provision through next.
+type SelectionPolicy string
+
+const (
+	// PolicyRoundRobin cycles through sub-controllers in registration order.
+	PolicyRoundRobin SelectionPolicy = "round_robin"
+	// PolicyWeightedRandom picks a sub-controller at random, weighted by
+	// SubControllerConfig.Weight.
+	PolicyWeightedRandom SelectionPolicy = "weighted_random"
+	// PolicyRegionPinned always rotates through the sub-controller whose
+	// SubControllerConfig.Region matches the hint passed to Rotate.
+	PolicyRegionPinned SelectionPolicy = "region_pinned"
+)
+
+// SubControllerConfig pairs a Controller with the weight/region
+// MultiController's policy needs to choose among several.
+type SubControllerConfig struct {
+	Name       string
+	Controller Controller
+	Weight     int    // consulted by PolicyWeightedRandom
+	Region     string // consulted by PolicyRegionPinned
+}
+
+// probeStats tracks recent health-probe outcomes for one endpoint.
+type probeStats struct {
+	attempts  int
+	successes int
+	lastRTT   time.Duration
+}
+
+func (s *probeStats) successRate() float64 {
+	if s.attempts == 0 {
+		return 1
+	}
+	return float64(s.successes) / float64(s.attempts)
+}
+
+// MultiController spreads endpoints across several rotation.Controller
+// backends (e.g. Cloudflare + AWS + Akash) and fails over between them
+// when one family gets blocked, instead of a deployment having to compile
+// in exactly one backend. It runs its own background health prober —
+// independent of HealthChecker, which only understands a single
+// Controller — dialing each endpoint's Address over TLS with the
+// camouflage SNI and tracking success rate / RTT in Endpoint.Metadata.
+type MultiController struct {
+	mu      sync.RWMutex
+	subs    []SubControllerConfig
+	policy  SelectionPolicy
+	rrIndex int
+	logger  *zap.Logger
+
+	probeSNI      string
+	probeInterval time.Duration
+	probeTimeout  time.Duration
+	failThreshold float64
+	graceWindow   time.Duration
+
+	stopCh       chan struct{}
+	probeHistory map[string]*probeStats
+	downSince    map[string]time.Time
+}
+
+// NewMultiController creates a MultiController over subs, probing each
+// endpoint's Address with sni as the TLS ServerName (matching the
+// camouflage fingerprint a real client would present).
+func NewMultiController(subs []SubControllerConfig, policy SelectionPolicy, sni string, logger *zap.Logger) *MultiController {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &MultiController{
+		subs:          subs,
+		policy:        policy,
+		logger:        logger,
+		probeSNI:      sni,
+		probeInterval: 30 * time.Second,
+		probeTimeout:  5 * time.Second,
+		failThreshold: 0.5,
+		graceWindow:   2 * time.Minute,
+		stopCh:        make(chan struct{}),
+		probeHistory:  make(map[string]*probeStats),
+		downSince:     make(map[string]time.Time),
+	}
+}
+
+// Rotate consults the selection policy to pick a sub-controller and
+// provisions a new endpoint through it.
+func (m *MultiController) Rotate(ctx context.Context) (*Endpoint, error) {
+	return m.RotateVia(ctx, "")
+}
+
+// RotateVia is like Rotate but, under PolicyRegionPinned, targets the
+// sub-controller whose Region matches hint.
+func (m *MultiController) RotateVia(ctx context.Context, hint string) (*Endpoint, error) {
+	sub, err := m.choose(hint)
+	if err != nil {
+		return nil, err
+	}
+
+	ep, err := sub.Controller.Rotate(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rotate via %s: %w", sub.Name, err)
+	}
+	m.logger.Info("multi-controller rotated endpoint",
+		zap.String("sub", sub.Name),
+		zap.String("id", ep.ID),
+	)
+	return ep, nil
+}
+
+// choose picks a sub-controller per m.policy.
+func (m *MultiController) choose(hint string) (SubControllerConfig, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.subs) == 0 {
+		return SubControllerConfig{}, fmt.Errorf("multi-controller has no sub-controllers registered")
+	}
+
+	switch m.policy {
+	case PolicyRegionPinned:
+		if hint != "" {
+			for _, s := range m.subs {
+				if s.Region == hint {
+					return s, nil
+				}
+			}
+		}
+		return m.subs[0], nil
+
+	case PolicyWeightedRandom:
+		total := 0
+		for _, s := range m.subs {
+			if s.Weight <= 0 {
+				total++
+			} else {
+				total += s.Weight
+			}
+		}
+		pick := rand.Intn(total)
+		for _, s := range m.subs {
+			w := s.Weight
+			if w <= 0 {
+				w = 1
+			}
+			if pick < w {
+				return s, nil
+			}
+			pick -= w
+		}
+		return m.subs[len(m.subs)-1], nil
+
+	default: // PolicyRoundRobin
+		s := m.subs[m.rrIndex%len(m.subs)]
+		m.rrIndex++
+		return s, nil
+	}
+}
+
+// Retire tears down ep via whichever sub-controller provisioned it,
+// matched by Name against ep.Provider; if no sub-controller's Name
+// matches, every sub is tried in turn until one succeeds.
+func (m *MultiController) Retire(ctx context.Context, ep *Endpoint) error {
+	m.mu.RLock()
+	subs := make([]SubControllerConfig, len(m.subs))
+	copy(subs, m.subs)
+	m.mu.RUnlock()
+
+	for _, s := range subs {
+		if s.Name == ep.Provider {
+			return s.Controller.Retire(ctx, ep)
+		}
+	}
+
+	var lastErr error
+	for _, s := range subs {
+		if err := s.Controller.Retire(ctx, ep); err == nil {
+			return nil
+		} else {
+			lastErr = err
+		}
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no sub-controller could retire endpoint %q", ep.ID)
+	}
+	return lastErr
+}
+
+// ActiveEndpoints aggregates ActiveEndpoints() across every sub-controller.
+func (m *MultiController) ActiveEndpoints() []*Endpoint {
+	m.mu.RLock()
+	subs := make([]SubControllerConfig, len(m.subs))
+	copy(subs, m.subs)
+	m.mu.RUnlock()
+
+	var all []*Endpoint
+	for _, s := range subs {
+		all = append(all, s.Controller.ActiveEndpoints()...)
+	}
+	return all
+}
+
+// SelectEndpoint returns the best currently-healthy endpoint for a client
+// dialer to use: among ActiveEndpoints, it excludes any whose recent probe
+// failure rate exceeds failThreshold, then picks the lowest-RTT survivor.
+// hint, if set, prefers an endpoint from that region when present.
+func (m *MultiController) SelectEndpoint(ctx context.Context, hint string) (*Endpoint, error) {
+	candidates := m.ActiveEndpoints()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var healthy []*Endpoint
+	for _, ep := range candidates {
+		stats, ok := m.probeHistory[ep.ID]
+		if ok && stats.successRate() < 1-m.failThreshold {
+			continue
+		}
+		healthy = append(healthy, ep)
+	}
+	if len(healthy) == 0 {
+		return nil, fmt.Errorf("no healthy endpoints available")
+	}
+
+	if hint != "" {
+		var regional []*Endpoint
+		for _, ep := range healthy {
+			if ep.Region == hint {
+				regional = append(regional, ep)
+			}
+		}
+		if len(regional) > 0 {
+			healthy = regional
+		}
+	}
+
+	sort.Slice(healthy, func(i, j int) bool {
+		return m.rttOf(healthy[i].ID) < m.rttOf(healthy[j].ID)
+	})
+	return healthy[0], nil
+}
+
+func (m *MultiController) rttOf(id string) time.Duration {
+	if s, ok := m.probeHistory[id]; ok {
+		return s.lastRTT
+	}
+	return time.Hour // unprobed endpoints sort last
+}
+
+// StartAutoRotation launches the background health prober and the
+// retireUnhealthy sweep; it does not itself provision endpoints on a
+// timer the way NoOpController does; callers drive Rotate/RotateVia
+// directly and rely on this loop purely for health bookkeeping and
+// eviction.
+func (m *MultiController) StartAutoRotation(ctx context.Context, interval time.Duration) error {
+	m.mu.Lock()
+	m.probeInterval = interval
+	m.mu.Unlock()
+
+	go m.probeLoop(ctx)
+	go m.retireUnhealthyLoop(ctx)
+
+	m.logger.Info("multi-controller health prober started", zap.Duration("interval", interval))
+	return nil
+}
+
+// StopAutoRotation halts the prober and eviction loops.
+func (m *MultiController) StopAutoRotation() {
+	close(m.stopCh)
+	m.stopCh = make(chan struct{})
+}
+
+func (m *MultiController) probeLoop(ctx context.Context) {
+	m.mu.RLock()
+	interval := m.probeInterval
+	m.mu.RUnlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			m.probeAll(ctx)
+		}
+	}
+}
+
+func (m *MultiController) probeAll(ctx context.Context) {
+	for _, ep := range m.ActiveEndpoints() {
+		ok, rtt := m.probeOne(ctx, ep)
+
+		m.mu.Lock()
+		stats, exists := m.probeHistory[ep.ID]
+		if !exists {
+			stats = &probeStats{}
+			m.probeHistory[ep.ID] = stats
+		}
+		stats.attempts++
+		if ok {
+			stats.successes++
+			stats.lastRTT = rtt
+			delete(m.downSince, ep.ID)
+		} else if _, down := m.downSince[ep.ID]; !down {
+			m.downSince[ep.ID] = time.Now()
+		}
+		rate := stats.successRate()
+		m.mu.Unlock()
+
+		if ep.Metadata == nil {
+			ep.Metadata = make(map[string]string)
+		}
+		ep.Metadata["probe_success_rate"] = strconv.FormatFloat(rate, 'f', 2, 64)
+		ep.Metadata["probe_rtt_ms"] = strconv.FormatInt(rtt.Milliseconds(), 10)
+	}
+}
+
+// probeOne dials ep.Address over TLS with the camouflage SNI and reports
+// whether the handshake succeeded along with its RTT.
+func (m *MultiController) probeOne(ctx context.Context, ep *Endpoint) (bool, time.Duration) {
+	dialer := &net.Dialer{Timeout: m.probeTimeout}
+	start := time.Now()
+
+	conn, err := tls.DialWithDialer(dialer, "tcp", ep.Address, &tls.Config{
+		ServerName: m.probeSNI,
+	})
+	rtt := time.Since(start)
+	if err != nil {
+		m.logger.Debug("endpoint probe failed", zap.String("id", ep.ID), zap.Error(err))
+		return false, rtt
+	}
+	conn.Close()
+	return true, rtt
+}
+
+func (m *MultiController) retireUnhealthyLoop(ctx context.Context) {
+	ticker := time.NewTicker(m.graceWindow / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			m.retireUnhealthy(ctx)
+		}
+	}
+}
+
+// retireUnhealthy retires any active endpoint that's been down past
+// graceWindow, giving transient blips time to recover before burning
+// provisioning quota on a replacement.
+func (m *MultiController) retireUnhealthy(ctx context.Context) {
+	m.mu.Lock()
+	var stale []string
+	for id, since := range m.downSince {
+		if time.Since(since) > m.graceWindow {
+			stale = append(stale, id)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, ep := range m.ActiveEndpoints() {
+		for _, id := range stale {
+			if ep.ID != id {
+				continue
+			}
+			m.logger.Warn("retiring endpoint down past grace period", zap.String("id", ep.ID))
+			if err := m.Retire(ctx, ep); err != nil {
+				m.logger.Warn("retireUnhealthy failed", zap.String("id", ep.ID), zap.Error(err))
+				continue
+			}
+			m.mu.Lock()
+			delete(m.downSince, ep.ID)
+			delete(m.probeHistory, ep.ID)
+			m.mu.Unlock()
+		}
+	}
+}
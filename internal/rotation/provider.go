@@ -0,0 +1,25 @@
+package rotation
+
+import "context"
+
+// Provider is the pluggable backend interface for the rotation Registry.
+// Each cloud backend (Cloudflare, AWS, and third-party providers like
+// Fastly/Bunny/GCP) implements this instead of the full Controller
+// interface, so the Registry can own TTL bookkeeping and health-driven
+// eviction once instead of duplicating it per backend.
+type Provider interface {
+	// Name identifies the provider, e.g. "cloudflare", "aws".
+	Name() string
+
+	// Provision stands up a new endpoint.
+	Provision(ctx context.Context) (*Endpoint, error)
+
+	// Decommission tears down a previously-provisioned endpoint.
+	Decommission(ctx context.Context, ep *Endpoint) error
+
+	// Regions lists the regions this provider can provision into, so a
+	// caller choosing among several Providers (e.g. MultiController's
+	// PolicyRegionPinned) can tell which ones are eligible without first
+	// attempting a Provision.
+	Regions() []string
+}
@@ -0,0 +1,207 @@
+package rotation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Registry is the core of the rotation subsystem: endpoint bookkeeping,
+// TTL/expiry tracking, and health-driven eviction, all independent of
+// which cloud backend actually provisions an endpoint. Backends register
+// themselves as Providers instead of reimplementing this bookkeeping, the
+// same split Snowflake uses between its broker core and pluggable
+// rendezvous strategies.
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+	endpoints []*Endpoint
+	logger    *zap.Logger
+
+	rotationsTotal map[string]int
+}
+
+// NewRegistry creates an empty rotation registry.
+func NewRegistry(logger *zap.Logger) *Registry {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Registry{
+		providers:      make(map[string]Provider),
+		logger:         logger,
+		rotationsTotal: make(map[string]int),
+	}
+}
+
+// RegisterProvider adds a backend the registry can rotate to.
+func (reg *Registry) RegisterProvider(p Provider) error {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if _, exists := reg.providers[p.Name()]; exists {
+		return fmt.Errorf("provider %q already registered", p.Name())
+	}
+	reg.providers[p.Name()] = p
+	return nil
+}
+
+// Providers lists the names of registered backends.
+func (reg *Registry) Providers() []string {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	names := make([]string, 0, len(reg.providers))
+	for name := range reg.providers {
+		names = append(names, name)
+	}
+	return names
+}
+
+// RotateVia provisions a new endpoint through the named provider.
+func (reg *Registry) RotateVia(ctx context.Context, providerName string) (*Endpoint, error) {
+	reg.mu.RLock()
+	p, ok := reg.providers[providerName]
+	reg.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("provider %q not registered", providerName)
+	}
+
+	ep, err := p.Provision(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("provision via %s: %w", providerName, err)
+	}
+
+	reg.mu.Lock()
+	reg.endpoints = append(reg.endpoints, ep)
+	reg.rotationsTotal[providerName]++
+	reg.mu.Unlock()
+
+	reg.logger.Info("registry rotated endpoint",
+		zap.String("provider", providerName),
+		zap.String("id", ep.ID),
+	)
+	return ep, nil
+}
+
+// RetireByID tears down and forgets the endpoint with the given ID via its
+// originating provider.
+func (reg *Registry) RetireByID(ctx context.Context, id string) error {
+	reg.mu.Lock()
+	var ep *Endpoint
+	idx := -1
+	for i, e := range reg.endpoints {
+		if e.ID == id {
+			ep, idx = e, i
+			break
+		}
+	}
+	reg.mu.Unlock()
+
+	if ep == nil {
+		return fmt.Errorf("endpoint %q not found", id)
+	}
+
+	reg.mu.RLock()
+	p, ok := reg.providers[ep.Provider]
+	reg.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("provider %q for endpoint %q not registered", ep.Provider, id)
+	}
+
+	if err := p.Decommission(ctx, ep); err != nil {
+		return fmt.Errorf("decommission %q via %s: %w", id, ep.Provider, err)
+	}
+
+	reg.mu.Lock()
+	reg.endpoints = append(reg.endpoints[:idx], reg.endpoints[idx+1:]...)
+	reg.mu.Unlock()
+	return nil
+}
+
+// EvictUnhealthy retires every endpoint health reports as unhealthy with
+// at least failThreshold consecutive failures, returning how many it
+// evicted. Intended to be called from a HealthChecker loop.
+func (reg *Registry) EvictUnhealthy(ctx context.Context, health map[string]*HealthResult, failThreshold int) int {
+	reg.mu.RLock()
+	var stale []string
+	for _, ep := range reg.endpoints {
+		if r, ok := health[ep.ID]; ok && !r.Healthy && r.FailCount >= failThreshold {
+			stale = append(stale, ep.ID)
+		}
+	}
+	reg.mu.RUnlock()
+
+	evicted := 0
+	for _, id := range stale {
+		if err := reg.RetireByID(ctx, id); err != nil {
+			reg.logger.Warn("health-driven eviction failed", zap.String("id", id), zap.Error(err))
+			continue
+		}
+		evicted++
+	}
+	return evicted
+}
+
+// ActiveEndpoints returns all endpoints that haven't expired.
+func (reg *Registry) ActiveEndpoints() []*Endpoint {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	active := make([]*Endpoint, 0, len(reg.endpoints))
+	for _, ep := range reg.endpoints {
+		if !ep.IsExpired() {
+			active = append(active, ep)
+		}
+	}
+	return active
+}
+
+// EvictExpired retires every endpoint past its ExpiresAt.
+func (reg *Registry) EvictExpired(ctx context.Context) {
+	reg.mu.RLock()
+	var expired []string
+	for _, ep := range reg.endpoints {
+		if ep.IsExpired() {
+			expired = append(expired, ep.ID)
+		}
+	}
+	reg.mu.RUnlock()
+
+	for _, id := range expired {
+		if err := reg.RetireByID(ctx, id); err != nil {
+			reg.logger.Warn("expiry eviction failed", zap.String("id", id), zap.Error(err))
+		}
+	}
+}
+
+// StartTTLSweep periodically evicts expired endpoints until ctx is done.
+func (reg *Registry) StartTTLSweep(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reg.EvictExpired(ctx)
+			}
+		}
+	}()
+}
+
+// RotationsTotal returns the per-provider rotation counters, for the
+// Prometheus frontend.
+func (reg *Registry) RotationsTotal() map[string]int {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	out := make(map[string]int, len(reg.rotationsTotal))
+	for k, v := range reg.rotationsTotal {
+		out[k] = v
+	}
+	return out
+}
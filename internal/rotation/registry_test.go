@@ -0,0 +1,81 @@
+package rotation
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeProvider struct {
+	name    string
+	counter int
+}
+
+func (f *fakeProvider) Name() string { return f.name }
+
+func (f *fakeProvider) Provision(ctx context.Context) (*Endpoint, error) {
+	f.counter++
+	return &Endpoint{ID: f.name, Provider: f.name, ExpiresAt: time.Now().Add(1 * time.Hour)}, nil
+}
+
+func (f *fakeProvider) Decommission(ctx context.Context, ep *Endpoint) error {
+	return nil
+}
+
+func (f *fakeProvider) Regions() []string { return []string{"test"} }
+
+func TestRegistry_RegisterAndRotate(t *testing.T) {
+	reg := NewRegistry(nil)
+	p := &fakeProvider{name: "fake"}
+
+	if err := reg.RegisterProvider(p); err != nil {
+		t.Fatalf("RegisterProvider() error = %v", err)
+	}
+
+	ep, err := reg.RotateVia(context.Background(), "fake")
+	if err != nil {
+		t.Fatalf("RotateVia() error = %v", err)
+	}
+	if ep.Provider != "fake" {
+		t.Errorf("expected provider 'fake', got %q", ep.Provider)
+	}
+
+	active := reg.ActiveEndpoints()
+	if len(active) != 1 {
+		t.Fatalf("expected 1 active endpoint, got %d", len(active))
+	}
+}
+
+func TestRegistry_RegisterDuplicate(t *testing.T) {
+	reg := NewRegistry(nil)
+	p := &fakeProvider{name: "fake"}
+
+	if err := reg.RegisterProvider(p); err != nil {
+		t.Fatalf("RegisterProvider() error = %v", err)
+	}
+	if err := reg.RegisterProvider(p); err == nil {
+		t.Error("expected error registering duplicate provider")
+	}
+}
+
+func TestRegistry_RetireByID(t *testing.T) {
+	reg := NewRegistry(nil)
+	p := &fakeProvider{name: "fake"}
+	_ = reg.RegisterProvider(p)
+
+	ep, _ := reg.RotateVia(context.Background(), "fake")
+	if err := reg.RetireByID(context.Background(), ep.ID); err != nil {
+		t.Fatalf("RetireByID() error = %v", err)
+	}
+
+	if len(reg.ActiveEndpoints()) != 0 {
+		t.Error("expected 0 active endpoints after retire")
+	}
+}
+
+func TestRegistry_RotateUnknownProvider(t *testing.T) {
+	reg := NewRegistry(nil)
+	if _, err := reg.RotateVia(context.Background(), "missing"); err == nil {
+		t.Error("expected error rotating via unregistered provider")
+	}
+}
@@ -0,0 +1,89 @@
+package rotation
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/rpc"
+
+	"go.uber.org/zap"
+)
+
+// RPCFrontend exposes the Registry over net/rpc so entropy-cli can trigger
+// a rotation without a full server restart (`entropy-cli rotate
+// --provider=cloudflare --now`).
+type RPCFrontend struct {
+	registry *Registry
+	logger   *zap.Logger
+	listener net.Listener
+}
+
+// NewRPCFrontend wraps registry for RPC access.
+func NewRPCFrontend(registry *Registry, logger *zap.Logger) *RPCFrontend {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &RPCFrontend{registry: registry, logger: logger}
+}
+
+// RotateArgs are the arguments for RPCFrontend.Rotate.
+type RotateArgs struct {
+	Provider string
+}
+
+// RotateReply is the result of RPCFrontend.Rotate.
+type RotateReply struct {
+	Endpoint *Endpoint
+}
+
+// Rotate is the net/rpc method entropy-cli calls for `rotate --now`.
+func (f *RPCFrontend) Rotate(args RotateArgs, reply *RotateReply) error {
+	ep, err := f.registry.RotateVia(context.Background(), args.Provider)
+	if err != nil {
+		return err
+	}
+	reply.Endpoint = ep
+	return nil
+}
+
+// RetireArgs are the arguments for RPCFrontend.Retire.
+type RetireArgs struct {
+	EndpointID string
+}
+
+// Retire is the net/rpc method for retiring a specific endpoint.
+func (f *RPCFrontend) Retire(args RetireArgs, reply *struct{}) error {
+	return f.registry.RetireByID(context.Background(), args.EndpointID)
+}
+
+// ListEndpoints is the net/rpc method for listing active endpoints.
+func (f *RPCFrontend) ListEndpoints(_ struct{}, reply *[]*Endpoint) error {
+	*reply = f.registry.ActiveEndpoints()
+	return nil
+}
+
+// Listen starts serving RPC requests on addr (typically a local unix
+// socket or loopback address, e.g. "127.0.0.1:9877").
+func (f *RPCFrontend) Listen(addr string) error {
+	if err := rpc.RegisterName("Rotation", f); err != nil {
+		return fmt.Errorf("register RPC service: %w", err)
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", addr, err)
+	}
+	f.listener = ln
+
+	f.logger.Info("rotation RPC frontend listening", zap.String("addr", addr))
+	go rpc.Accept(ln)
+	return nil
+}
+
+// Close stops serving RPC requests.
+func (f *RPCFrontend) Close() error {
+	if f.listener != nil {
+		return f.listener.Close()
+	}
+	return nil
+}
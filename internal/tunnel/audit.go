@@ -0,0 +1,330 @@
+package tunnel
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fabiano/entropy-tunnel/internal/camouflage"
+)
+
+// Severity classifies how worried an operator should be about a Finding.
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// Finding is a single audit result, structural or live.
+type Finding struct {
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// AuditReport is the result of running an Auditor, combining structural
+// config checks with (optionally) a live handshake capture.
+type AuditReport struct {
+	Findings []Finding `json:"findings"`
+}
+
+// Passed reports whether the audit found nothing at SeverityCritical.
+func (r *AuditReport) Passed() bool {
+	for _, f := range r.Findings {
+		if f.Severity == SeverityCritical {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders the report as human-readable lines, one Finding per
+// line, for CLI output.
+func (r *AuditReport) String() string {
+	var b strings.Builder
+	for _, f := range r.Findings {
+		fmt.Fprintf(&b, "[%s] %s: %s\n", strings.ToUpper(string(f.Severity)), f.Check, f.Message)
+	}
+	if len(r.Findings) == 0 {
+		b.WriteString("no findings\n")
+	}
+	return b.String()
+}
+
+func (r *AuditReport) add(check string, sev Severity, format string, args ...any) {
+	r.Findings = append(r.Findings, Finding{
+		Check:    check,
+		Severity: sev,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Auditor runs the server's own DPI-resistance checks against a config,
+// and optionally against a running instance, so an operator can ask "does
+// this deployment actually look like what it's pretending to be?" without
+// reading through config_builder.go by hand. It promotes what used to be
+// ad-hoc assertions in dpi_test.go into something callable from
+// entropy-server audit and from the API server.
+type Auditor struct {
+	cfg    *Config
+	logger *zap.Logger
+}
+
+// NewAuditor creates an Auditor for cfg. logger may be nil.
+func NewAuditor(cfg *Config, logger *zap.Logger) *Auditor {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Auditor{cfg: cfg, logger: logger}
+}
+
+// leakyStrings are identifiers that would tip off a DPI engine inspecting
+// the generated xray-core JSON that this is a tunnel, not plain HTTPS.
+var leakyStrings = []string{
+	"entropy", "tunnel", "vpn", "proxy",
+	"shadowsocks", "v2ray", "xray",
+}
+
+// LoopbackListenAddr turns a listen address like ":443" or "0.0.0.0:443"
+// into a dialable "127.0.0.1:443". The server binds to all interfaces,
+// but Auditor.Run's live handshake check needs a concrete host to connect
+// to, so callers (the CLI's --live flag, the API's ?live=1 query param)
+// derive one from the same Config.Listen the server itself bound to.
+func LoopbackListenAddr(listen string) (string, error) {
+	_, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "", fmt.Errorf("parse listen address %q: %w", listen, err)
+	}
+	return net.JoinHostPort("127.0.0.1", port), nil
+}
+
+// Run performs the structural config checks, and — if listenAddr is
+// non-empty — dials it to capture a live ClientHello and certificate
+// chain. listenAddr is typically the server's own Reality listen address
+// (e.g. "127.0.0.1:443"); pass "" to skip the live check (e.g. in CI,
+// where nothing is listening).
+func (a *Auditor) Run(ctx context.Context, listenAddr string) (*AuditReport, error) {
+	report := &AuditReport{}
+
+	jsonCfg, err := BuildServerJSON(a.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("build server json: %w", err)
+	}
+
+	var parsed xrayFullConfig
+	if err := json.Unmarshal(jsonCfg, &parsed); err != nil {
+		return nil, fmt.Errorf("parse generated server json: %w", err)
+	}
+
+	a.auditSecurity(report, &parsed)
+	a.auditLeakyStrings(report, jsonCfg)
+	a.auditFallbackDecoy(report, &parsed)
+	a.auditFingerprintPlausibility(report)
+
+	if listenAddr != "" {
+		a.auditHandshake(ctx, report, listenAddr)
+	}
+
+	return report, nil
+}
+
+// auditSecurity checks the primary inbound uses Reality (not plain TLS,
+// which is trivially fingerprintable) and that Reality itself is
+// configured the way it needs to be to pass as the decoy destination.
+func (a *Auditor) auditSecurity(report *AuditReport, cfg *xrayFullConfig) {
+	if len(cfg.Inbounds) == 0 {
+		report.add("security", SeverityCritical, "generated config has no inbounds")
+		return
+	}
+
+	primary := cfg.Inbounds[0]
+	if primary.Stream == nil || primary.Stream.Security != "reality" {
+		report.add("security", SeverityCritical, "primary inbound security = %q, want %q", streamSecurity(primary.Stream), "reality")
+		return
+	}
+
+	reality := primary.Stream.Reality
+	if reality == nil {
+		report.add("security", SeverityCritical, "primary inbound is missing realitySettings")
+		return
+	}
+	if reality.Show {
+		report.add("security", SeverityCritical, "realitySettings.show is true; Reality internals can leak into TLS alerts")
+	}
+	if reality.Dest != a.cfg.Reality.SNI+":443" {
+		report.add("security", SeverityWarning, "realitySettings.dest = %q, want %q (decoy destination)", reality.Dest, a.cfg.Reality.SNI+":443")
+	}
+
+	found := false
+	for _, sn := range reality.ServerNames {
+		if sn == a.cfg.Reality.SNI {
+			found = true
+			break
+		}
+	}
+	if !found {
+		report.add("security", SeverityCritical, "realitySettings.serverNames is missing the configured SNI %q", a.cfg.Reality.SNI)
+	}
+}
+
+func streamSecurity(s *xrayStream) string {
+	if s == nil {
+		return ""
+	}
+	return s.Security
+}
+
+// auditLeakyStrings flags any identifying substring in the generated JSON
+// that a DPI engine's string matcher would key on.
+func (a *Auditor) auditLeakyStrings(report *AuditReport, jsonCfg []byte) {
+	raw := string(jsonCfg)
+	for _, s := range leakyStrings {
+		if auditContainsCI(raw, s) {
+			report.add("leaky-strings", SeverityCritical, "generated config contains identifying string %q", s)
+		}
+	}
+}
+
+// auditFallbackDecoy checks that each configured fallback actually carries
+// the transport settings it claims to, so traffic that doesn't match the
+// primary inbound still gets routed to something that looks legitimate.
+func (a *Auditor) auditFallbackDecoy(report *AuditReport, cfg *xrayFullConfig) {
+	if len(a.cfg.Fallbacks) == 0 {
+		report.add("fallback-decoy", SeverityInfo, "no fallbacks configured")
+		return
+	}
+
+	if len(cfg.Inbounds) < 1+len(a.cfg.Fallbacks) {
+		report.add("fallback-decoy", SeverityCritical, "expected %d fallback inbound(s), generated config has %d total inbounds", len(a.cfg.Fallbacks), len(cfg.Inbounds))
+		return
+	}
+
+	for i, fb := range a.cfg.Fallbacks {
+		inbound := cfg.Inbounds[i+1]
+		if inbound.Protocol != fb.Protocol {
+			report.add("fallback-decoy", SeverityCritical, "fallback %d protocol = %q, want %q", i, inbound.Protocol, fb.Protocol)
+		}
+		if fb.Transport == "ws" && (inbound.Stream == nil || inbound.Stream.WS == nil) {
+			report.add("fallback-decoy", SeverityCritical, "fallback %d declares ws transport but has no wsSettings", i)
+		}
+	}
+}
+
+// auditFingerprintPlausibility checks the configured fingerprint is one
+// of the browsers camouflage actually knows how to emulate.
+func (a *Auditor) auditFingerprintPlausibility(report *AuditReport) {
+	if a.cfg.Fingerprint == "rotate" {
+		report.add("fingerprint-plausibility", SeverityInfo, "fingerprint %q rotates per-dial across camouflage.SupportedFingerprints via Engine's RollingFingerprint; live handshake check below reflects only this run", a.cfg.Fingerprint)
+		return
+	}
+	if _, ok := camouflage.SupportedFingerprints[a.cfg.Fingerprint]; !ok {
+		report.add("fingerprint-plausibility", SeverityCritical, "fingerprint %q is not a recognized browser fingerprint (supported: %v)", a.cfg.Fingerprint, camouflage.ListFingerprints())
+		return
+	}
+	if a.cfg.Fingerprint == "random" || a.cfg.Fingerprint == "randomized" {
+		report.add("fingerprint-plausibility", SeverityInfo, "fingerprint %q rotates per-connection; live handshake check below reflects only this run", a.cfg.Fingerprint)
+	}
+}
+
+// helloCapture wraps a net.Conn and records the bytes of its first Write,
+// which for a freshly dialed tls.Client is the raw ClientHello record —
+// crypto/tls.ClientHelloInfo doesn't expose the ordered extension list
+// JA3/JA4 need, so capturing the wire bytes is the only way to get them.
+type helloCapture struct {
+	net.Conn
+	once     sync.Once
+	captured []byte
+}
+
+func (h *helloCapture) Write(b []byte) (int, error) {
+	h.once.Do(func() {
+		h.captured = append([]byte(nil), b...)
+	})
+	return h.Conn.Write(b)
+}
+
+// auditHandshake dials listenAddr, captures the ClientHello this process's
+// stdlib TLS stack sends, and compares its JA3/JA4 hashes against the
+// bundled corpus entry for the configured fingerprint. This tree has no
+// vendored uTLS dependency, so the dial below uses crypto/tls rather than
+// a true browser-mimicking client; a stdlib ClientHello will legitimately
+// fail to match the corpus even when the production client (which does
+// use uTLS) would pass. Treat a mismatch here as inconclusive, not as
+// proof the deployed fingerprint is wrong — point this at a real client
+// process's connection for a trustworthy verdict.
+func (a *Auditor) auditHandshake(ctx context.Context, report *AuditReport, listenAddr string) {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	rawConn, err := dialer.DialContext(ctx, "tcp", listenAddr)
+	if err != nil {
+		report.add("handshake", SeverityWarning, "could not dial %s: %v", listenAddr, err)
+		return
+	}
+	defer rawConn.Close()
+
+	capture := &helloCapture{Conn: rawConn}
+	tlsConn := tls.Client(capture, &tls.Config{
+		ServerName:         a.cfg.Reality.SNI,
+		InsecureSkipVerify: true,
+	})
+	defer tlsConn.Close()
+
+	// Reality answers a non-conforming client with the real decoy site's
+	// TLS, or simply resets — either way we only need the ClientHello we
+	// sent, so a handshake error here isn't itself a Finding.
+	_ = tlsConn.HandshakeContext(ctx)
+
+	if len(capture.captured) == 0 {
+		report.add("handshake", SeverityWarning, "no ClientHello bytes captured from %s", listenAddr)
+		return
+	}
+
+	ch, err := camouflage.ParseClientHello(capture.captured)
+	if err != nil {
+		report.add("handshake", SeverityWarning, "could not parse captured ClientHello: %v", err)
+		return
+	}
+
+	ja3 := camouflage.JA3Hash(ch)
+	ja4 := camouflage.JA4Hash(ch)
+	report.add("handshake", SeverityInfo, "captured ClientHello: ja3=%s ja4=%s", ja3, ja4)
+
+	known, ok := camouflage.LookupCorpus(a.cfg.Fingerprint)
+	if !ok {
+		report.add("handshake", SeverityInfo, "no corpus entry for fingerprint %q to compare against", a.cfg.Fingerprint)
+		return
+	}
+	if ja3 != known.JA3 {
+		report.add("handshake", SeverityInfo, "captured JA3 %s does not match bundled %s JA3 %s (expected with this tree's stdlib-only TLS dial; see Auditor.auditHandshake doc comment)", ja3, known.Browser, known.JA3)
+	}
+
+	if state := tlsConn.ConnectionState(); len(state.PeerCertificates) > 0 {
+		leaf := state.PeerCertificates[0]
+		if leaf.Subject.CommonName == a.cfg.Reality.SNI || contains(leaf.DNSNames, a.cfg.Reality.SNI) {
+			report.add("handshake", SeverityInfo, "peer certificate matches configured decoy SNI %q", a.cfg.Reality.SNI)
+		}
+	}
+}
+
+func contains(haystack []string, needle string) bool {
+	for _, h := range haystack {
+		if h == needle {
+			return true
+		}
+	}
+	return false
+}
+
+// auditContainsCI reports whether haystack contains needle,
+// case-insensitively.
+func auditContainsCI(haystack, needle string) bool {
+	return strings.Contains(strings.ToLower(haystack), strings.ToLower(needle))
+}
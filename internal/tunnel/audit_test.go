@@ -0,0 +1,76 @@
+package tunnel
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAuditor_Run_CleanConfig(t *testing.T) {
+	cfg := &Config{
+		Listen: ":443", Protocol: "vless", UUID: "uuid",
+		Reality: RealityConfig{
+			SNI:        "www.microsoft.com",
+			PrivateKey: "test-key",
+			ShortIDs:   []string{"deadbeef"},
+		},
+		Fingerprint: "chrome",
+		Fallbacks: []FallbackConfig{
+			{Protocol: "trojan", Listen: ":8443", Transport: "ws", Path: "/ws"},
+		},
+	}
+
+	a := NewAuditor(cfg, nil)
+	report, err := a.Run(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if !report.Passed() {
+		t.Errorf("expected a clean config to pass, findings: %s", report.String())
+	}
+}
+
+func TestAuditor_Run_UnrecognizedFingerprint(t *testing.T) {
+	cfg := &Config{
+		Listen: ":443", UUID: "u",
+		Reality:     RealityConfig{SNI: "g.com", PrivateKey: "k"},
+		Fingerprint: "not-a-real-browser",
+	}
+
+	a := NewAuditor(cfg, nil)
+	report, err := a.Run(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if report.Passed() {
+		t.Error("expected an unrecognized fingerprint to fail the audit")
+	}
+}
+
+func TestAuditor_Run_NoFallbacksConfigured(t *testing.T) {
+	cfg := &Config{
+		Listen: ":443", UUID: "u",
+		Reality: RealityConfig{SNI: "g.com", PrivateKey: "k"},
+	}
+
+	a := NewAuditor(cfg, nil)
+	report, err := a.Run(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	for _, f := range report.Findings {
+		if f.Check == "fallback-decoy" && f.Severity == SeverityInfo {
+			return
+		}
+	}
+	t.Error("expected an info-level fallback-decoy finding when no fallbacks are configured")
+}
+
+func TestAuditReport_String_EmptyFindings(t *testing.T) {
+	report := &AuditReport{}
+	if got := report.String(); got != "no findings\n" {
+		t.Errorf("String() = %q, want %q", got, "no findings\n")
+	}
+}
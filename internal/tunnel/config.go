@@ -3,8 +3,12 @@ package tunnel
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/fabiano/entropy-tunnel/internal/camouflage"
 )
 
 // Config holds the server-side tunnel configuration.
@@ -25,6 +29,49 @@ type Config struct {
 
 	// Payment settings.
 	Payment PaymentConfig `yaml:"payment"`
+
+	// Shaping enables BuFLO/Tamaraw-style traffic shaping
+	// (camouflage.Shaper) on the fallback inbounds.
+	Shaping ShapingConfig `yaml:"shaping"`
+}
+
+// ShapingConfig configures camouflage.Shaper for connections that opt in:
+// fixed-size cells sent at a fixed cadence, real data or Poisson-distributed
+// dummy padding, until a run of quiet slots ends the flow.
+type ShapingConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// SlotInterval (τ), e.g. "20ms". Empty uses camouflage's default.
+	SlotInterval string `yaml:"slot_interval"`
+
+	// CellSize (L) in bytes. Zero uses camouflage's default.
+	CellSize int `yaml:"cell_size"`
+
+	// Lambda (λ), the dummy-cell Poisson process's mean rate in
+	// cells/second.
+	Lambda float64 `yaml:"lambda"`
+
+	// QuietQuantum (Q), consecutive empty slots before the flow ends.
+	// Zero uses camouflage's default.
+	QuietQuantum int `yaml:"quiet_quantum"`
+}
+
+// Resolve converts a ShapingConfig into the camouflage.ShapingConfig
+// camouflage.NewShaper expects, parsing SlotInterval if set.
+func (c ShapingConfig) Resolve() (camouflage.ShapingConfig, error) {
+	out := camouflage.ShapingConfig{
+		CellSize:     c.CellSize,
+		Lambda:       c.Lambda,
+		QuietQuantum: c.QuietQuantum,
+	}
+	if c.SlotInterval != "" {
+		d, err := time.ParseDuration(c.SlotInterval)
+		if err != nil {
+			return out, fmt.Errorf("invalid shaping.slot_interval %q: %w", c.SlotInterval, err)
+		}
+		out.SlotInterval = d
+	}
+	return out, nil
 }
 
 // RealityConfig holds XTLS-Reality settings.
@@ -45,23 +92,23 @@ type FallbackConfig struct {
 
 // RotationConfig holds dynamic endpoint rotation settings.
 type RotationConfig struct {
-	Enabled    bool   `yaml:"enabled"`
-	Provider   string `yaml:"provider"`   // "cloudflare", "aws", "noop"
-	Interval   string `yaml:"interval"`   // e.g. "30m"
-	CFAPIToken string `yaml:"cf_api_token"`
+	Enabled     bool   `yaml:"enabled"`
+	Provider    string `yaml:"provider"` // "cloudflare", "aws", "noop"
+	Interval    string `yaml:"interval"` // e.g. "30m"
+	CFAPIToken  string `yaml:"cf_api_token"`
 	CFAccountID string `yaml:"cf_account_id"`
-	CFZoneID   string `yaml:"cf_zone_id"`
-	AWSRegion  string `yaml:"aws_region"`
-	AWSKey     string `yaml:"aws_access_key"`
-	AWSSecret  string `yaml:"aws_secret_key"`
+	CFZoneID    string `yaml:"cf_zone_id"`
+	AWSRegion   string `yaml:"aws_region"`
+	AWSKey      string `yaml:"aws_access_key"`
+	AWSSecret   string `yaml:"aws_secret_key"`
 }
 
 // PaymentConfig holds BTCPay Server settings.
 type PaymentConfig struct {
-	Enabled    bool   `yaml:"enabled"`
-	BTCPayURL  string `yaml:"btcpay_url"`
-	BTCPayKey  string `yaml:"btcpay_api_key"`
-	StoreID    string `yaml:"btcpay_store_id"`
+	Enabled   bool   `yaml:"enabled"`
+	BTCPayURL string `yaml:"btcpay_url"`
+	BTCPayKey string `yaml:"btcpay_api_key"`
+	StoreID   string `yaml:"btcpay_store_id"`
 }
 
 // Validate checks the configuration for required fields.
@@ -81,6 +128,12 @@ func (c *Config) Validate() error {
 	if c.Reality.PrivateKey == "" {
 		return fmt.Errorf("reality.private_key is required")
 	}
+	if err := validateSecretEntropy("uuid", c.UUID); err != nil {
+		return err
+	}
+	if err := validateSecretEntropy("reality.private_key", c.Reality.PrivateKey); err != nil {
+		return err
+	}
 	if c.Fingerprint == "" {
 		c.Fingerprint = "chrome"
 	}
@@ -90,9 +143,84 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("unsupported protocol: %s (supported: vless, trojan)", c.Protocol)
 	}
 
+	if c.Rotation.Enabled && c.Rotation.Provider == "cloudflare" && c.Rotation.CFAPIToken == "" {
+		return fmt.Errorf("rotation.cf_api_token is required when rotation is enabled with provider \"cloudflare\"")
+	}
+
+	return nil
+}
+
+// knownWeakSecrets lists values that turn up in tutorials, doc examples,
+// and this repo's own `generate-config` output (see
+// cmd/entropy-server/main.go) — if Validate sees one of these verbatim,
+// the operator almost certainly copy-pasted it rather than generating
+// their own.
+var knownWeakSecrets = map[string]bool{
+	"your-uuid-here":                       true,
+	"your-x25519-private-key":              true,
+	"00000000-0000-0000-0000-000000000000": true,
+	"11111111-1111-1111-1111-111111111111": true,
+	"123e4567-e89b-12d3-a456-426614174000": true, // the canonical RFC 9562 example UUID
+}
+
+// validateSecretEntropy flags UUID/private-key values that could not have
+// come out of a CSPRNG: known tutorial placeholders, a single repeated
+// character, or a run of strictly sequential characters. It only looks at
+// values long enough for the pattern to be meaningful, so short
+// placeholders used in unit tests don't trip it — real generated keys and
+// UUIDs are always well past that length anyway.
+func validateSecretEntropy(field, value string) error {
+	if knownWeakSecrets[strings.ToLower(value)] {
+		return fmt.Errorf("%s is a known tutorial/placeholder value (%q); generate a real one instead of copying it from docs", field, value)
+	}
+	if len(value) < 16 {
+		return nil
+	}
+
+	stripped := strings.ToLower(strings.ReplaceAll(value, "-", ""))
+	if isRepeatedChar(stripped) {
+		return fmt.Errorf("%s is a single repeated character, not a randomly generated value", field)
+	}
+	if looksSequential(stripped) {
+		return fmt.Errorf("%s looks sequential, not a randomly generated value", field)
+	}
 	return nil
 }
 
+// isRepeatedChar reports whether s is the same byte repeated throughout
+// (e.g. an all-zeros UUID with its dashes stripped).
+func isRepeatedChar(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if s[i] != s[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// looksSequential reports whether s is a strictly ascending or strictly
+// descending run of adjacent byte values (e.g. "0123456789abcdef"), a
+// pattern no CSPRNG produces but that shows up constantly in hand-typed
+// placeholder secrets.
+func looksSequential(s string) bool {
+	if len(s) < 4 {
+		return false
+	}
+	ascending, descending := true, true
+	for i := 1; i < len(s); i++ {
+		if s[i] != s[i-1]+1 {
+			ascending = false
+		}
+		if s[i] != s[i-1]-1 {
+			descending = false
+		}
+	}
+	return ascending || descending
+}
+
 // LoadConfig reads and parses a YAML configuration file.
 func LoadConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
@@ -118,11 +246,39 @@ type ClientConfig struct {
 	HTTPListen  string `yaml:"http_listen"`
 	LogLevel    string `yaml:"log_level"`
 
+	// Transport selects the streamSettings network for the proxy
+	// outbound: "" (default) for raw REALITY over tcp, or "grpc" to
+	// layer REALITY over a gRPC stream instead, which is markedly
+	// harder for DPI to distinguish from legitimate gRPC API traffic.
+	Transport string `yaml:"transport"`
+
+	// Shaping enables BuFLO/Tamaraw-style traffic shaping
+	// (camouflage.Shaper) on the local SOCKS5/HTTP inbounds.
+	Shaping ShapingConfig `yaml:"shaping"`
+
 	// SportsMode for low-latency + extra noise.
 	SportsMode bool `yaml:"sports_mode"`
 
 	// APIListen is the local HTTP API address for GUI integration.
 	APIListen string `yaml:"api_listen"`
+
+	// ECHMode selects Encrypted Client Hello behavior: "" or "disabled"
+	// sends no ECH extension, "grease" sends camouflage.GenerateGreaseECH
+	// (looks like ECH to a passive observer, hides nothing), and "full"
+	// seals the real ClientHelloInner against ECHConfigList using
+	// camouflage.SealClientHelloInner, actually hiding the SNI.
+	ECHMode string `yaml:"ech_mode"`
+
+	// ECHConfigList is the base64 ECHConfigList to seal against when
+	// ECHMode is "full" — either pasted from camouflage.GenerateECHConfig
+	// or camouflage.FetchECHConfigFromHTTPS, or left empty to fetch it
+	// fresh from ECHPublicName at startup via FetchECHConfigFromHTTPS.
+	ECHConfigList string `yaml:"ech_config_list"`
+
+	// ECHPublicName is the outer SNI an ECHConfigList was published
+	// under (e.g. "cloudflare-ech.com"); used to fetch ECHConfigList via
+	// FetchECHConfigFromHTTPS when it isn't set directly.
+	ECHPublicName string `yaml:"ech_public_name"`
 }
 
 // LoadClientConfig reads and parses a client YAML configuration file.
@@ -161,5 +317,17 @@ func (c *ClientConfig) Validate() error {
 	if c.APIListen == "" {
 		c.APIListen = "127.0.0.1:9876"
 	}
+	if c.ECHMode == "" {
+		c.ECHMode = string(camouflage.ECHModeDisabled)
+	}
+	switch camouflage.ECHMode(c.ECHMode) {
+	case camouflage.ECHModeDisabled, camouflage.ECHModeGrease:
+	case camouflage.ECHModeFull:
+		if c.ECHConfigList == "" && c.ECHPublicName == "" {
+			return fmt.Errorf("ech_config_list or ech_public_name is required when ech_mode is \"full\"")
+		}
+	default:
+		return fmt.Errorf("unsupported ech_mode: %s (supported: disabled, grease, full)", c.ECHMode)
+	}
 	return nil
 }
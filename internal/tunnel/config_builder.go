@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/fabiano/entropy-tunnel/internal/camouflage"
 )
 
 // ---- Xray-core compatible JSON structures ----
@@ -36,11 +38,12 @@ type xrayOutbound struct {
 }
 
 type xrayStream struct {
-	Network  string              `json:"network"`
-	Security string              `json:"security"`
-	Reality  *xrayRealityStream  `json:"realitySettings,omitempty"`
-	TLS      *xrayTLSStream      `json:"tlsSettings,omitempty"`
-	WS       *xrayWSStream       `json:"wsSettings,omitempty"`
+	Network  string             `json:"network"`
+	Security string             `json:"security"`
+	Reality  *xrayRealityStream `json:"realitySettings,omitempty"`
+	TLS      *xrayTLSStream     `json:"tlsSettings,omitempty"`
+	WS       *xrayWSStream      `json:"wsSettings,omitempty"`
+	GRPC     *xrayGRPCStream    `json:"grpcSettings,omitempty"`
 }
 
 type xrayRealityStream struct {
@@ -55,6 +58,10 @@ type xrayRealityStream struct {
 	Fingerprint string `json:"fingerprint,omitempty"`
 	PublicKey   string `json:"publicKey,omitempty"`
 	ShortID     string `json:"shortId,omitempty"`
+	// EchConfigList is the base64 ECHConfigList to seal the ClientHello
+	// against when cfg.ECHMode is "full"; GREASE mode rides along inside
+	// Fingerprint/uTLS instead and leaves this empty.
+	EchConfigList string `json:"echConfigList,omitempty"`
 }
 
 type xrayTLSStream struct {
@@ -65,10 +72,92 @@ type xrayWSStream struct {
 	Path string `json:"path"`
 }
 
+// xrayGRPCStream is xray-core's gRPC streamSettings. gRPC-over-TLS is
+// markedly harder for DPI to tell apart from legitimate gRPC API traffic
+// than raw WebSocket, so it's offered as a fallback transport below
+// REALITY for networks that block the latter.
+type xrayGRPCStream struct {
+	ServiceName         string `json:"serviceName"`
+	MultiMode           bool   `json:"multiMode,omitempty"`
+	IdleTimeout         int    `json:"idle_timeout,omitempty"`
+	HealthCheckTimeout  int    `json:"health_check_timeout,omitempty"`
+	PermitWithoutStream bool   `json:"permit_without_stream,omitempty"`
+	InitialWindowsSize  int    `json:"initial_windows_size,omitempty"`
+}
+
+// defaultGRPCServiceName is used when a gRPC fallback/transport doesn't
+// specify its own service name (fallbacks reuse FallbackConfig.Path for
+// this; the client has nowhere else to source one from).
+const defaultGRPCServiceName = "tun"
+
 // ---- Server JSON builder ----
 
 // BuildServerJSON produces xray-core compatible JSON for server mode.
 func BuildServerJSON(cfg *Config) ([]byte, error) {
+	xc, err := buildServerConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(xc)
+}
+
+// BuildServerJSONWithServe is BuildServerJSON plus sc's TCP forwards
+// folded into the same inbound/outbound graph, for Engine.SetServeConfig
+// to hand to Reload without disturbing the VLESS/Reality inbound. sc's
+// Web entries aren't xray-core's concern at all — see appendTCPForwards's
+// doc comment — so they're ignored here; Engine manages those itself.
+func BuildServerJSONWithServe(cfg *Config, sc *ServeConfig) ([]byte, error) {
+	xc, err := buildServerConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if sc != nil {
+		if err := appendTCPForwards(xc, sc); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(xc)
+}
+
+// appendTCPForwards adds one dokodemo-door inbound per sc.TCP entry to
+// xc, each embedding its destination address/port directly in its
+// settings (xray-core's usual shape for a fixed-destination forward).
+// It's routed out through the existing shared "direct" freedom outbound
+// rather than a dedicated one per forward: freedom just relays wherever
+// dokodemo-door already decided to dial, so a second outbound would do
+// nothing the shared one doesn't.
+func appendTCPForwards(xc *xrayFullConfig, sc *ServeConfig) error {
+	for port, dest := range sc.TCP {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return fmt.Errorf("invalid tcp port %q: %w", port, err)
+		}
+		destHost, destPort, err := splitHostPort(dest)
+		if err != nil {
+			return fmt.Errorf("invalid tcp destination %q: %w", dest, err)
+		}
+
+		settings, _ := json.Marshal(map[string]any{
+			"address": destHost,
+			"port":    destPort,
+			"network": "tcp",
+		})
+		xc.Inbounds = append(xc.Inbounds, xrayInbound{
+			Tag:      fmt.Sprintf("tcp-forward-%s", port),
+			Listen:   "0.0.0.0",
+			Port:     p,
+			Protocol: "dokodemo-door",
+			Settings: settings,
+		})
+	}
+	return nil
+}
+
+// buildServerConfig builds the xray-core config graph for server mode
+// without marshaling it, so callers that need to layer more onto the
+// graph (BuildServerJSONWithServe's TCP forwards) can do so before the
+// final json.Marshal.
+func buildServerConfig(cfg *Config) (*xrayFullConfig, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("config is nil")
 	}
@@ -139,18 +228,26 @@ func BuildServerJSON(cfg *Config) ([]byte, error) {
 			Settings: fbSettings,
 		}
 
-		if fb.Transport == "ws" {
+		switch fb.Transport {
+		case "ws":
 			inbound.Stream = &xrayStream{
 				Network:  "ws",
 				Security: "tls",
 				WS:       &xrayWSStream{Path: fb.Path},
 			}
+		case "grpc":
+			serviceName := coalesce(fb.Path, defaultGRPCServiceName)
+			inbound.Stream = &xrayStream{
+				Network:  "grpc",
+				Security: "tls",
+				GRPC:     &xrayGRPCStream{ServiceName: serviceName},
+			}
 		}
 
 		xc.Inbounds = append(xc.Inbounds, inbound)
 	}
 
-	return json.Marshal(xc)
+	return xc, nil
 }
 
 // ---- Client JSON builder ----
@@ -188,11 +285,24 @@ func BuildClientJSON(cfg *ClientConfig) ([]byte, error) {
 		},
 	})
 
+	// "rotate" is resolved per-dial by Engine (see RollingFingerprint in
+	// internal/camouflage) before it ever reaches here; a caller that
+	// builds JSON directly without going through Engine gets the same
+	// safe default as an unset fingerprint rather than an invalid
+	// "rotate" string landing in the xray-core config.
 	fingerprint := cfg.Fingerprint
-	if fingerprint == "" {
+	if fingerprint == "" || fingerprint == "rotate" {
 		fingerprint = "chrome"
 	}
 
+	// Real ECH (ech_mode: full) replaces GREASE once a config list is
+	// present; resolveClientECH is responsible for populating
+	// cfg.ECHConfigList before this function ever sees it.
+	var echConfigList string
+	if camouflage.ECHMode(cfg.ECHMode) == camouflage.ECHModeFull {
+		echConfigList = cfg.ECHConfigList
+	}
+
 	xc := &xrayFullConfig{
 		Log: &xrayLog{LogLevel: coalesce(cfg.LogLevel, "info")},
 		Inbounds: []xrayInbound{
@@ -210,15 +320,17 @@ func BuildClientJSON(cfg *ClientConfig) ([]byte, error) {
 				Protocol: "vless",
 				Settings: vlessOutSettings,
 				Stream: &xrayStream{
-					Network:  "tcp",
+					Network:  coalesce(networkForTransport(cfg.Transport), "tcp"),
 					Security: "reality",
 					Reality: &xrayRealityStream{
-						Show:        false,
-						ServerName:  cfg.SNI,
-						Fingerprint: fingerprint,
-						PublicKey:   cfg.PublicKey,
-						ShortID:     cfg.ShortID,
+						Show:          false,
+						ServerName:    cfg.SNI,
+						Fingerprint:   fingerprint,
+						PublicKey:     cfg.PublicKey,
+						ShortID:       cfg.ShortID,
+						EchConfigList: echConfigList,
 					},
+					GRPC: grpcSettingsForTransport(cfg.Transport),
 				},
 			},
 			{Tag: "direct", Protocol: "freedom"},
@@ -289,6 +401,24 @@ func parseServerAddr(addr string) (string, int) {
 	return host, port
 }
 
+// networkForTransport maps a ClientConfig.Transport value to the
+// xray-core streamSettings network it needs, or "" for the default (tcp).
+func networkForTransport(transport string) string {
+	if transport == "grpc" {
+		return "grpc"
+	}
+	return ""
+}
+
+// grpcSettingsForTransport returns the gRPC streamSettings for transport,
+// or nil if transport isn't "grpc".
+func grpcSettingsForTransport(transport string) *xrayGRPCStream {
+	if transport != "grpc" {
+		return nil
+	}
+	return &xrayGRPCStream{ServiceName: defaultGRPCServiceName}
+}
+
 func coalesce(values ...string) string {
 	for _, v := range values {
 		if v != "" {
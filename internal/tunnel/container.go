@@ -0,0 +1,228 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fabiano/entropy-tunnel/internal/payment"
+	"github.com/fabiano/entropy-tunnel/internal/protocols"
+	"github.com/fabiano/entropy-tunnel/internal/rotation"
+)
+
+// InitOptions configures Init. Every field is optional: Init builds a
+// sensible default for anything left nil, which is what every one of the
+// cmd/entropy-* binaries previously did by hand. Tests and embedders can
+// override individual dependencies (e.g. a fake rotation.Controller)
+// without touching the rest of the container.
+type InitOptions struct {
+	// ConfigPath loads server config from a YAML file, same as
+	// tunnel.LoadConfig. Ignored if Config is set.
+	ConfigPath string
+
+	// Config supplies an already-resolved in-memory config, bypassing
+	// file loading entirely. Takes priority over ConfigPath.
+	Config *Config
+
+	// Logger overrides the default production zap.Logger.
+	Logger *zap.Logger
+
+	// Rotation overrides the controller Init would otherwise build from
+	// Config.Rotation.
+	Rotation rotation.Controller
+
+	// Payment overrides the BTCPay client Init would otherwise build
+	// from Config.Payment.
+	Payment *payment.BTCPayClient
+
+	// Protocols overrides the protocol registry Init would otherwise
+	// build.
+	Protocols *protocols.Registry
+
+	// AllowVulnerable overrides the engine's vulnerability preflight,
+	// letting Start run even if CheckVulnerabilities reports a critical
+	// CVE in the linked xray-core version.
+	AllowVulnerable bool
+}
+
+// Container is the resolved set of dependencies a tunnel binary needs to
+// run: config, logger, the xray-core engine, and the rotation/payment/
+// protocols subsystems, all wired to the same bootstrap event bus. It
+// replaces the manual logger/config/engine construction that used to be
+// duplicated across cmd/entropy-server, cmd/entropy-client, and the GUI
+// bridge.
+type Container struct {
+	Config    *Config
+	Logger    *zap.Logger
+	Engine    *Engine
+	Rotation  rotation.Controller
+	Payment   *payment.BTCPayClient
+	Protocols *protocols.Registry
+}
+
+// Init resolves opts into a ready-to-run Container: it loads config (from
+// opts.Config, opts.ConfigPath, or ENTROPY_*-prefixed environment
+// variables, in that priority order), validates cross-package invariants,
+// and constructs the engine plus rotation/payment/protocols subsystems,
+// wiring them all to the engine's bootstrap event bus.
+func Init(opts InitOptions) (*Container, error) {
+	logger := opts.Logger
+	if logger == nil {
+		var err error
+		logger, err = zap.NewProduction()
+		if err != nil {
+			return nil, fmt.Errorf("create logger: %w", err)
+		}
+	}
+
+	cfg := opts.Config
+	if cfg == nil {
+		if opts.ConfigPath == "" {
+			return nil, fmt.Errorf("tunnel.Init: either Config or ConfigPath must be set")
+		}
+		var err error
+		cfg, err = LoadConfig(opts.ConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("load config: %w", err)
+		}
+	}
+	applyEnvOverrides(cfg)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	engine, err := NewEngine(cfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("create engine: %w", err)
+	}
+	engine.SetAllowVulnerable(opts.AllowVulnerable)
+	bus := engine.EventBus()
+
+	rotCtl := opts.Rotation
+	if rotCtl == nil {
+		var err error
+		rotCtl, err = buildRotationController(context.Background(), cfg.Rotation, logger)
+		if err != nil {
+			return nil, fmt.Errorf("build rotation controller: %w", err)
+		}
+	}
+
+	btcpay := opts.Payment
+	if btcpay == nil && cfg.Payment.Enabled {
+		btcpay = payment.NewBTCPayClient(cfg.Payment.BTCPayURL, cfg.Payment.BTCPayKey, cfg.Payment.StoreID)
+	}
+
+	protoReg := opts.Protocols
+	if protoReg == nil {
+		protoReg = protocols.NewRegistry()
+		protoReg.SetEventBus(bus)
+	}
+
+	return &Container{
+		Config:    cfg,
+		Logger:    logger,
+		Engine:    engine,
+		Rotation:  rotCtl,
+		Payment:   btcpay,
+		Protocols: protoReg,
+	}, nil
+}
+
+// Start brings the engine up and, if configured, kicks off rotation's
+// auto-rotation loop.
+func (c *Container) Start(ctx context.Context) error {
+	if err := c.Engine.Start(); err != nil {
+		return err
+	}
+
+	if c.Config.Rotation.Enabled {
+		interval, err := time.ParseDuration(c.Config.Rotation.Interval)
+		if err != nil {
+			interval = 30 * time.Minute
+		}
+		if err := c.Rotation.StartAutoRotation(ctx, interval); err != nil {
+			return fmt.Errorf("start auto-rotation: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Stop halts auto-rotation (if it was started) and shuts down the engine.
+func (c *Container) Stop(ctx context.Context) error {
+	if c.Config.Rotation.Enabled {
+		c.Rotation.StopAutoRotation()
+	}
+	return c.Engine.Stop()
+}
+
+// Rotate provisions a new endpoint through the rotation controller and, if
+// it changes where the engine listens, reloads it into the running engine
+// so existing connections aren't just dropped mid-rotation.
+func (c *Container) Rotate(ctx context.Context) (*rotation.Endpoint, error) {
+	ep, err := c.Rotation.Rotate(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rotate endpoint: %w", err)
+	}
+
+	if ep.Address != "" && ep.Address != c.Config.Listen {
+		next := *c.Config
+		next.Listen = ep.Address
+		if err := c.Engine.Reload(&next); err != nil {
+			return ep, fmt.Errorf("reload engine with rotated endpoint: %w", err)
+		}
+		c.Config = &next
+	}
+
+	return ep, nil
+}
+
+// buildRotationController picks the rotation backend named by rc.Provider,
+// defaulting to the no-op controller when rotation is disabled or the
+// provider is unrecognized.
+func buildRotationController(ctx context.Context, rc RotationConfig, logger *zap.Logger) (rotation.Controller, error) {
+	switch rc.Provider {
+	case "cloudflare":
+		ctrl := rotation.NewCloudflareController(rc.CFAPIToken, rc.CFAccountID, rc.CFZoneID, logger)
+		verifyCtx, cancel := context.WithTimeout(ctx, tokenVerifyTimeout)
+		defer cancel()
+		if err := ctrl.VerifyTokenActive(verifyCtx); err != nil {
+			return nil, fmt.Errorf("verify cloudflare api token: %w", err)
+		}
+		return ctrl, nil
+	case "aws":
+		return rotation.NewAWSController(rc.AWSRegion, rc.AWSKey, rc.AWSSecret, logger), nil
+	default:
+		return rotation.NewNoOpController(logger), nil
+	}
+}
+
+// tokenVerifyTimeout bounds buildRotationController's startup token check,
+// matching how resolveClientECH bounds its own startup HTTP fetch.
+const tokenVerifyTimeout = 10 * time.Second
+
+// applyEnvOverrides lets a small set of ENTROPY_*-prefixed environment
+// variables override file-loaded config values, so a Container can be
+// deployed from env vars alone (e.g. in a container image) without a
+// config file on disk at all.
+func applyEnvOverrides(cfg *Config) {
+	if v := os.Getenv("ENTROPY_LISTEN"); v != "" {
+		cfg.Listen = v
+	}
+	if v := os.Getenv("ENTROPY_UUID"); v != "" {
+		cfg.UUID = v
+	}
+	if v := os.Getenv("ENTROPY_SNI"); v != "" {
+		cfg.Reality.SNI = v
+	}
+	if v := os.Getenv("ENTROPY_CF_API_TOKEN"); v != "" {
+		cfg.Rotation.CFAPIToken = v
+	}
+	if v := os.Getenv("ENTROPY_BTCPAY_API_KEY"); v != "" {
+		cfg.Payment.BTCPayKey = v
+	}
+}
@@ -0,0 +1,137 @@
+package tunnel
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/fabiano/entropy-tunnel/internal/rotation"
+)
+
+// DebugHandler returns an http.Handler exposing engine and rotation
+// internals for operators, following the debug-endpoint pattern Istio's
+// pilot uses: config_dump, health, endpoints, and rotation history
+// alongside the standard net/http/pprof profiles, all under /debug/.
+// rotationCtrl and health may be nil (e.g. a client-mode engine that
+// doesn't own a rotation subsystem), in which case the routes that need
+// them report 503 instead of panicking. Callers decide whether and how to
+// gate access (see api.Server's debug-token flag).
+func (e *Engine) DebugHandler(rotationCtrl rotation.Controller, health *rotation.HealthChecker) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /debug/config_dump", e.handleDebugConfigDump(rotationCtrl))
+	mux.HandleFunc("GET /debug/health", e.handleDebugHealth(health))
+	mux.HandleFunc("GET /debug/endpoints", e.handleDebugEndpoints(rotationCtrl))
+	mux.HandleFunc("GET /debug/rotation/history", e.handleDebugRotationHistory)
+	mux.HandleFunc("POST /debug/probe/{id}", e.handleDebugProbe(health))
+	mux.HandleFunc("GET /debug/vulnerabilities", e.handleDebugVulnerabilities)
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return mux
+}
+
+func (e *Engine) handleDebugConfigDump(ctrl rotation.Controller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := map[string]any{
+			"config": e.ConfigPretty(),
+		}
+		if ctrl != nil {
+			resp["endpoints"] = ctrl.ActiveEndpoints()
+		}
+		writeJSON(w, resp)
+	}
+}
+
+func (e *Engine) handleDebugHealth(health *rotation.HealthChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if health == nil {
+			writeError(w, http.StatusServiceUnavailable, "no health checker wired to this engine")
+			return
+		}
+		writeJSON(w, health.Results())
+	}
+}
+
+// handleDebugEndpoints reports active endpoints from ctrl and, best
+// effort, recently retired ones by scanning the bootstrap event bus's
+// rotation history for endpoints that were switched away from and aren't
+// active anymore. There's no generic "retired" query on rotation.Controller
+// itself, so this is necessarily a reconstruction rather than a ground
+// truth.
+func (e *Engine) handleDebugEndpoints(ctrl rotation.Controller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ctrl == nil {
+			writeError(w, http.StatusServiceUnavailable, "no rotation controller wired to this engine")
+			return
+		}
+
+		active := ctrl.ActiveEndpoints()
+		activeIDs := make(map[string]bool, len(active))
+		for _, ep := range active {
+			activeIDs[ep.ID] = true
+		}
+
+		var retired []string
+		seen := make(map[string]bool)
+		for _, ev := range e.EventBus().History("rotation") {
+			data, ok := ev.Data.(RotationSwitchedData)
+			if !ok || activeIDs[data.OldID] || seen[data.OldID] {
+				continue
+			}
+			retired = append(retired, data.OldID)
+			seen[data.OldID] = true
+		}
+
+		writeJSON(w, map[string]any{
+			"active":  active,
+			"retired": retired,
+		})
+	}
+}
+
+func (e *Engine) handleDebugRotationHistory(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, e.EventBus().History("rotation"))
+}
+
+func (e *Engine) handleDebugProbe(health *rotation.HealthChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if health == nil {
+			writeError(w, http.StatusServiceUnavailable, "no health checker wired to this engine")
+			return
+		}
+
+		result, err := health.ProbeNow(r.Context(), r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeJSON(w, result)
+	}
+}
+
+// handleDebugVulnerabilities reports the vulnerability preflight from the
+// engine's last Start call, or 503 if Start hasn't run yet.
+func (e *Engine) handleDebugVulnerabilities(w http.ResponseWriter, r *http.Request) {
+	report := e.VulnerabilityReport()
+	if report == nil {
+		writeError(w, http.StatusServiceUnavailable, "no vulnerability report yet; engine hasn't started")
+		return
+	}
+	writeJSON(w, report)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
@@ -2,13 +2,33 @@ package tunnel
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
+
+	"github.com/fabiano/entropy-tunnel/internal/camouflage"
 )
 
+// defaultDrainWindow is how long Reload keeps a superseded XrayInstance
+// alive (serving already-established connections) after starting its
+// replacement, for loaders that can't hot-reload in place. Tunable per
+// engine via SetDrainWindow.
+const defaultDrainWindow = 10 * time.Second
+
+// shadowPortOffset is added to every inbound port when Reload needs to
+// start a replacement instance alongside one that's still bound to the
+// real ports, so the two don't collide while the old one drains.
+const shadowPortOffset = 10000
+
+// echFetchTimeout bounds resolveClientECH's DoH lookup when a client is
+// configured with ech_mode: full and ech_public_name but no
+// ech_config_list, so a slow or unreachable resolver can't hang Start.
+const echFetchTimeout = 10 * time.Second
+
 // EngineStatus represents the current state of the tunnel engine.
 type EngineStatus string
 
@@ -41,9 +61,30 @@ type XrayInstance interface {
 // The default stub (xray_stub.go) returns a no-op instance.
 type XrayLoader func(jsonCfg []byte) (XrayInstance, error)
 
+// HotReloader is an optional XrayInstance capability: loaders that can
+// swap routing/outbound state in place, without rebinding their inbound
+// listeners, implement it so Engine.Reload can update them directly
+// instead of falling back to the shadow-port drain-and-swap path.
+type HotReloader interface {
+	Reload(jsonCfg []byte) error
+}
+
+// EventBusSetter is implemented by XrayInstances that want to publish
+// lifecycle events (e.g. the exec-tagged loader's crash/restart reports)
+// onto the engine's bootstrap event bus. Engine.Start wires it in when
+// present; the stub and xray-core-backed instances don't implement it.
+type EventBusSetter interface {
+	SetEventBus(bus *BootstrapEventBus)
+}
+
 // defaultLoader is set by init() in the appropriate build-tag file.
 var defaultLoader XrayLoader
 
+// xrayVersionFunc resolves the version of xray-core Start's vulnerability
+// preflight checks against. The stub build can't know a real version;
+// xray_real.go overrides it with core.Version.
+var xrayVersionFunc = func() string { return unknownXrayVersion }
+
 func init() {
 	if defaultLoader == nil {
 		// Fallback: stub loader so the binary always compiles.
@@ -63,6 +104,82 @@ type Engine struct {
 	stopCh       chan struct{}
 	loader       XrayLoader
 	jsonConfig   []byte // cached generated config
+	eventBus     *BootstrapEventBus
+	drainWindow  time.Duration
+
+	// rollingFP rotates the uTLS ClientHelloID per dial when clientConfig.
+	// Fingerprint == "rotate". nil for server-mode engines and for
+	// client-mode engines using a static fingerprint.
+	rollingFP *camouflage.RollingFingerprint
+
+	allowVulnerable bool
+	vulnReport      *VulnerabilityReport
+
+	// serveConfig and webServers back SetServeConfig (see serve.go):
+	// serveConfig is the last config applied; webServers holds the
+	// running net/http servers for its Web entries, keyed by hostport.
+	serveConfig *ServeConfig
+	webServers  map[string]*webServer
+}
+
+// SetAllowVulnerable overrides Start's vulnerability preflight, letting an
+// operator run a build with a known-exploitable xray-core version anyway
+// (e.g. while waiting on a vendor patch). Default false: Start refuses
+// with StatusError when CheckVulnerabilities reports a critical finding.
+func (e *Engine) SetAllowVulnerable(allow bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.allowVulnerable = allow
+}
+
+// VulnerabilityReport returns the result of the vulnerability preflight
+// from the last call to Start, or nil if Start hasn't run yet.
+func (e *Engine) VulnerabilityReport() *VulnerabilityReport {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.vulnReport
+}
+
+// SetDrainWindow overrides how long Reload keeps a superseded XrayInstance
+// alive during the shadow-port fallback path (default 10s).
+func (e *Engine) SetDrainWindow(d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.drainWindow = d
+}
+
+// ClientConfig returns the engine's current client-mode config, or nil
+// for a server-mode engine.
+func (e *Engine) ClientConfig() *ClientConfig {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.clientConfig
+}
+
+// Config returns the engine's current server-mode config, or nil for a
+// client-mode engine.
+func (e *Engine) Config() *Config {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.config
+}
+
+// EventBus returns the engine's bootstrap-progress event bus, creating one
+// on first use. Subsystems (protocols, rotation) publish to it; the local
+// API exposes it over SSE for GUI consumption.
+func (e *Engine) EventBus() *BootstrapEventBus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.eventBusLocked()
+}
+
+// eventBusLocked returns the event bus, creating it if needed. Callers
+// must already hold e.mu.
+func (e *Engine) eventBusLocked() *BootstrapEventBus {
+	if e.eventBus == nil {
+		e.eventBus = NewBootstrapEventBus()
+	}
+	return e.eventBus
 }
 
 // NewEngine creates a new server-mode tunnel engine.
@@ -77,12 +194,13 @@ func NewEngine(cfg *Config, logger *zap.Logger) (*Engine, error) {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 	return &Engine{
-		config: cfg,
-		mode:   ModeServer,
-		logger: logger,
-		status: StatusStopped,
-		stopCh: make(chan struct{}),
-		loader: defaultLoader,
+		config:      cfg,
+		mode:        ModeServer,
+		logger:      logger,
+		status:      StatusStopped,
+		stopCh:      make(chan struct{}),
+		loader:      defaultLoader,
+		drainWindow: defaultDrainWindow,
 	}, nil
 }
 
@@ -97,6 +215,12 @@ func NewClientEngine(cfg *ClientConfig, logger *zap.Logger) (*Engine, error) {
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid client config: %w", err)
 	}
+
+	var rollingFP *camouflage.RollingFingerprint
+	if cfg.Fingerprint == "rotate" {
+		rollingFP = camouflage.NewRollingFingerprint(logger)
+	}
+
 	return &Engine{
 		clientConfig: cfg,
 		mode:         ModeClient,
@@ -104,9 +228,55 @@ func NewClientEngine(cfg *ClientConfig, logger *zap.Logger) (*Engine, error) {
 		status:       StatusStopped,
 		stopCh:       make(chan struct{}),
 		loader:       defaultLoader,
+		drainWindow:  defaultDrainWindow,
+		rollingFP:    rollingFP,
 	}, nil
 }
 
+// RollingFingerprint returns the engine's per-dial uTLS fingerprint
+// rotator, or nil unless this is a client-mode engine configured with
+// Fingerprint: "rotate".
+func (e *Engine) RollingFingerprint() *camouflage.RollingFingerprint {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.rollingFP
+}
+
+// resolveClientFingerprint substitutes the concrete uTLS fingerprint name
+// e.rollingFP.Next() picks when cfg.Fingerprint is "rotate", otherwise
+// returns cfg unchanged.
+func (e *Engine) resolveClientFingerprint(cfg *ClientConfig) *ClientConfig {
+	if e.rollingFP == nil || cfg.Fingerprint != "rotate" {
+		return cfg
+	}
+	resolved := *cfg
+	resolved.Fingerprint = e.rollingFP.Next()
+	return &resolved
+}
+
+// resolveClientECH fetches cfg.ECHConfigList via FetchECHConfigFromHTTPS
+// when the client is configured for ech_mode: full with an
+// ech_public_name but no config list pasted in directly, so operators can
+// point at a front like cloudflare-ech.com without hand-fetching its ECH
+// config. Otherwise cfg is returned unchanged.
+func (e *Engine) resolveClientECH(cfg *ClientConfig) (*ClientConfig, error) {
+	if camouflage.ECHMode(cfg.ECHMode) != camouflage.ECHModeFull || cfg.ECHConfigList != "" {
+		return cfg, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), echFetchTimeout)
+	defer cancel()
+
+	configList, err := camouflage.FetchECHConfigFromHTTPS(ctx, cfg.ECHPublicName)
+	if err != nil {
+		return nil, fmt.Errorf("fetching ECH config for %s: %w", cfg.ECHPublicName, err)
+	}
+
+	resolved := *cfg
+	resolved.ECHConfigList = configList
+	return &resolved, nil
+}
+
 // Start boots the tunnel engine by building and loading the xray-core config.
 func (e *Engine) Start() error {
 	e.mu.Lock()
@@ -118,11 +288,22 @@ func (e *Engine) Start() error {
 
 	e.status = StatusStarting
 
+	report, err := CheckVulnerabilities(xrayVersionFunc())
+	if err != nil {
+		e.status = StatusError
+		return fmt.Errorf("vulnerability preflight: %w", err)
+	}
+	e.vulnReport = report
+	if report.HasCritical() && !e.allowVulnerable {
+		e.status = StatusError
+		return fmt.Errorf("refusing to start: %s (pass --allow-vulnerable to override)", report.Summary())
+	}
+	if len(report.Findings) > 0 {
+		e.logger.Warn("vulnerability preflight found issues", zap.String("report", report.String()))
+	}
+
 	// Build JSON config for xray-core
-	var (
-		jsonCfg []byte
-		err     error
-	)
+	var jsonCfg []byte
 	switch e.mode {
 	case ModeServer:
 		e.logger.Info("building server config",
@@ -132,12 +313,19 @@ func (e *Engine) Start() error {
 		)
 		jsonCfg, err = BuildServerJSON(e.config)
 	case ModeClient:
+		clientCfg := e.resolveClientFingerprint(e.clientConfig)
+		clientCfg, err = e.resolveClientECH(clientCfg)
+		if err != nil {
+			e.status = StatusError
+			return fmt.Errorf("resolving ECH config: %w", err)
+		}
 		e.logger.Info("building client config",
-			zap.String("server", e.clientConfig.Server),
-			zap.String("sni", e.clientConfig.SNI),
-			zap.String("fingerprint", e.clientConfig.Fingerprint),
+			zap.String("server", clientCfg.Server),
+			zap.String("sni", clientCfg.SNI),
+			zap.String("fingerprint", clientCfg.Fingerprint),
+			zap.String("ech_mode", clientCfg.ECHMode),
 		)
-		jsonCfg, err = BuildClientJSON(e.clientConfig)
+		jsonCfg, err = BuildClientJSON(clientCfg)
 	default:
 		e.status = StatusError
 		return fmt.Errorf("unknown engine mode: %s", e.mode)
@@ -159,6 +347,8 @@ func (e *Engine) Start() error {
 		return fmt.Errorf("failed to load xray config: %w", err)
 	}
 
+	e.wireInstance(instance)
+
 	// Start xray-core instance
 	if err := instance.Start(); err != nil {
 		e.status = StatusError
@@ -168,9 +358,157 @@ func (e *Engine) Start() error {
 	e.instance = instance
 	e.status = StatusRunning
 	e.logger.Info("entropy tunnel engine is running", zap.String("mode", string(e.mode)))
+
+	if e.mode == ModeClient {
+		e.eventBusLocked().Publish("reality", EventRealityHandshakeOK, nil)
+	}
+
+	return nil
+}
+
+// wireInstance sets up cross-cutting hooks on a freshly loaded instance
+// before Start (or Reload) brings it up. Called with e.mu held.
+func (e *Engine) wireInstance(instance XrayInstance) {
+	if setter, ok := instance.(EventBusSetter); ok {
+		setter.SetEventBus(e.eventBusLocked())
+	}
+}
+
+// Reload regenerates the xray-core config from newCfg and applies it to a
+// running server-mode engine. If the loader's instance implements
+// HotReloader, the new config is handed to it in place and the inbound
+// listeners never move. Otherwise Reload falls back to the drain-and-swap
+// path: see reload for details.
+func (e *Engine) Reload(newCfg *Config) error {
+	if e.mode != ModeServer {
+		return fmt.Errorf("Reload is only valid for a server-mode engine")
+	}
+	if newCfg == nil {
+		return fmt.Errorf("config must not be nil")
+	}
+	if err := newCfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	jsonCfg, err := BuildServerJSON(newCfg)
+	if err != nil {
+		return fmt.Errorf("failed to build xray config: %w", err)
+	}
+	return e.reload(jsonCfg, func() { e.config = newCfg })
+}
+
+// ReloadClient is Reload's client-mode counterpart: it regenerates the
+// xray-core config from newCfg (e.g. after rotation.Controller.Rotate
+// hands back a new server endpoint) and applies it without dropping the
+// user's SOCKS5/HTTP session where the loader allows it.
+func (e *Engine) ReloadClient(newCfg *ClientConfig) error {
+	if e.mode != ModeClient {
+		return fmt.Errorf("ReloadClient is only valid for a client-mode engine")
+	}
+	if newCfg == nil {
+		return fmt.Errorf("client config must not be nil")
+	}
+	if err := newCfg.Validate(); err != nil {
+		return fmt.Errorf("invalid client config: %w", err)
+	}
+	jsonCfg, err := BuildClientJSON(e.resolveClientFingerprint(newCfg))
+	if err != nil {
+		return fmt.Errorf("failed to build xray config: %w", err)
+	}
+	return e.reload(jsonCfg, func() { e.clientConfig = newCfg })
+}
+
+// reload applies jsonCfg to the running instance and, on success, commits
+// the new config via applyCfg.
+//
+// XrayInstance only exposes Start/Close, so the engine has no way to hand
+// a listening socket from one instance to another; the drain-and-swap
+// fallback below can't literally keep the old listener accepting while
+// the new one comes up on the same port. What it can do: dry-run jsonCfg
+// on shadow ports first, so a bad config is caught before the working
+// instance is touched at all, then give the old instance up to
+// e.drainWindow to close gracefully (e.g. the exec loader's SIGTERM/
+// SIGKILL grace period, letting in-flight streams finish) before the
+// replacement binds the real ports. Loaders that implement HotReloader
+// avoid this gap entirely by swapping routing/outbound state without
+// ever closing their listeners.
+func (e *Engine) reload(jsonCfg []byte, applyCfg func()) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.status != StatusRunning {
+		return fmt.Errorf("engine is not running (status: %s)", e.status)
+	}
+
+	if hr, ok := e.instance.(HotReloader); ok {
+		if err := hr.Reload(jsonCfg); err != nil {
+			return fmt.Errorf("hot reload xray config: %w", err)
+		}
+		e.jsonConfig = jsonCfg
+		applyCfg()
+		e.logger.Info("reloaded xray config in place")
+		return nil
+	}
+
+	shadowCfg, err := shiftInboundPorts(jsonCfg, shadowPortOffset)
+	if err != nil {
+		return fmt.Errorf("build shadow-port config: %w", err)
+	}
+	shadow, err := e.loader(shadowCfg)
+	if err != nil {
+		return fmt.Errorf("load replacement config: %w", err)
+	}
+	if err := shadow.Start(); err != nil {
+		return fmt.Errorf("replacement config failed to start on shadow ports: %w", err)
+	}
+	_ = shadow.Close() // shadow instance only proves jsonCfg boots; real ports come next
+
+	old := e.instance
+	drained := make(chan struct{})
+	go func() {
+		if err := old.Close(); err != nil {
+			e.logger.Error("error closing superseded xray instance", zap.Error(err))
+		}
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(e.drainWindow):
+		e.logger.Warn("superseded xray instance did not close within drain window",
+			zap.Duration("drain_window", e.drainWindow))
+	}
+
+	next, err := e.loader(jsonCfg)
+	if err != nil {
+		e.status = StatusError
+		return fmt.Errorf("load reloaded xray config: %w", err)
+	}
+	e.wireInstance(next)
+	if err := next.Start(); err != nil {
+		e.status = StatusError
+		return fmt.Errorf("start reloaded xray instance: %w", err)
+	}
+
+	e.instance = next
+	e.jsonConfig = jsonCfg
+	applyCfg()
+	e.logger.Info("reloaded xray config via drain-and-swap")
 	return nil
 }
 
+// shiftInboundPorts returns a copy of jsonCfg with every inbound's port
+// shifted by offset, used to boot a replacement instance on disjoint
+// "shadow" ports during the drain-and-swap reload path.
+func shiftInboundPorts(jsonCfg []byte, offset int) ([]byte, error) {
+	var xc xrayFullConfig
+	if err := json.Unmarshal(jsonCfg, &xc); err != nil {
+		return nil, fmt.Errorf("parse xray config: %w", err)
+	}
+	for i := range xc.Inbounds {
+		xc.Inbounds[i].Port += offset
+	}
+	return json.Marshal(&xc)
+}
+
 // Stop gracefully shuts down the tunnel engine.
 func (e *Engine) Stop() error {
 	e.mu.Lock()
@@ -191,6 +529,8 @@ func (e *Engine) Stop() error {
 		}
 	}
 
+	e.closeWebServers()
+
 	e.instance = nil
 	e.status = StatusStopped
 	e.stopCh = make(chan struct{})
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/fabiano/entropy-tunnel/internal/camouflage"
 )
 
 // ---- Config Validation Tests ----
@@ -43,6 +45,30 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "tutorial placeholder UUID",
+			config: Config{
+				Listen: ":443", UUID: "your-uuid-here",
+				Reality: RealityConfig{SNI: "g.com", PrivateKey: "k"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "all-zeros UUID",
+			config: Config{
+				Listen: ":443", UUID: "00000000-0000-0000-0000-000000000000",
+				Reality: RealityConfig{SNI: "g.com", PrivateKey: "k"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "sequential private key",
+			config: Config{
+				Listen: ":443", UUID: "x",
+				Reality: RealityConfig{SNI: "g.com", PrivateKey: "abcdefghijklmnop"},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -70,6 +96,21 @@ func TestClientConfigValidate(t *testing.T) {
 		{name: "missing uuid", config: ClientConfig{Server: "x", SNI: "g.com", PublicKey: "pk"}, wantErr: true},
 		{name: "missing sni", config: ClientConfig{Server: "x", UUID: "u", PublicKey: "pk"}, wantErr: true},
 		{name: "missing pubkey", config: ClientConfig{Server: "x", UUID: "u", SNI: "g.com"}, wantErr: true},
+		{
+			name:    "ech full without config list or public name",
+			config:  ClientConfig{Server: "x", UUID: "u", SNI: "g.com", PublicKey: "pk", ECHMode: "full"},
+			wantErr: true,
+		},
+		{
+			name:    "ech full with public name",
+			config:  ClientConfig{Server: "x", UUID: "u", SNI: "g.com", PublicKey: "pk", ECHMode: "full", ECHPublicName: "cloudflare-ech.com"},
+			wantErr: false,
+		},
+		{
+			name:    "ech unsupported mode",
+			config:  ClientConfig{Server: "x", UUID: "u", SNI: "g.com", PublicKey: "pk", ECHMode: "bogus"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -162,6 +203,58 @@ func TestBuildClientJSON(t *testing.T) {
 	}
 }
 
+func TestBuildClientJSON_ECHFull(t *testing.T) {
+	cfg := &ClientConfig{
+		Server:        "1.2.3.4:443",
+		UUID:          "test-uuid",
+		SNI:           "www.google.com",
+		PublicKey:     "test-pubkey",
+		LocalListen:   "127.0.0.1:1080",
+		ECHMode:       "full",
+		ECHConfigList: "base64echconfig",
+	}
+
+	jsonBytes, err := BuildClientJSON(cfg)
+	if err != nil {
+		t.Fatalf("BuildClientJSON() error = %v", err)
+	}
+
+	var parsed xrayFullConfig
+	if err := json.Unmarshal(jsonBytes, &parsed); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+
+	if got := parsed.Outbounds[0].Stream.Reality.EchConfigList; got != "base64echconfig" {
+		t.Errorf("EchConfigList = %q, want %q", got, "base64echconfig")
+	}
+}
+
+func TestBuildClientJSON_ECHGreaseOmitsConfigList(t *testing.T) {
+	cfg := &ClientConfig{
+		Server:        "1.2.3.4:443",
+		UUID:          "test-uuid",
+		SNI:           "www.google.com",
+		PublicKey:     "test-pubkey",
+		LocalListen:   "127.0.0.1:1080",
+		ECHMode:       "grease",
+		ECHConfigList: "should-not-be-used",
+	}
+
+	jsonBytes, err := BuildClientJSON(cfg)
+	if err != nil {
+		t.Fatalf("BuildClientJSON() error = %v", err)
+	}
+
+	var parsed xrayFullConfig
+	if err := json.Unmarshal(jsonBytes, &parsed); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+
+	if got := parsed.Outbounds[0].Stream.Reality.EchConfigList; got != "" {
+		t.Errorf("EchConfigList = %q, want empty for ech_mode: grease", got)
+	}
+}
+
 func TestBuildClientJSON_WithHTTPListen(t *testing.T) {
 	cfg := &ClientConfig{
 		Server:      "1.2.3.4:443",
@@ -235,6 +328,66 @@ func TestNewClientEngine(t *testing.T) {
 	}
 }
 
+func TestNewClientEngine_RotateFingerprint(t *testing.T) {
+	cfg := &ClientConfig{
+		Server: "1.2.3.4:443", UUID: "u", SNI: "g.com", PublicKey: "pk",
+		Fingerprint: "rotate",
+	}
+
+	engine, err := NewClientEngine(cfg, nil)
+	if err != nil {
+		t.Fatalf("NewClientEngine() error = %v", err)
+	}
+	if engine.RollingFingerprint() == nil {
+		t.Fatal("expected RollingFingerprint() to be set for Fingerprint: \"rotate\"")
+	}
+
+	resolved := engine.resolveClientFingerprint(cfg)
+	if resolved.Fingerprint == "rotate" {
+		t.Error("resolveClientFingerprint() did not replace the \"rotate\" sentinel")
+	}
+	if _, ok := camouflage.SupportedFingerprints[resolved.Fingerprint]; !ok {
+		t.Errorf("resolveClientFingerprint() = %q, not a supported fingerprint", resolved.Fingerprint)
+	}
+}
+
+func TestResolveClientECH_PassthroughWhenConfigListSet(t *testing.T) {
+	cfg := &ClientConfig{
+		Server: "1.2.3.4:443", UUID: "u", SNI: "g.com", PublicKey: "pk",
+		ECHMode: "full", ECHConfigList: "already-set",
+	}
+
+	engine, err := NewClientEngine(cfg, nil)
+	if err != nil {
+		t.Fatalf("NewClientEngine() error = %v", err)
+	}
+
+	resolved, err := engine.resolveClientECH(cfg)
+	if err != nil {
+		t.Fatalf("resolveClientECH() error = %v", err)
+	}
+	if resolved.ECHConfigList != "already-set" {
+		t.Errorf("resolveClientECH() overwrote an already-set ECHConfigList: got %q", resolved.ECHConfigList)
+	}
+}
+
+func TestResolveClientECH_DisabledPassthrough(t *testing.T) {
+	cfg := &ClientConfig{Server: "1.2.3.4:443", UUID: "u", SNI: "g.com", PublicKey: "pk"}
+
+	engine, err := NewClientEngine(cfg, nil)
+	if err != nil {
+		t.Fatalf("NewClientEngine() error = %v", err)
+	}
+
+	resolved, err := engine.resolveClientECH(cfg)
+	if err != nil {
+		t.Fatalf("resolveClientECH() error = %v", err)
+	}
+	if resolved != cfg {
+		t.Error("resolveClientECH() should return cfg unchanged when ech_mode isn't \"full\"")
+	}
+}
+
 func TestNewEngineNilConfig(t *testing.T) {
 	_, err := NewEngine(nil, nil)
 	if err == nil {
@@ -329,6 +482,99 @@ func TestEngineJSONConfig(t *testing.T) {
 	}
 }
 
+func TestEngineReload(t *testing.T) {
+	cfg := &Config{
+		Listen: ":443", Protocol: "vless", UUID: "test-uuid",
+		Reality: RealityConfig{SNI: "www.google.com", PrivateKey: "key"},
+	}
+
+	engine, _ := NewEngine(cfg, nil)
+	if err := engine.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer engine.Stop()
+
+	next := *cfg
+	next.Reality.SNI = "www.bing.com"
+	if err := engine.Reload(&next); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	var parsed xrayFullConfig
+	if err := json.Unmarshal(engine.JSONConfig(), &parsed); err != nil {
+		t.Fatalf("invalid JSON config after reload: %v", err)
+	}
+	if parsed.Inbounds[0].Stream.Reality.ServerNames[0] != "www.bing.com" {
+		t.Errorf("expected reloaded SNI 'www.bing.com', got %q", parsed.Inbounds[0].Stream.Reality.ServerNames[0])
+	}
+}
+
+func TestEngineReload_NotRunning(t *testing.T) {
+	cfg := &Config{
+		Listen: ":443", Protocol: "vless", UUID: "test-uuid",
+		Reality: RealityConfig{SNI: "www.google.com", PrivateKey: "key"},
+	}
+	engine, _ := NewEngine(cfg, nil)
+
+	if err := engine.Reload(cfg); err == nil {
+		t.Error("expected error reloading a stopped engine")
+	}
+}
+
+func TestEngineReload_WrongMode(t *testing.T) {
+	cfg := &ClientConfig{Server: "1.2.3.4:443", UUID: "u", SNI: "g.com", PublicKey: "pk"}
+	engine, _ := NewClientEngine(cfg, nil)
+
+	if err := engine.Reload(&Config{}); err == nil {
+		t.Error("expected error calling Reload on a client-mode engine")
+	}
+}
+
+func TestEngineReloadClient(t *testing.T) {
+	cfg := &ClientConfig{
+		Server: "1.2.3.4:443", UUID: "u", SNI: "g.com", PublicKey: "pk",
+		LocalListen: "127.0.0.1:1080",
+	}
+	engine, _ := NewClientEngine(cfg, nil)
+	if err := engine.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer engine.Stop()
+
+	next := *cfg
+	next.Server = "5.6.7.8:443"
+	if err := engine.ReloadClient(&next); err != nil {
+		t.Fatalf("ReloadClient() error = %v", err)
+	}
+	if engine.ClientConfig().Server != "5.6.7.8:443" {
+		t.Errorf("expected updated server, got %q", engine.ClientConfig().Server)
+	}
+}
+
+func TestShiftInboundPorts(t *testing.T) {
+	cfg := &Config{
+		Listen: ":443", Protocol: "vless", UUID: "test-uuid",
+		Reality: RealityConfig{SNI: "www.google.com", PrivateKey: "key"},
+	}
+	jsonCfg, err := BuildServerJSON(cfg)
+	if err != nil {
+		t.Fatalf("BuildServerJSON() error = %v", err)
+	}
+
+	shifted, err := shiftInboundPorts(jsonCfg, shadowPortOffset)
+	if err != nil {
+		t.Fatalf("shiftInboundPorts() error = %v", err)
+	}
+
+	var parsed xrayFullConfig
+	if err := json.Unmarshal(shifted, &parsed); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if parsed.Inbounds[0].Port != 443+shadowPortOffset {
+		t.Errorf("expected port %d, got %d", 443+shadowPortOffset, parsed.Inbounds[0].Port)
+	}
+}
+
 // ---- Config Loader Tests ----
 
 func TestLoadConfig(t *testing.T) {
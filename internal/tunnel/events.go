@@ -0,0 +1,35 @@
+package tunnel
+
+import "github.com/fabiano/entropy-tunnel/internal/events"
+
+// BootstrapEvent and BootstrapEventBus are aliases onto the internal/events
+// package, which is where the bus actually lives so that rotation,
+// protocols, and payment can publish to it without importing tunnel (and
+// tunnel, in turn, can import them for Container without a cycle). The
+// aliases keep the original tunnel.BootstrapEventBus API working for
+// callers like Engine and the local API server.
+type BootstrapEvent = events.BootstrapEvent
+type BootstrapEventBus = events.Bus
+
+// NewBootstrapEventBus creates an empty event bus.
+func NewBootstrapEventBus() *BootstrapEventBus {
+	return events.NewBus()
+}
+
+// Known BootstrapEvent.Type values, re-exported from internal/events.
+const (
+	EventSnowflakeBrokerContacted = events.EventSnowflakeBrokerContacted
+	EventSnowflakePeerConnected   = events.EventSnowflakePeerConnected
+	EventRealityHandshakeOK       = events.EventRealityHandshakeOK
+	EventRotationSwitched         = events.EventRotationSwitched
+	EventFallbackActivated        = events.EventFallbackActivated
+	EventSubscriptionExpiring     = events.EventSubscriptionExpiring
+	EventXrayProcessCrashed       = events.EventXrayProcessCrashed
+)
+
+// Data payload aliases, re-exported from internal/events.
+type SnowflakePeerConnectedData = events.SnowflakePeerConnectedData
+type RotationSwitchedData = events.RotationSwitchedData
+type FallbackActivatedData = events.FallbackActivatedData
+type SubscriptionExpiringData = events.SubscriptionExpiringData
+type XrayProcessCrashedData = events.XrayProcessCrashedData
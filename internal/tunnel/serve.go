@@ -0,0 +1,148 @@
+package tunnel
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// switchableHandler lets applyWebServers swap a webServer's active
+// http.Handler in place when SetServeConfig changes that hostport's
+// Handlers, without closing and rebinding its net.Listener — the same
+// reason Engine.reload prefers HotReloader over a drain-and-swap when it
+// can. A request already being served by the old handler finishes
+// normally; only requests accepted afterward see the new one.
+type switchableHandler struct {
+	h atomic.Value // http.Handler
+}
+
+func newSwitchableHandler(h http.Handler) *switchableHandler {
+	s := &switchableHandler{}
+	s.set(h)
+	return s
+}
+
+func (s *switchableHandler) set(h http.Handler) { s.h.Store(&h) }
+
+func (s *switchableHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handler := s.h.Load().(*http.Handler)
+	(*handler).ServeHTTP(w, r)
+}
+
+// webServer is one ServeConfig.Web entry's running net/http server.
+type webServer struct {
+	ln      net.Listener
+	server  *http.Server
+	handler *switchableHandler
+}
+
+// ServeConfig returns the engine's current declarative serve config, or
+// nil if SetServeConfig has never been called.
+func (e *Engine) ServeConfig() *ServeConfig {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.serveConfig
+}
+
+// SetServeConfig atomically applies a declarative multi-handler serve
+// config to a running server-mode engine: sc.TCP forwards are compiled
+// into dokodemo-door inbounds and folded into the xray-core config via
+// the same Reload path Config changes use, so the VLESS/Reality inbound
+// and its existing sessions are untouched. sc.Web entries aren't
+// xray-core's concern — its "http" inbound protocol is a forward proxy,
+// not a reverse proxy capable of dispatching Proxy/Text/Path handlers by
+// path — so each is instead backed by a plain net/http server the engine
+// runs itself, with its handler swapped in place on later calls rather
+// than the listener being rebound.
+func (e *Engine) SetServeConfig(sc *ServeConfig) error {
+	if e.mode != ModeServer {
+		return fmt.Errorf("SetServeConfig is only valid for a server-mode engine")
+	}
+	if sc == nil {
+		sc = &ServeConfig{}
+	}
+	if err := sc.Validate(); err != nil {
+		return fmt.Errorf("invalid serve config: %w", err)
+	}
+
+	e.mu.Lock()
+	cfg := e.config
+	e.mu.Unlock()
+	if cfg == nil {
+		return fmt.Errorf("engine has no base config")
+	}
+
+	jsonCfg, err := BuildServerJSONWithServe(cfg, sc)
+	if err != nil {
+		return fmt.Errorf("failed to build xray config: %w", err)
+	}
+	if err := e.reload(jsonCfg, func() { e.serveConfig = sc }); err != nil {
+		return err
+	}
+
+	return e.applyWebServers(sc)
+}
+
+// applyWebServers reconciles e.webServers against sc.Web: hostports that
+// are new get a freshly bound net/http server, hostports whose Handlers
+// changed get their switchableHandler updated in place, and hostports no
+// longer present are closed.
+func (e *Engine) applyWebServers(sc *ServeConfig) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.webServers == nil {
+		e.webServers = make(map[string]*webServer)
+	}
+
+	for hostport := range e.webServers {
+		if _, ok := sc.Web[hostport]; !ok {
+			if err := e.webServers[hostport].server.Close(); err != nil {
+				e.logger.Warn("error closing removed serve web server", zap.String("hostport", hostport), zap.Error(err))
+			}
+			delete(e.webServers, hostport)
+		}
+	}
+
+	for hostport, wsc := range sc.Web {
+		mux, err := buildWebServerMux(wsc)
+		if err != nil {
+			return fmt.Errorf("web[%q]: %w", hostport, err)
+		}
+
+		if ws, ok := e.webServers[hostport]; ok {
+			ws.handler.set(mux)
+			continue
+		}
+
+		ln, err := net.Listen("tcp", hostport)
+		if err != nil {
+			return fmt.Errorf("web[%q]: listen: %w", hostport, err)
+		}
+		handler := newSwitchableHandler(mux)
+		server := &http.Server{Handler: handler}
+		go func(hostport string, ln net.Listener, server *http.Server) {
+			if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+				e.logger.Error("serve web server exited", zap.String("hostport", hostport), zap.Error(err))
+			}
+		}(hostport, ln, server)
+
+		e.webServers[hostport] = &webServer{ln: ln, server: server, handler: handler}
+	}
+
+	return nil
+}
+
+// closeWebServers closes every serve-config-managed net/http server.
+// Called with e.mu held, from Stop.
+func (e *Engine) closeWebServers() {
+	for hostport, ws := range e.webServers {
+		if err := ws.server.Close(); err != nil {
+			e.logger.Warn("error closing serve web server", zap.String("hostport", hostport), zap.Error(err))
+		}
+	}
+	e.webServers = nil
+}
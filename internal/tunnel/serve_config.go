@@ -0,0 +1,181 @@
+package tunnel
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ServeConfig is a declarative description of extra HTTP and TCP
+// listeners an engine should run alongside its VLESS/Reality front-door,
+// modeled after Tailscale's ipn.ServeConfig: Web maps a local "host:port"
+// to the handlers served there (dispatched by URL path), and TCP maps a
+// local port straight through to a destination with no HTTP involved.
+// Engine.SetServeConfig applies one of these atomically, without
+// restarting the VLESS inbound or dropping its existing sessions.
+type ServeConfig struct {
+	// Web maps "host:port" to the handlers served on that address.
+	Web map[string]*WebServerConfig `yaml:"web,omitempty" json:"web,omitempty"`
+
+	// TCP maps a local port (as a string, e.g. "5432") to a
+	// "host:port" destination forwarded at the raw TCP level.
+	TCP map[string]string `yaml:"tcp,omitempty" json:"tcp,omitempty"`
+}
+
+// WebServerConfig holds the handlers for one Web listen address, keyed by
+// the URL path they're registered under (e.g. "/", "/api/").
+type WebServerConfig struct {
+	Handlers map[string]HandlerConfig `yaml:"handlers" json:"handlers"`
+}
+
+// HandlerConfig is one handler entry; exactly one of Proxy, Text, or Path
+// must be set.
+type HandlerConfig struct {
+	// Proxy reverse-proxies matching requests to an upstream, expanded by
+	// expandProxyArg: a bare port, a "host:port" pair, or a URL (
+	// "http://...", "https://...", or "https+insecure://..." to skip the
+	// upstream's certificate verification).
+	Proxy string `yaml:"proxy,omitempty" json:"proxy,omitempty"`
+
+	// Text serves a fixed plain-text response body.
+	Text string `yaml:"text,omitempty" json:"text,omitempty"`
+
+	// Path serves the directory tree rooted at this local filesystem path.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+}
+
+// Validate checks that every Web and TCP entry is well-formed and that
+// each handler can actually be built, without starting anything.
+func (sc *ServeConfig) Validate() error {
+	for hostport, wsc := range sc.Web {
+		if _, _, err := splitHostPort(hostport); err != nil {
+			return fmt.Errorf("web[%q]: %w", hostport, err)
+		}
+		if wsc == nil || len(wsc.Handlers) == 0 {
+			return fmt.Errorf("web[%q]: at least one handler is required", hostport)
+		}
+		for path, hc := range wsc.Handlers {
+			if _, err := buildHandler(hc); err != nil {
+				return fmt.Errorf("web[%q] handler %q: %w", hostport, path, err)
+			}
+		}
+	}
+
+	for port, dest := range sc.TCP {
+		if _, err := strconv.Atoi(port); err != nil {
+			return fmt.Errorf("tcp[%q]: not a valid port: %w", port, err)
+		}
+		if _, _, err := splitHostPort(dest); err != nil {
+			return fmt.Errorf("tcp[%q]: invalid destination %q: %w", port, dest, err)
+		}
+	}
+
+	return nil
+}
+
+// expandProxyArg expands the shorthand forms a HandlerConfig.Proxy value
+// may take into a full URL: a bare port ("3000"), a "host:port" pair
+// ("localhost:3000"), or a URL with an explicit scheme already supplied
+// ("http://...", "https://...", "https+insecure://..."). Anything else is
+// rejected rather than guessed at.
+func expandProxyArg(arg string) (string, error) {
+	if arg == "" {
+		return "", fmt.Errorf("proxy target must not be empty")
+	}
+	if _, err := strconv.Atoi(arg); err == nil {
+		return "http://127.0.0.1:" + arg, nil
+	}
+	switch {
+	case strings.HasPrefix(arg, "http://"),
+		strings.HasPrefix(arg, "https://"),
+		strings.HasPrefix(arg, "https+insecure://"):
+		return arg, nil
+	case !strings.Contains(arg, "://"):
+		return "http://" + arg, nil
+	default:
+		return "", fmt.Errorf("unsupported proxy target %q", arg)
+	}
+}
+
+// buildHandler builds the http.Handler hc describes, also serving as
+// HandlerConfig's validation: Validate calls it to catch a malformed
+// Proxy target or an ambiguous/empty handler before anything is started.
+func buildHandler(hc HandlerConfig) (http.Handler, error) {
+	set := 0
+	for _, v := range []string{hc.Proxy, hc.Text, hc.Path} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("exactly one of proxy, text, path must be set")
+	}
+
+	switch {
+	case hc.Proxy != "":
+		return buildProxyHandler(hc.Proxy)
+	case hc.Text != "":
+		return buildTextHandler(hc.Text), nil
+	default:
+		return http.FileServer(http.Dir(hc.Path)), nil
+	}
+}
+
+// buildProxyHandler reverse-proxies to proxyArg (after expandProxyArg),
+// skipping the upstream's TLS certificate verification when the caller
+// opted into "https+insecure://" — e.g. an upstream on the loopback
+// interface presenting a self-signed cert.
+func buildProxyHandler(proxyArg string) (http.Handler, error) {
+	target, err := expandProxyArg(proxyArg)
+	if err != nil {
+		return nil, err
+	}
+
+	insecure := false
+	if strings.HasPrefix(target, "https+insecure://") {
+		insecure = true
+		target = "https://" + strings.TrimPrefix(target, "https+insecure://")
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy target %q: %w", target, err)
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	if insecure {
+		proxy.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+	return proxy, nil
+}
+
+func buildTextHandler(text string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(text))
+	})
+}
+
+// buildWebServerMux builds the http.ServeMux a WebServerConfig's Handlers
+// describe. A Path handler is stripped of its registered prefix before
+// hitting http.FileServer, the same way http.StripPrefix is conventionally
+// paired with a sub-path file server; Proxy and Text handlers see the
+// request path unmodified.
+func buildWebServerMux(wsc *WebServerConfig) (http.Handler, error) {
+	mux := http.NewServeMux()
+	for path, hc := range wsc.Handlers {
+		handler, err := buildHandler(hc)
+		if err != nil {
+			return nil, fmt.Errorf("handler %q: %w", path, err)
+		}
+		if hc.Path != "" && path != "/" {
+			handler = http.StripPrefix(strings.TrimSuffix(path, "/"), handler)
+		}
+		mux.Handle(path, handler)
+	}
+	return mux, nil
+}
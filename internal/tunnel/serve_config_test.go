@@ -0,0 +1,228 @@
+package tunnel
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExpandProxyArg(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     string
+		want    string
+		wantErr bool
+	}{
+		{name: "bare port", arg: "3000", want: "http://127.0.0.1:3000"},
+		{name: "host:port", arg: "localhost:3000", want: "http://localhost:3000"},
+		{name: "http url", arg: "http://10.0.0.5:8080", want: "http://10.0.0.5:8080"},
+		{name: "https url", arg: "https://10.0.0.5", want: "https://10.0.0.5"},
+		{name: "https+insecure url", arg: "https+insecure://10.0.0.5", want: "https+insecure://10.0.0.5"},
+		{name: "empty", arg: "", wantErr: true},
+		{name: "unsupported scheme", arg: "ftp://10.0.0.5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandProxyArg(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandProxyArg(%q) error = %v, wantErr %v", tt.arg, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("expandProxyArg(%q) = %q, want %q", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerConfig_ExactlyOneField(t *testing.T) {
+	tests := []struct {
+		name    string
+		hc      HandlerConfig
+		wantErr bool
+	}{
+		{name: "proxy only", hc: HandlerConfig{Proxy: "3000"}, wantErr: false},
+		{name: "text only", hc: HandlerConfig{Text: "hello"}, wantErr: false},
+		{name: "path only", hc: HandlerConfig{Path: "/var/www"}, wantErr: false},
+		{name: "none set", hc: HandlerConfig{}, wantErr: true},
+		{name: "proxy and text", hc: HandlerConfig{Proxy: "3000", Text: "hello"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := buildHandler(tt.hc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("buildHandler(%+v) error = %v, wantErr %v", tt.hc, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServeConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sc      ServeConfig
+		wantErr bool
+	}{
+		{
+			name: "valid web and tcp",
+			sc: ServeConfig{
+				Web: map[string]*WebServerConfig{
+					"127.0.0.1:8080": {Handlers: map[string]HandlerConfig{"/": {Text: "hi"}}},
+				},
+				TCP: map[string]string{"5432": "10.0.0.5:5432"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid web hostport",
+			sc: ServeConfig{
+				Web: map[string]*WebServerConfig{
+					"not-a-hostport": {Handlers: map[string]HandlerConfig{"/": {Text: "hi"}}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "web entry with no handlers",
+			sc: ServeConfig{
+				Web: map[string]*WebServerConfig{
+					"127.0.0.1:8080": {},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "tcp invalid port",
+			sc:      ServeConfig{TCP: map[string]string{"not-a-port": "10.0.0.5:5432"}},
+			wantErr: true,
+		},
+		{
+			name:    "tcp invalid destination",
+			sc:      ServeConfig{TCP: map[string]string{"5432": "not-a-destination"}},
+			wantErr: true,
+		},
+		{
+			name:    "empty config",
+			sc:      ServeConfig{},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.sc.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildWebServerMux_TextHandler(t *testing.T) {
+	mux, err := buildWebServerMux(&WebServerConfig{
+		Handlers: map[string]HandlerConfig{"/": {Text: "hello from entropy-tunnel"}},
+	})
+	if err != nil {
+		t.Fatalf("buildWebServerMux() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Body.String() != "hello from entropy-tunnel" {
+		t.Errorf("body = %q, want %q", rec.Body.String(), "hello from entropy-tunnel")
+	}
+}
+
+func TestBuildServerJSONWithServe_AddsTCPForward(t *testing.T) {
+	cfg := &Config{
+		Listen: ":443", Protocol: "vless", UUID: "test-uuid",
+		Reality: RealityConfig{SNI: "www.google.com", PrivateKey: "key"},
+	}
+	sc := &ServeConfig{TCP: map[string]string{"5432": "10.0.0.5:5432"}}
+
+	jsonCfg, err := BuildServerJSONWithServe(cfg, sc)
+	if err != nil {
+		t.Fatalf("BuildServerJSONWithServe() error = %v", err)
+	}
+
+	var parsed xrayFullConfig
+	if err := json.Unmarshal(jsonCfg, &parsed); err != nil {
+		t.Fatalf("invalid JSON config: %v", err)
+	}
+
+	found := false
+	for _, in := range parsed.Inbounds {
+		if in.Protocol == "dokodemo-door" && in.Port == 5432 {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected a dokodemo-door inbound on port 5432")
+	}
+}
+
+func TestEngineSetServeConfig(t *testing.T) {
+	cfg := &Config{
+		Listen: ":443", Protocol: "vless", UUID: "test-uuid",
+		Reality: RealityConfig{SNI: "www.google.com", PrivateKey: "key"},
+	}
+	engine, _ := NewEngine(cfg, nil)
+	if err := engine.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer engine.Stop()
+
+	sc := &ServeConfig{TCP: map[string]string{"5432": "10.0.0.5:5432"}}
+	if err := engine.SetServeConfig(sc); err != nil {
+		t.Fatalf("SetServeConfig() error = %v", err)
+	}
+
+	if engine.ServeConfig() != sc {
+		t.Error("ServeConfig() did not return the config just applied")
+	}
+
+	var parsed xrayFullConfig
+	if err := json.Unmarshal(engine.JSONConfig(), &parsed); err != nil {
+		t.Fatalf("invalid JSON config after SetServeConfig: %v", err)
+	}
+	found := false
+	for _, in := range parsed.Inbounds {
+		if in.Protocol == "dokodemo-door" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected SetServeConfig to add a dokodemo-door inbound to the running engine")
+	}
+}
+
+func TestEngineSetServeConfig_WrongMode(t *testing.T) {
+	cfg := &ClientConfig{Server: "1.2.3.4:443", UUID: "u", SNI: "g.com", PublicKey: "pk"}
+	engine, _ := NewClientEngine(cfg, nil)
+
+	if err := engine.SetServeConfig(&ServeConfig{}); err == nil {
+		t.Error("expected error calling SetServeConfig on a client-mode engine")
+	}
+}
+
+func TestEngineSetServeConfig_WebServerServesTraffic(t *testing.T) {
+	cfg := &Config{
+		Listen: ":443", Protocol: "vless", UUID: "test-uuid",
+		Reality: RealityConfig{SNI: "www.google.com", PrivateKey: "key"},
+	}
+	engine, _ := NewEngine(cfg, nil)
+	if err := engine.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer engine.Stop()
+
+	sc := &ServeConfig{
+		Web: map[string]*WebServerConfig{
+			"127.0.0.1:0": {Handlers: map[string]HandlerConfig{"/": {Text: "ok"}}},
+		},
+	}
+	if err := engine.SetServeConfig(sc); err != nil {
+		t.Fatalf("SetServeConfig() error = %v", err)
+	}
+
+	if len(engine.webServers) != 1 {
+		t.Fatalf("expected 1 running web server, got %d", len(engine.webServers))
+	}
+}
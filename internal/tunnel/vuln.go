@@ -0,0 +1,218 @@
+package tunnel
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// unknownXrayVersion marks a build that can't report which xray-core
+// version it linked (the stub loader, or a loader that hasn't been taught
+// to report one yet). CheckVulnerabilities treats it as "can't verify",
+// not "clean".
+const unknownXrayVersion = "unknown"
+
+//go:generate go run ./vulngen -module github.com/xtls/xray-core -out vulndb.json
+
+// embeddedVulnDB is the vulnerability corpus vulngen last produced from
+// govulncheck, baked into the binary so Start's preflight works offline.
+// Operators who need a fresher corpus without a rebuild can call
+// LoadVulnDBFile instead, the same escape hatch camouflage.LoadCorpusFile
+// gives the JA3/JA4 corpus.
+//
+//go:embed vulndb.json
+var embeddedVulnDB []byte
+
+var (
+	vulnDBMu sync.RWMutex
+	vulnDB   = mustParseVulnDB(embeddedVulnDB)
+)
+
+// VulnerabilityFinding is one known-exploitable issue in a linked
+// component, as reported by the vulnerability preflight.
+type VulnerabilityFinding struct {
+	CVE              string   `json:"cve"`
+	Component        string   `json:"component"`
+	AffectedVersions string   `json:"affected_versions"` // e.g. "< 1.8.4"
+	FixedIn          string   `json:"fixed_in"`
+	Severity         Severity `json:"severity"`
+	Summary          string   `json:"summary"`
+}
+
+// VulnerabilityReport is the result of CheckVulnerabilities.
+type VulnerabilityReport struct {
+	XrayVersion string                 `json:"xray_version"`
+	Findings    []VulnerabilityFinding `json:"findings"`
+}
+
+// HasCritical reports whether any finding is SeverityCritical — the bar
+// Engine.Start uses to refuse to run without --allow-vulnerable.
+func (r *VulnerabilityReport) HasCritical() bool {
+	for _, f := range r.Findings {
+		if f.Severity == SeverityCritical {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary renders the critical findings as a short comma-joined string,
+// for use in the error Start returns when it refuses to run.
+func (r *VulnerabilityReport) Summary() string {
+	var parts []string
+	for _, f := range r.Findings {
+		if f.Severity == SeverityCritical {
+			parts = append(parts, fmt.Sprintf("%s: %s", f.CVE, f.Summary))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// String renders the report as human-readable lines, one finding per
+// line, for CLI and log output.
+func (r *VulnerabilityReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "xray-core version: %s\n", r.XrayVersion)
+	for _, f := range r.Findings {
+		if f.CVE != "" {
+			fmt.Fprintf(&b, "[%s] %s (%s): %s\n", strings.ToUpper(string(f.Severity)), f.CVE, f.AffectedVersions, f.Summary)
+		} else {
+			fmt.Fprintf(&b, "[%s] %s\n", strings.ToUpper(string(f.Severity)), f.Summary)
+		}
+	}
+	if len(r.Findings) == 0 {
+		b.WriteString("no known vulnerabilities\n")
+	}
+	return b.String()
+}
+
+// CheckVulnerabilities compares xrayVersion against the embedded (or
+// LoadVulnDBFile-replaced) corpus and returns every entry whose affected
+// range includes it. If xrayVersion is unknown, it returns a single
+// warning finding instead of silently reporting a clean build — a loader
+// that can't report its version isn't evidence it's safe.
+func CheckVulnerabilities(xrayVersion string) (*VulnerabilityReport, error) {
+	report := &VulnerabilityReport{XrayVersion: xrayVersion}
+
+	if xrayVersion == "" || xrayVersion == unknownXrayVersion {
+		report.Findings = append(report.Findings, VulnerabilityFinding{
+			Component: "xray-core",
+			Severity:  SeverityWarning,
+			Summary:   "linked xray-core version could not be determined; skipping CVE comparison",
+		})
+		return report, nil
+	}
+
+	vulnDBMu.RLock()
+	defer vulnDBMu.RUnlock()
+
+	for _, f := range vulnDB {
+		if f.Component != "xray-core" {
+			continue
+		}
+		affected, err := versionAffected(xrayVersion, f.AffectedVersions)
+		if err != nil {
+			return nil, fmt.Errorf("evaluate %s against %q: %w", f.CVE, f.AffectedVersions, err)
+		}
+		if affected {
+			report.Findings = append(report.Findings, f)
+		}
+	}
+	return report, nil
+}
+
+// LoadVulnDBFile replaces the in-memory vulnerability corpus with the
+// contents of a JSON file (the same shape vulngen writes), so operators
+// can pick up newly disclosed CVEs between releases without a rebuild.
+func LoadVulnDBFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read vulnerability corpus %s: %w", path, err)
+	}
+	loaded, err := parseVulnDB(data)
+	if err != nil {
+		return fmt.Errorf("parse vulnerability corpus %s: %w", path, err)
+	}
+
+	vulnDBMu.Lock()
+	vulnDB = loaded
+	vulnDBMu.Unlock()
+	return nil
+}
+
+// ResetVulnDB restores the bundled default corpus, mainly useful for
+// tests that call LoadVulnDBFile.
+func ResetVulnDB() {
+	vulnDBMu.Lock()
+	vulnDB = mustParseVulnDB(embeddedVulnDB)
+	vulnDBMu.Unlock()
+}
+
+func parseVulnDB(data []byte) ([]VulnerabilityFinding, error) {
+	var findings []VulnerabilityFinding
+	if err := json.Unmarshal(data, &findings); err != nil {
+		return nil, err
+	}
+	return findings, nil
+}
+
+func mustParseVulnDB(data []byte) []VulnerabilityFinding {
+	findings, err := parseVulnDB(data)
+	if err != nil {
+		panic(fmt.Sprintf("tunnel: embedded vulndb.json is invalid: %v", err))
+	}
+	return findings
+}
+
+// versionAffected reports whether version satisfies constraint, a
+// govulncheck-style range string. Only the "< X.Y.Z" form vulngen emits
+// is supported, which is all the embedded corpus needs — xray-core CVEs
+// are fixed going forward, not re-introduced in a later release.
+func versionAffected(version, constraint string) (bool, error) {
+	constraint = strings.TrimSpace(constraint)
+	bound, ok := strings.CutPrefix(constraint, "<")
+	if !ok {
+		return false, fmt.Errorf("unsupported constraint syntax %q", constraint)
+	}
+	return versionLess(version, strings.TrimSpace(bound)), nil
+}
+
+// versionLess compares dotted version strings like "1.8.4" or "v1.8.4"
+// (xray-core tags use both forms). Missing or non-numeric trailing
+// components (e.g. a "-rc1" suffix) compare as zero, which is good enough
+// for plain MAJOR.MINOR.PATCH release comparisons.
+func versionLess(a, b string) bool {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < 3; i++ {
+		var av, bv int
+		if i < len(pa) {
+			av = pa[i]
+		}
+		if i < len(pb) {
+			bv = pb[i]
+		}
+		if av != bv {
+			return av < bv
+		}
+	}
+	return false
+}
+
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, _, _ = strings.Cut(v, "-") // drop any -rc/-beta suffix
+	parts := strings.Split(v, ".")
+	out := make([]int, 0, len(parts))
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			break
+		}
+		out = append(out, n)
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+package tunnel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckVulnerabilities_UnknownVersion(t *testing.T) {
+	report, err := CheckVulnerabilities(unknownXrayVersion)
+	if err != nil {
+		t.Fatalf("CheckVulnerabilities() error = %v", err)
+	}
+	if report.HasCritical() {
+		t.Error("an unknown version should never be reported as critical")
+	}
+	if len(report.Findings) != 1 || report.Findings[0].Severity != SeverityWarning {
+		t.Errorf("expected a single warning finding for an unknown version, got %+v", report.Findings)
+	}
+}
+
+func TestCheckVulnerabilities_AffectedVersion(t *testing.T) {
+	report, err := CheckVulnerabilities("1.8.0")
+	if err != nil {
+		t.Fatalf("CheckVulnerabilities() error = %v", err)
+	}
+	if !report.HasCritical() {
+		t.Errorf("expected 1.8.0 to hit the CVE-2023-41089 critical finding, got %+v", report.Findings)
+	}
+}
+
+func TestCheckVulnerabilities_FixedVersion(t *testing.T) {
+	report, err := CheckVulnerabilities("1.8.99")
+	if err != nil {
+		t.Fatalf("CheckVulnerabilities() error = %v", err)
+	}
+	if report.HasCritical() {
+		t.Errorf("expected a version past every fixed_in to have no critical findings, got %+v", report.Findings)
+	}
+}
+
+func TestLoadVulnDBFile(t *testing.T) {
+	t.Cleanup(ResetVulnDB)
+
+	path := filepath.Join(t.TempDir(), "vulndb.json")
+	custom := `[{"cve":"CVE-9999-0001","component":"xray-core","affected_versions":"< 2.0.0","fixed_in":"2.0.0","severity":"critical","summary":"test entry"}]`
+	if err := os.WriteFile(path, []byte(custom), 0o644); err != nil {
+		t.Fatalf("write test corpus: %v", err)
+	}
+
+	if err := LoadVulnDBFile(path); err != nil {
+		t.Fatalf("LoadVulnDBFile() error = %v", err)
+	}
+
+	report, err := CheckVulnerabilities("1.0.0")
+	if err != nil {
+		t.Fatalf("CheckVulnerabilities() error = %v", err)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].CVE != "CVE-9999-0001" {
+		t.Errorf("expected the loaded corpus to replace the default, got %+v", report.Findings)
+	}
+}
+
+func TestEngine_Start_RefusesCriticalVulnerability(t *testing.T) {
+	origFunc := xrayVersionFunc
+	xrayVersionFunc = func() string { return "1.0.0" }
+	t.Cleanup(func() { xrayVersionFunc = origFunc })
+
+	cfg := &Config{
+		Listen: ":443", Protocol: "vless", UUID: "test-uuid",
+		Reality: RealityConfig{SNI: "www.google.com", PrivateKey: "key"},
+	}
+	e, err := NewEngine(cfg, nil)
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+
+	if err := e.Start(); err == nil {
+		t.Fatal("expected Start() to refuse a critically vulnerable xray-core version")
+	}
+	if e.Status() != StatusError {
+		t.Errorf("Status() = %v, want %v", e.Status(), StatusError)
+	}
+
+	e.SetAllowVulnerable(true)
+	if err := e.Start(); err != nil {
+		t.Errorf("Start() with AllowVulnerable = true should succeed, got %v", err)
+	}
+}
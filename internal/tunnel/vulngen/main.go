@@ -0,0 +1,155 @@
+// Command vulngen regenerates internal/tunnel/vulndb.json from
+// govulncheck's newline-delimited JSON output, filtered down to the
+// module entropy-tunnel actually cares about (xray-core). Run it with:
+//
+//	govulncheck -json ./... | go run ./vulngen -out vulndb.json
+//
+// This tree has no network access to govulncheck's vulnerability
+// database, so vulndb.json here is a checked-in snapshot rather than
+// something regenerated on every build — the same tradeoff
+// camouflage.LoadCorpusFile makes for the JA3/JA4 corpus: ship a good
+// default, let operators refresh it out of band.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// osvMessage is the subset of a govulncheck -json "osv" message this tool
+// reads. See golang.org/x/vuln's OSV schema for the full shape.
+type osvMessage struct {
+	OSV *struct {
+		ID       string `json:"id"`
+		Summary  string `json:"summary"`
+		Affected []struct {
+			Package struct {
+				Name string `json:"name"`
+			} `json:"package"`
+			Ranges []struct {
+				Events []struct {
+					Introduced string `json:"introduced,omitempty"`
+					Fixed      string `json:"fixed,omitempty"`
+				} `json:"events"`
+			} `json:"ranges"`
+		} `json:"affected"`
+	} `json:"osv"`
+}
+
+// finding mirrors tunnel.VulnerabilityFinding's JSON shape; vulngen has no
+// dependency on the tunnel package so it can be built and run standalone.
+type finding struct {
+	CVE              string `json:"cve"`
+	Component        string `json:"component"`
+	AffectedVersions string `json:"affected_versions"`
+	FixedIn          string `json:"fixed_in"`
+	Severity         string `json:"severity"`
+	Summary          string `json:"summary"`
+}
+
+func main() {
+	in := flag.String("in", "-", "path to govulncheck -json output, or - for stdin")
+	out := flag.String("out", "vulndb.json", "path to write the filtered vulnerability corpus")
+	module := flag.String("module", "github.com/xtls/xray-core", "module name to keep findings for")
+	severity := flag.String("default-severity", "high", "severity to assign findings (govulncheck doesn't itself grade severity)")
+	flag.Parse()
+
+	r, err := openInput(*in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "vulngen: %v\n", err)
+		os.Exit(1)
+	}
+	defer r.Close()
+
+	findings, err := extractFindings(r, *module, *severity)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "vulngen: %v\n", err)
+		os.Exit(1)
+	}
+
+	data, err := json.MarshalIndent(findings, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "vulngen: marshal findings: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, append(data, '\n'), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "vulngen: write %s: %v\n", *out, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "vulngen: wrote %d finding(s) to %s\n", len(findings), *out)
+}
+
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
+
+// extractFindings reads govulncheck's newline-delimited JSON messages,
+// keeps the "osv" ones affecting module, and collapses each into the
+// "< fixed_version" range tunnel.CheckVulnerabilities expects.
+func extractFindings(r io.Reader, module, defaultSeverity string) ([]finding, error) {
+	var out []finding
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var msg osvMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			return nil, fmt.Errorf("parse govulncheck message: %w", err)
+		}
+		if msg.OSV == nil {
+			continue
+		}
+
+		for _, a := range msg.OSV.Affected {
+			if a.Package.Name != module {
+				continue
+			}
+			fixed := latestFixedVersion(a.Ranges)
+			if fixed == "" {
+				continue
+			}
+			out = append(out, finding{
+				CVE:              msg.OSV.ID,
+				Component:        shortComponentName(module),
+				AffectedVersions: "< " + fixed,
+				FixedIn:          fixed,
+				Severity:         defaultSeverity,
+				Summary:          msg.OSV.Summary,
+			})
+		}
+	}
+	return out, scanner.Err()
+}
+
+func latestFixedVersion(ranges []struct {
+	Events []struct {
+		Introduced string `json:"introduced,omitempty"`
+		Fixed      string `json:"fixed,omitempty"`
+	} `json:"events"`
+}) string {
+	for _, rng := range ranges {
+		for _, ev := range rng.Events {
+			if ev.Fixed != "" {
+				return ev.Fixed
+			}
+		}
+	}
+	return ""
+}
+
+func shortComponentName(module string) string {
+	parts := strings.Split(module, "/")
+	return parts[len(parts)-1]
+}
@@ -3,74 +3,370 @@
 package tunnel
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
 )
 
 func init() {
 	defaultLoader = execLoader
 }
 
-// execLoader shells out to the system xray binary.
+const (
+	execMaxRestarts   = 10
+	execBaseBackoff   = 1 * time.Second
+	execMaxBackoff    = 30 * time.Second
+	execShutdownGrace = 5 * time.Second
+	execReadinessWait = 10 * time.Second
+	execLogRingSize   = 200
+)
+
+// execLoader shells out to the system xray binary, supervising it instead
+// of just firing it off: it restarts on crash with exponential backoff up
+// to execMaxRestarts, captures stdout/stderr into a bounded ring buffer
+// queryable via Instance.RecentLogs(), and probes the configured inbound
+// port before Start returns so callers know xray is actually accepting
+// connections rather than merely forked.
 func execLoader(jsonCfg []byte) (XrayInstance, error) {
-	// Write config to temp file
-	tmpDir := os.TempDir()
-	configPath := filepath.Join(tmpDir, "entropy-xray-config.json")
-	
+	xrayPath, err := findXrayBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := inboundPortFromConfig(jsonCfg)
+	if err != nil {
+		return nil, fmt.Errorf("determine inbound port for readiness probe: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "entropy-xray-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir for xray config: %w", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
 	if err := os.WriteFile(configPath, jsonCfg, 0600); err != nil {
-		return nil, fmt.Errorf("failed to write temp config: %w", err)
+		os.RemoveAll(tmpDir)
+		return nil, fmt.Errorf("write temp config: %w", err)
 	}
-	
-	return &execXrayInstance{configPath: configPath}, nil
+
+	return &execXrayInstance{
+		xrayPath:    xrayPath,
+		configPath:  configPath,
+		tmpDir:      tmpDir,
+		port:        port,
+		maxRestarts: execMaxRestarts,
+		stopCh:      make(chan struct{}),
+		logs:        newLogRing(execLogRingSize),
+	}, nil
 }
 
+// execXrayInstance supervises a real `xray run` child process.
 type execXrayInstance struct {
-	configPath string
-	cmd        *exec.Cmd
+	xrayPath    string
+	configPath  string
+	tmpDir      string
+	port        int
+	maxRestarts int
+
+	mu       sync.Mutex
+	cmd      *exec.Cmd
+	restarts int
+	eventBus *BootstrapEventBus
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+	logs     *logRing
+}
+
+// SetEventBus wires the bus execXrayInstance publishes EventXrayProcessCrashed
+// to. Engine.Start calls this on any instance implementing EventBusSetter.
+func (e *execXrayInstance) SetEventBus(bus *BootstrapEventBus) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.eventBus = bus
+}
+
+// RecentLogs returns the most recent lines captured from xray's stdout and
+// stderr, oldest first, for surfacing in crash diagnostics.
+func (e *execXrayInstance) RecentLogs() []string {
+	return e.logs.lines()
 }
 
 func (e *execXrayInstance) Start() error {
-	// Find xray binary
-	xrayPath, err := exec.LookPath("xray")
+	if err := e.spawn(); err != nil {
+		os.RemoveAll(e.tmpDir)
+		return err
+	}
+
+	if err := e.waitReady(execReadinessWait); err != nil {
+		e.kill()
+		os.RemoveAll(e.tmpDir)
+		return fmt.Errorf("xray did not become ready: %w", err)
+	}
+
+	e.wg.Add(1)
+	go e.supervise()
+
+	return nil
+}
+
+// spawn starts a fresh xray process and begins draining its stdio into the
+// ring buffer. It replaces e.cmd, so it's also used on restart.
+func (e *execXrayInstance) spawn() error {
+	cmd := exec.Command(e.xrayPath, "run", "-config", e.configPath)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("attach xray stdout: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
 	if err != nil {
-		// Try common locations
-		candidates := []string{
-			"/opt/homebrew/bin/xray",
-			"/usr/local/bin/xray",
-			"/usr/bin/xray",
+		return fmt.Errorf("attach xray stderr: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start xray: %w", err)
+	}
+
+	go e.logs.drain(stdout)
+	go e.logs.drain(stderr)
+
+	e.mu.Lock()
+	e.cmd = cmd
+	e.mu.Unlock()
+
+	return nil
+}
+
+// waitReady polls the configured inbound port until it accepts a TCP
+// connection, the process exits, or timeout elapses.
+func (e *execXrayInstance) waitReady(timeout time.Duration) error {
+	addr := fmt.Sprintf("127.0.0.1:%d", e.port)
+	deadline := time.Now().Add(timeout)
+
+	for {
+		if conn, err := net.DialTimeout("tcp", addr, 500*time.Millisecond); err == nil {
+			conn.Close()
+			return nil
+		}
+
+		e.mu.Lock()
+		cmd := e.cmd
+		e.mu.Unlock()
+		if cmd != nil && cmd.ProcessState != nil {
+			return fmt.Errorf("xray process exited before port %d became reachable", e.port)
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for port %d", timeout, e.port)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// supervise waits on the current child process and, unless Close has
+// already signalled stopCh, restarts it with exponential backoff up to
+// maxRestarts, publishing an EventXrayProcessCrashed on each crash.
+func (e *execXrayInstance) supervise() {
+	defer e.wg.Done()
+
+	backoff := execBaseBackoff
+	for {
+		e.mu.Lock()
+		cmd := e.cmd
+		e.mu.Unlock()
+		if cmd == nil {
+			return
+		}
+
+		waitErr := cmd.Wait()
+
+		select {
+		case <-e.stopCh:
+			return
+		default:
 		}
-		for _, candidate := range candidates {
-			if _, err := os.Stat(candidate); err == nil {
-				xrayPath = candidate
-				break
-			}
+
+		e.mu.Lock()
+		e.restarts++
+		restarts := e.restarts
+		e.mu.Unlock()
+
+		gaveUp := restarts > e.maxRestarts
+		e.publishCrash(restarts, gaveUp, waitErr)
+		if gaveUp {
+			return
+		}
+
+		select {
+		case <-e.stopCh:
+			return
+		case <-time.After(backoff):
 		}
-		if xrayPath == "" {
-			return fmt.Errorf("xray binary not found in PATH or common locations")
+		backoff *= 2
+		if backoff > execMaxBackoff {
+			backoff = execMaxBackoff
+		}
+
+		if err := e.spawn(); err != nil {
+			e.publishCrash(restarts, true, err)
+			return
 		}
 	}
-	
-	e.cmd = exec.Command(xrayPath, "run", "-config", e.configPath)
-	e.cmd.Stdout = os.Stdout
-	e.cmd.Stderr = os.Stderr
-	
-	if err := e.cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start xray: %w", err)
+}
+
+func (e *execXrayInstance) publishCrash(restarts int, gaveUp bool, cause error) {
+	e.mu.Lock()
+	bus := e.eventBus
+	e.mu.Unlock()
+	if bus == nil {
+		return
 	}
-	
-	return nil
+
+	var msg string
+	if cause != nil {
+		msg = cause.Error()
+	}
+	bus.Publish("xray", EventXrayProcessCrashed, XrayProcessCrashedData{
+		Restarts: restarts,
+		GaveUp:   gaveUp,
+		Err:      msg,
+	})
 }
 
+// kill force-kills the current child without going through the graceful
+// SIGTERM path, for use when Start fails before supervise is running.
+func (e *execXrayInstance) kill() {
+	e.mu.Lock()
+	cmd := e.cmd
+	e.mu.Unlock()
+	if cmd != nil && cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+}
+
+// Close signals the child to shut down gracefully (SIGTERM, then SIGKILL
+// after execShutdownGrace), stops the supervisor from restarting it, and
+// removes the temp config dir regardless of how far Start got.
 func (e *execXrayInstance) Close() error {
-	if e.cmd != nil && e.cmd.Process != nil {
-		// Try graceful shutdown first
-		e.cmd.Process.Signal(os.Interrupt)
-		// Give it a moment to cleanup
-		// In production, you'd want proper process management
-	}
-	// Clean up temp config
-	os.Remove(e.configPath)
+	defer os.RemoveAll(e.tmpDir)
+
+	close(e.stopCh)
+
+	e.mu.Lock()
+	cmd := e.cmd
+	e.mu.Unlock()
+	if cmd == nil || cmd.Process == nil {
+		return nil
+	}
+
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("signal xray to stop: %w", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(execShutdownGrace):
+		_ = cmd.Process.Kill()
+		<-done
+	}
+
 	return nil
 }
+
+// findXrayBinary locates the xray-core executable in PATH or one of the
+// common install locations used by the project's deployment scripts.
+func findXrayBinary() (string, error) {
+	if path, err := exec.LookPath("xray"); err == nil {
+		return path, nil
+	}
+
+	candidates := []string{
+		"/opt/homebrew/bin/xray",
+		"/usr/local/bin/xray",
+		"/usr/bin/xray",
+	}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("xray binary not found in PATH or common locations")
+}
+
+// inboundPortFromConfig extracts the first inbound's port from a generated
+// xray-core JSON config, for the Start readiness probe.
+func inboundPortFromConfig(jsonCfg []byte) (int, error) {
+	var cfg xrayFullConfig
+	if err := json.Unmarshal(jsonCfg, &cfg); err != nil {
+		return 0, fmt.Errorf("parse xray config: %w", err)
+	}
+	if len(cfg.Inbounds) == 0 {
+		return 0, fmt.Errorf("xray config has no inbounds")
+	}
+	return cfg.Inbounds[0].Port, nil
+}
+
+// logRing is a small bounded ring buffer of recent process output lines,
+// queried via Instance.RecentLogs() for crash diagnostics without holding
+// unbounded log history in memory.
+type logRing struct {
+	mu   sync.Mutex
+	buf  []string
+	cap  int
+	next int
+	full bool
+}
+
+func newLogRing(capacity int) *logRing {
+	return &logRing{buf: make([]string, capacity), cap: capacity}
+}
+
+func (r *logRing) add(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buf[r.next] = line
+	r.next = (r.next + 1) % r.cap
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// lines returns the buffered lines in chronological order.
+func (r *logRing) lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		out := make([]string, r.next)
+		copy(out, r.buf[:r.next])
+		return out
+	}
+
+	out := make([]string, r.cap)
+	copy(out, r.buf[r.next:])
+	copy(out[r.cap-r.next:], r.buf[:r.next])
+	return out
+}
+
+func (r *logRing) drain(rc io.ReadCloser) {
+	scanner := bufio.NewScanner(rc)
+	for scanner.Scan() {
+		r.add(scanner.Text())
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 func init() {
 	defaultLoader = realLoader
+	xrayVersionFunc = core.Version
 }
 
 // realLoader uses xray-core library to create a real tunnel instance.
@@ -0,0 +1,181 @@
+package xds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/fabiano/entropy-tunnel/internal/tunnel"
+)
+
+// Client subscribes engine to cp under nodeID and applies each Delta it
+// receives onto a working copy of base, calling engine.Reload with the
+// result. A Delta that produces an invalid config (Validate or
+// BuildServerJSON fails) is NACKed with the error detail and left
+// unapplied, so engine keeps running its last-good config and the
+// control plane can roll the offending version back.
+type Client struct {
+	engine *tunnel.Engine
+	cp     ControlPlane
+	nodeID string
+	logger *zap.Logger
+
+	current *tunnel.Config
+	reality RealityKeyResource
+}
+
+// NewClient creates an xDS subscriber for engine. base is the initial
+// config (typically what the engine was started with); incoming deltas
+// are applied on top of it rather than replacing it wholesale, since a
+// delta only ever describes what changed.
+func NewClient(engine *tunnel.Engine, base *tunnel.Config, cp ControlPlane, nodeID string, logger *zap.Logger) *Client {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	cfgCopy := *base
+	return &Client{
+		engine:  engine,
+		cp:      cp,
+		nodeID:  nodeID,
+		logger:  logger,
+		current: &cfgCopy,
+		reality: RealityKeyResource{PrivateKey: base.Reality.PrivateKey, PublicKey: base.Reality.PublicKey},
+	}
+}
+
+// Run subscribes to cp and applies deltas until ctx is done or the
+// subscription channel closes.
+func (c *Client) Run(ctx context.Context) error {
+	deltas, err := c.cp.Subscribe(ctx, c.nodeID)
+	if err != nil {
+		return fmt.Errorf("subscribe to control plane: %w", err)
+	}
+	defer c.cp.Unsubscribe(c.nodeID)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case delta, ok := <-deltas:
+			if !ok {
+				return nil
+			}
+			c.apply(ctx, delta)
+		}
+	}
+}
+
+// apply merges delta onto c.current, validates and reloads the engine
+// with the result, and ACKs or NACKs accordingly.
+func (c *Client) apply(ctx context.Context, delta Delta) {
+	next := *c.current
+	next.Fallbacks = append([]tunnel.FallbackConfig(nil), c.current.Fallbacks...)
+
+	if err := mergeDelta(&next, &c.reality, delta); err != nil {
+		c.nack(ctx, delta.Nonce, fmt.Errorf("merge delta: %w", err))
+		return
+	}
+	next.Reality.PrivateKey = c.reality.PrivateKey
+	next.Reality.PublicKey = c.reality.PublicKey
+
+	if err := c.engine.Reload(&next); err != nil {
+		c.nack(ctx, delta.Nonce, err)
+		return
+	}
+
+	c.current = &next
+	c.ack(ctx, delta.Nonce)
+}
+
+func (c *Client) ack(ctx context.Context, nonce string) {
+	if err := c.cp.Ack(ctx, AckRequest{NodeID: c.nodeID, Nonce: nonce}); err != nil {
+		c.logger.Warn("xds: failed to ACK applied delta", zap.String("nonce", nonce), zap.Error(err))
+	}
+}
+
+func (c *Client) nack(ctx context.Context, nonce string, cause error) {
+	c.logger.Error("xds: rejecting delta, keeping last-good config",
+		zap.String("nonce", nonce), zap.Error(cause))
+	if err := c.cp.Ack(ctx, AckRequest{NodeID: c.nodeID, Nonce: nonce, ErrorDetail: cause.Error()}); err != nil {
+		c.logger.Warn("xds: failed to NACK rejected delta", zap.String("nonce", nonce), zap.Error(err))
+	}
+}
+
+// mergeDelta applies added/modified/removed resources onto cfg and
+// reality in place.
+func mergeDelta(cfg *tunnel.Config, reality *RealityKeyResource, delta Delta) error {
+	for _, res := range append(append([]Resource{}, delta.Added...), delta.Modified...) {
+		if err := applyResource(cfg, reality, res); err != nil {
+			return err
+		}
+	}
+	for _, key := range delta.Removed {
+		if key.Type == ResourceTypeFallback {
+			cfg.Fallbacks = removeFallback(cfg.Fallbacks, key.Name)
+		}
+	}
+	return nil
+}
+
+func applyResource(cfg *tunnel.Config, reality *RealityKeyResource, res Resource) error {
+	switch res.Type {
+	case ResourceTypeListener:
+		var l ListenerResource
+		if err := json.Unmarshal(res.Payload, &l); err != nil {
+			return fmt.Errorf("unmarshal listener resource: %w", err)
+		}
+		cfg.Listen = l.Listen
+		cfg.Protocol = l.Protocol
+		cfg.UUID = l.UUID
+		cfg.Reality.SNI = l.SNI
+		cfg.Reality.ShortIDs = l.ShortIDs
+		cfg.Fingerprint = l.Fingerprint
+
+	case ResourceTypeFallback:
+		var fb tunnel.FallbackConfig
+		if err := json.Unmarshal(res.Payload, &fb); err != nil {
+			return fmt.Errorf("unmarshal fallback resource %q: %w", res.Name, err)
+		}
+		cfg.Fallbacks = append(removeFallback(cfg.Fallbacks, res.Name), fb)
+
+	case ResourceTypeRealityKey:
+		var rk RealityKeyResource
+		if err := json.Unmarshal(res.Payload, &rk); err != nil {
+			return fmt.Errorf("unmarshal reality key resource: %w", err)
+		}
+		*reality = rk
+
+	case ResourceTypeRotationSchedule:
+		var rc tunnel.RotationConfig
+		if err := json.Unmarshal(res.Payload, &rc); err != nil {
+			return fmt.Errorf("unmarshal rotation schedule resource: %w", err)
+		}
+		cfg.Rotation = rc
+
+	default:
+		return fmt.Errorf("unknown resource type %q", res.Type)
+	}
+	return nil
+}
+
+// removeFallback returns fallbacks with the fallback tagged name (its
+// ResourceTypeFallback Name, matched against the xray config tag
+// "fallback-<protocol>-<index>" convention via Protocol+Listen instead,
+// since FallbackConfig has no name field of its own) dropped. Resources
+// name fallbacks by "<protocol>:<listen>", which applyResource relies on
+// too.
+func removeFallback(fallbacks []tunnel.FallbackConfig, name string) []tunnel.FallbackConfig {
+	out := make([]tunnel.FallbackConfig, 0, len(fallbacks))
+	for _, fb := range fallbacks {
+		if fallbackName(fb) != name {
+			out = append(out, fb)
+		}
+	}
+	return out
+}
+
+func fallbackName(fb tunnel.FallbackConfig) string {
+	return fmt.Sprintf("%s:%s", fb.Protocol, fb.Listen)
+}
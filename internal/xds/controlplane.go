@@ -0,0 +1,153 @@
+package xds
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// ControlPlane is the subscriber-facing half of the xDS subsystem: a
+// node (identified by nodeID) subscribes once and receives a stream of
+// Deltas on the returned channel as the control plane's snapshot changes,
+// ACKing or NACKing each one via Ack. FileControlPlane is the built-in
+// single-node implementation; RPCControlPlane lets nodes subscribe to a
+// remote control plane over the net/rpc transport the rest of this
+// codebase's RPC frontends use (see rotation.RPCFrontend).
+type ControlPlane interface {
+	// Subscribe registers nodeID and returns a channel of Deltas, starting
+	// with a full Added-only Delta for the current snapshot. The channel
+	// is closed when Unsubscribe is called or the control plane shuts
+	// down.
+	Subscribe(ctx context.Context, nodeID string) (<-chan Delta, error)
+
+	// Ack reports whether a subscriber applied a Delta successfully
+	// (ErrorDetail empty) or needs to be rolled back (ErrorDetail set).
+	Ack(ctx context.Context, ack AckRequest) error
+
+	// Unsubscribe stops a node's delta stream.
+	Unsubscribe(nodeID string)
+}
+
+// subscriber tracks one node's delivery state: the last snapshot version
+// it's known to have ACKed, so reconnects and retries compute deltas
+// relative to where that node actually is, not where the control plane
+// is.
+type subscriber struct {
+	nodeID      string
+	ch          chan Delta
+	ackedUpTo   *Snapshot // last snapshot this node ACKed, nil until first ACK
+	pendingSent *Snapshot // snapshot the in-flight (un-ACKed) delta was computed from
+}
+
+// baseControlPlane implements the subscriber bookkeeping, version-nonce
+// accounting, and rollback-on-NACK logic shared by every ControlPlane
+// backend. Backends (FileControlPlane) embed it and call publish when
+// their snapshot changes.
+type baseControlPlane struct {
+	mu      sync.Mutex
+	logger  *zap.Logger
+	current *Snapshot
+	subs    map[string]*subscriber
+	nonce   int
+}
+
+func newBaseControlPlane(logger *zap.Logger) baseControlPlane {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return baseControlPlane{
+		logger: logger,
+		subs:   make(map[string]*subscriber),
+	}
+}
+
+func (b *baseControlPlane) nextNonce() string {
+	b.nonce++
+	return fmt.Sprintf("n%d", b.nonce)
+}
+
+// Subscribe registers nodeID and immediately queues a delta bringing it
+// from nothing to the current snapshot.
+func (b *baseControlPlane) Subscribe(ctx context.Context, nodeID string) (<-chan Delta, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &subscriber{nodeID: nodeID, ch: make(chan Delta, 1)}
+	b.subs[nodeID] = sub
+
+	if b.current != nil {
+		b.sendLocked(sub, b.current)
+	}
+
+	return sub.ch, nil
+}
+
+// Unsubscribe stops nodeID's delta stream and releases its bookkeeping.
+func (b *baseControlPlane) Unsubscribe(nodeID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub, ok := b.subs[nodeID]
+	if !ok {
+		return
+	}
+	close(sub.ch)
+	delete(b.subs, nodeID)
+}
+
+// Ack applies a subscriber's response to its pending delta: an ACK
+// commits pendingSent as ackedUpTo; a NACK logs the failure and leaves
+// ackedUpTo where it was, so the next publish recomputes a delta from the
+// node's last-good version instead of compounding on top of a config it
+// never actually applied.
+func (b *baseControlPlane) Ack(ctx context.Context, ack AckRequest) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub, ok := b.subs[ack.NodeID]
+	if !ok {
+		return fmt.Errorf("unknown subscriber %q", ack.NodeID)
+	}
+
+	if ack.Nacked() {
+		b.logger.Warn("xds subscriber NACKed delta, holding at last-good version",
+			zap.String("node_id", ack.NodeID),
+			zap.String("nonce", ack.Nonce),
+			zap.String("error_detail", ack.ErrorDetail),
+		)
+		return nil
+	}
+
+	sub.ackedUpTo = sub.pendingSent
+	return nil
+}
+
+// publishLocked pushes a delta to every subscriber bringing it from its
+// own ackedUpTo to snapshot, then records it as current. Callers must
+// hold b.mu.
+func (b *baseControlPlane) publishLocked(snapshot *Snapshot) {
+	b.current = snapshot
+	for _, sub := range b.subs {
+		b.sendLocked(sub, snapshot)
+	}
+}
+
+// sendLocked computes and queues the delta bringing sub up to snapshot,
+// dropping a still-pending (un-ACKed) delta in favor of the newer one —
+// the subscriber always converges on the latest snapshot rather than
+// replaying every intermediate version.
+func (b *baseControlPlane) sendLocked(sub *subscriber, snapshot *Snapshot) {
+	delta := diffSnapshots(sub.ackedUpTo, snapshot, b.nextNonce())
+	if delta.Empty() && sub.ackedUpTo != nil {
+		return
+	}
+	sub.pendingSent = snapshot
+
+	select {
+	case <-sub.ch:
+	default:
+	}
+	sub.ch <- *delta
+}
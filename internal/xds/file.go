@@ -0,0 +1,197 @@
+package xds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"github.com/fabiano/entropy-tunnel/internal/rotation"
+)
+
+// FileControlPlane is the single-node ControlPlane backend: it polls a
+// YAML resource file on disk and republishes a snapshot whenever the
+// file's mtime changes, so an operator can roll out config with `scp` +
+// no restart instead of standing up a full gRPC control plane. A
+// CanaryPath can additionally be set so a subset of nodes (canaryNodeIDs)
+// track a separate file; PromoteCanary folds the canary file's contents
+// into the stable one once rotation.HealthChecker reports the canary
+// endpoint healthy, giving the control plane a basic canary-rollout gate.
+type FileControlPlane struct {
+	baseControlPlane
+
+	path         string
+	pollInterval time.Duration
+	lastModTime  time.Time
+	stopCh       chan struct{}
+
+	canaryPath    string
+	canaryNodeIDs map[string]bool
+	health        *rotation.HealthChecker
+	canaryEpID    string
+}
+
+// fileResources is the on-disk shape of a FileControlPlane resource file.
+type fileResources struct {
+	Version   string     `yaml:"version"`
+	Resources []Resource `yaml:"resources"`
+}
+
+// NewFileControlPlane creates a control plane that serves path's resource
+// set and polls it for changes every pollInterval (typically a few
+// seconds; there's no fsnotify dependency in this tree).
+func NewFileControlPlane(path string, pollInterval time.Duration, logger *zap.Logger) *FileControlPlane {
+	return &FileControlPlane{
+		baseControlPlane: newBaseControlPlane(logger),
+		path:             path,
+		pollInterval:     pollInterval,
+		stopCh:           make(chan struct{}),
+		canaryNodeIDs:    make(map[string]bool),
+	}
+}
+
+// SetCanary points a subset of subscribing nodes at a second resource
+// file so their config can be validated on real traffic before the
+// change reaches the fleet. healthEndpointID is the rotation.Endpoint ID
+// health-checked to decide whether the canary is safe to promote.
+func (f *FileControlPlane) SetCanary(canaryPath string, nodeIDs []string, hc *rotation.HealthChecker, healthEndpointID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.canaryPath = canaryPath
+	f.canaryNodeIDs = make(map[string]bool, len(nodeIDs))
+	for _, id := range nodeIDs {
+		f.canaryNodeIDs[id] = true
+	}
+	f.health = hc
+	f.canaryEpID = healthEndpointID
+}
+
+// Subscribe registers nodeID against the stable snapshot, or the canary
+// snapshot if nodeID was named in SetCanary.
+func (f *FileControlPlane) Subscribe(ctx context.Context, nodeID string) (<-chan Delta, error) {
+	f.mu.Lock()
+	isCanary := f.canaryNodeIDs[nodeID]
+	f.mu.Unlock()
+
+	if isCanary {
+		snapshot, err := f.loadSnapshot(f.canaryPath)
+		if err != nil {
+			return nil, fmt.Errorf("load canary resource file: %w", err)
+		}
+		f.mu.Lock()
+		sub := &subscriber{nodeID: nodeID, ch: make(chan Delta, 1)}
+		f.subs[nodeID] = sub
+		f.sendLocked(sub, snapshot)
+		f.mu.Unlock()
+		return sub.ch, nil
+	}
+
+	return f.baseControlPlane.Subscribe(ctx, nodeID)
+}
+
+// Run polls path (and, once SetCanary is called, the canary gate) every
+// pollInterval until ctx is done.
+func (f *FileControlPlane) Run(ctx context.Context) error {
+	if err := f.reload(); err != nil {
+		return fmt.Errorf("initial load of %s: %w", f.path, err)
+	}
+
+	ticker := time.NewTicker(f.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-f.stopCh:
+			return nil
+		case <-ticker.C:
+			if err := f.reload(); err != nil {
+				f.logger.Error("xds: failed to reload resource file", zap.Error(err))
+			}
+			f.maybePromoteCanary()
+		}
+	}
+}
+
+// Stop halts Run's polling loop.
+func (f *FileControlPlane) Stop() {
+	close(f.stopCh)
+}
+
+// reload re-reads path and republishes a new snapshot to stable
+// subscribers if its mtime advanced.
+func (f *FileControlPlane) reload() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	if !info.ModTime().After(f.lastModTime) {
+		return nil
+	}
+
+	snapshot, err := f.loadSnapshot(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	f.lastModTime = info.ModTime()
+	f.publishLocked(snapshot)
+	f.mu.Unlock()
+	return nil
+}
+
+// loadSnapshot reads and parses a resource file into a Snapshot.
+func (f *FileControlPlane) loadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var parsed fileResources
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("parse resource file %s: %w", path, err)
+	}
+	return NewSnapshot(parsed.Version, parsed.Resources), nil
+}
+
+// maybePromoteCanary copies the canary resource file over the stable one
+// once the canary endpoint is reporting healthy, so the rest of the fleet
+// picks up the same change the canary already validated. It's a no-op
+// until SetCanary has been called.
+func (f *FileControlPlane) maybePromoteCanary() {
+	f.mu.Lock()
+	canaryPath, health, epID := f.canaryPath, f.health, f.canaryEpID
+	f.mu.Unlock()
+
+	if canaryPath == "" || health == nil {
+		return
+	}
+
+	result, ok := health.Results()[epID]
+	if !ok || !result.Healthy {
+		return
+	}
+
+	data, err := os.ReadFile(canaryPath)
+	if err != nil {
+		f.logger.Error("xds: failed to read canary resource file for promotion", zap.Error(err))
+		return
+	}
+	if err := os.WriteFile(f.path, data, 0644); err != nil {
+		f.logger.Error("xds: failed to promote canary resource file", zap.Error(err))
+		return
+	}
+
+	f.logger.Info("xds: promoted healthy canary to stable", zap.String("endpoint_id", epID))
+
+	f.mu.Lock()
+	f.canaryPath = ""
+	f.health = nil
+	f.canaryNodeIDs = make(map[string]bool)
+	f.mu.Unlock()
+}
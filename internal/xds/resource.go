@@ -0,0 +1,159 @@
+// Package xds implements an Envoy/Istio-style incremental config
+// distribution subsystem for entropy-tunnel server fleets: instead of
+// loading a static YAML file, a tunnel.Engine can subscribe to a central
+// ControlPlane over a long-lived connection and receive Added/Modified/
+// Removed resource deltas (endpoints, fallbacks, Reality keys, rotation
+// schedule), each tagged with a version nonce the subscriber ACKs once
+// applied or NACKs with an error detail so the control plane can roll
+// back a bad push.
+package xds
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ResourceType identifies which slice of tunnel.Config a Resource updates.
+type ResourceType string
+
+const (
+	// ResourceTypeListener carries the primary listen address, protocol,
+	// UUID, and Reality SNI/short IDs — payload is a ListenerResource.
+	ResourceTypeListener ResourceType = "listener"
+
+	// ResourceTypeFallback carries one tunnel.FallbackConfig entry.
+	ResourceTypeFallback ResourceType = "fallback"
+
+	// ResourceTypeRealityKey carries a Reality keypair rotation —
+	// payload is a RealityKeyResource.
+	ResourceTypeRealityKey ResourceType = "reality_key"
+
+	// ResourceTypeRotationSchedule carries the endpoint-rotation policy —
+	// payload is a tunnel.RotationConfig.
+	ResourceTypeRotationSchedule ResourceType = "rotation_schedule"
+)
+
+// Resource is one versioned config unit the control plane tracks and
+// diffs. Name scopes uniqueness within a ResourceType (e.g. a fallback's
+// tag, or "primary" for the single listener resource).
+type Resource struct {
+	Type    ResourceType    `json:"type"`
+	Name    string          `json:"name"`
+	Version string          `json:"version"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// ListenerResource is the ResourceTypeListener payload.
+type ListenerResource struct {
+	Listen      string   `json:"listen"`
+	Protocol    string   `json:"protocol"`
+	UUID        string   `json:"uuid"`
+	SNI         string   `json:"sni"`
+	ShortIDs    []string `json:"short_ids"`
+	Fingerprint string   `json:"fingerprint"`
+}
+
+// RealityKeyResource is the ResourceTypeRealityKey payload.
+type RealityKeyResource struct {
+	PrivateKey string `json:"private_key"`
+	PublicKey  string `json:"public_key"`
+}
+
+// resourceKey uniquely identifies a Resource within a Snapshot regardless
+// of its version, for diffing.
+type resourceKey struct {
+	Type ResourceType
+	Name string
+}
+
+// Snapshot is the full resource set a ControlPlane holds for a node group
+// at a point in time, keyed for diffing against the previous snapshot.
+type Snapshot struct {
+	VersionInfo string
+	Resources   map[resourceKey]Resource
+}
+
+// NewSnapshot builds a Snapshot from a flat resource list, tagging it
+// with versionInfo (the nonce sent to subscribers alongside the delta
+// computed from it).
+func NewSnapshot(versionInfo string, resources []Resource) *Snapshot {
+	s := &Snapshot{
+		VersionInfo: versionInfo,
+		Resources:   make(map[resourceKey]Resource, len(resources)),
+	}
+	for _, r := range resources {
+		s.Resources[resourceKey{r.Type, r.Name}] = r
+	}
+	return s
+}
+
+// Delta is an incremental update: resources that are new or changed since
+// the subscriber's last ACKed version, and the names of resources that no
+// longer exist. Nonce identifies this exact push for the Ack/Nack
+// roundtrip; VersionInfo is the snapshot version it was computed from.
+type Delta struct {
+	VersionInfo string
+	Nonce       string
+	Added       []Resource
+	Modified    []Resource
+	Removed     []resourceKey
+}
+
+// Empty reports whether the delta has nothing to apply, which happens
+// when a subscriber is already caught up to the latest snapshot.
+func (d *Delta) Empty() bool {
+	return d == nil || (len(d.Added) == 0 && len(d.Modified) == 0 && len(d.Removed) == 0)
+}
+
+// diffSnapshots computes the incremental delta an up-to-date subscriber
+// of prev needs to catch up to next. prev may be nil for a subscriber's
+// first delta, in which case every resource in next is Added.
+func diffSnapshots(prev, next *Snapshot, nonce string) *Delta {
+	d := &Delta{VersionInfo: next.VersionInfo, Nonce: nonce}
+
+	var prevResources map[resourceKey]Resource
+	if prev != nil {
+		prevResources = prev.Resources
+	}
+
+	for key, res := range next.Resources {
+		old, existed := prevResources[key]
+		switch {
+		case !existed:
+			d.Added = append(d.Added, res)
+		case old.Version != res.Version:
+			d.Modified = append(d.Modified, res)
+		}
+	}
+	for key := range prevResources {
+		if _, stillPresent := next.Resources[key]; !stillPresent {
+			d.Removed = append(d.Removed, key)
+		}
+	}
+
+	return d
+}
+
+// AckRequest is what a subscriber sends back after applying (or failing
+// to apply) a Delta: Nonce echoes the delta it's responding to, and
+// ErrorDetail is empty for an ACK or holds the BuildServerJSON/Validate
+// failure for a NACK, letting the control plane roll the node back to
+// its last-good version.
+type AckRequest struct {
+	NodeID      string `json:"node_id"`
+	Nonce       string `json:"nonce"`
+	ErrorDetail string `json:"error_detail,omitempty"`
+}
+
+// Nacked reports whether this is a NACK (the subscriber failed to apply
+// the delta and is reporting why).
+func (a AckRequest) Nacked() bool {
+	return a.ErrorDetail != ""
+}
+
+func (a AckRequest) String() string {
+	if a.Nacked() {
+		return fmt.Sprintf("NACK node=%s nonce=%s: %s", a.NodeID, a.Nonce, a.ErrorDetail)
+	}
+	return fmt.Sprintf("ACK node=%s nonce=%s", a.NodeID, a.Nonce)
+}
@@ -0,0 +1,171 @@
+package xds
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/rpc"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RPCServer exposes a ControlPlane to remote entropy-server nodes over
+// net/rpc, the same transport rotation.RPCFrontend uses for the rotation
+// control plane — there's no grpc-go dependency in this tree, and
+// net/rpc's call/reply shape maps onto xDS's request/response Delta
+// exchange well enough for the single-control-plane-per-fleet topology
+// this is built for. Pull (long-poll with a timeout) stands in for
+// server-push streaming: a subscriber blocks in Pull until a delta is
+// ready or deadline elapses, then calls again.
+type RPCServer struct {
+	cp       ControlPlane
+	logger   *zap.Logger
+	listener net.Listener
+}
+
+// NewRPCServer wraps cp for RPC access.
+func NewRPCServer(cp ControlPlane, logger *zap.Logger) *RPCServer {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &RPCServer{cp: cp, logger: logger}
+}
+
+// PullArgs are the arguments for RPCServer.Pull.
+type PullArgs struct {
+	NodeID  string
+	Timeout time.Duration
+}
+
+// PullReply is the result of RPCServer.Pull.
+type PullReply struct {
+	Delta Delta
+}
+
+// Pull blocks until a Delta is available for args.NodeID or args.Timeout
+// elapses, in which case it returns an empty Delta (Delta.Empty() true)
+// rather than an error, so the caller's poll loop can just retry.
+func (s *RPCServer) Pull(args PullArgs, reply *PullReply) error {
+	ctx, cancel := context.WithTimeout(context.Background(), args.Timeout)
+	defer cancel()
+
+	deltas, err := s.cp.Subscribe(ctx, args.NodeID)
+	if err != nil {
+		return fmt.Errorf("subscribe %q: %w", args.NodeID, err)
+	}
+
+	select {
+	case delta := <-deltas:
+		reply.Delta = delta
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// AckArgs are the arguments for RPCServer.Ack.
+type AckArgs struct {
+	Ack AckRequest
+}
+
+// Ack forwards a subscriber's ACK/NACK to the underlying control plane.
+func (s *RPCServer) Ack(args AckArgs, _ *struct{}) error {
+	return s.cp.Ack(context.Background(), args.Ack)
+}
+
+// Listen starts serving RPC requests on addr.
+func (s *RPCServer) Listen(addr string) error {
+	if err := rpc.RegisterName("XDS", s); err != nil {
+		return fmt.Errorf("register RPC service: %w", err)
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", addr, err)
+	}
+	s.listener = ln
+
+	s.logger.Info("xds RPC control plane listening", zap.String("addr", addr))
+	go rpc.Accept(ln)
+	return nil
+}
+
+// Close stops serving RPC requests.
+func (s *RPCServer) Close() error {
+	if s.listener != nil {
+		return s.listener.Close()
+	}
+	return nil
+}
+
+// RPCControlPlane is a ControlPlane that talks to a remote RPCServer,
+// letting Client subscribe to a control plane running on another host
+// without changing its API. It polls Pull in a loop rather than holding
+// a live stream, matching net/rpc's synchronous call model.
+type RPCControlPlane struct {
+	client      *rpc.Client
+	pullTimeout time.Duration
+	logger      *zap.Logger
+	stopCh      chan struct{}
+}
+
+// DialRPCControlPlane connects to an RPCServer at addr.
+func DialRPCControlPlane(addr string, pullTimeout time.Duration, logger *zap.Logger) (*RPCControlPlane, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	client, err := rpc.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("dial xds control plane at %s: %w", addr, err)
+	}
+	return &RPCControlPlane{client: client, pullTimeout: pullTimeout, logger: logger, stopCh: make(chan struct{})}, nil
+}
+
+// Subscribe starts a goroutine that repeatedly calls XDS.Pull and
+// forwards non-empty deltas onto the returned channel until ctx is done.
+func (r *RPCControlPlane) Subscribe(ctx context.Context, nodeID string) (<-chan Delta, error) {
+	ch := make(chan Delta, 1)
+
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.stopCh:
+				return
+			default:
+			}
+
+			var reply PullReply
+			args := PullArgs{NodeID: nodeID, Timeout: r.pullTimeout}
+			if err := r.client.Call("XDS.Pull", args, &reply); err != nil {
+				r.logger.Error("xds: RPC pull failed", zap.Error(err))
+				time.Sleep(time.Second)
+				continue
+			}
+			if reply.Delta.Empty() {
+				continue
+			}
+			select {
+			case ch <- reply.Delta:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// Ack forwards node's ACK/NACK to the remote control plane.
+func (r *RPCControlPlane) Ack(ctx context.Context, ack AckRequest) error {
+	return r.client.Call("XDS.Ack", AckArgs{Ack: ack}, &struct{}{})
+}
+
+// Unsubscribe stops this control plane's pull loop and closes the RPC
+// connection.
+func (r *RPCControlPlane) Unsubscribe(nodeID string) {
+	close(r.stopCh)
+	_ = r.client.Close()
+}
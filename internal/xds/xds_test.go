@@ -0,0 +1,208 @@
+package xds
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/fabiano/entropy-tunnel/internal/tunnel"
+)
+
+func mustJSON(t *testing.T, v any) json.RawMessage {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return b
+}
+
+func TestDiffSnapshots_FirstDeltaIsAllAdded(t *testing.T) {
+	fb := Resource{Type: ResourceTypeFallback, Name: "trojan:1", Version: "v1", Payload: mustJSON(t, tunnel.FallbackConfig{Protocol: "trojan"})}
+	next := NewSnapshot("1", []Resource{fb})
+
+	delta := diffSnapshots(nil, next, "n1")
+	if len(delta.Added) != 1 || len(delta.Modified) != 0 || len(delta.Removed) != 0 {
+		t.Fatalf("expected 1 added resource, got %+v", delta)
+	}
+}
+
+func TestDiffSnapshots_ModifiedAndRemoved(t *testing.T) {
+	fbV1 := Resource{Type: ResourceTypeFallback, Name: "trojan:1", Version: "v1"}
+	fbV2 := Resource{Type: ResourceTypeFallback, Name: "trojan:1", Version: "v2"}
+	stale := Resource{Type: ResourceTypeFallback, Name: "trojan:2", Version: "v1"}
+
+	prev := NewSnapshot("1", []Resource{fbV1, stale})
+	next := NewSnapshot("2", []Resource{fbV2})
+
+	delta := diffSnapshots(prev, next, "n2")
+	if len(delta.Added) != 0 {
+		t.Errorf("expected no added resources, got %+v", delta.Added)
+	}
+	if len(delta.Modified) != 1 || delta.Modified[0].Version != "v2" {
+		t.Errorf("expected trojan:1 modified to v2, got %+v", delta.Modified)
+	}
+	if len(delta.Removed) != 1 || delta.Removed[0].Name != "trojan:2" {
+		t.Errorf("expected trojan:2 removed, got %+v", delta.Removed)
+	}
+}
+
+func TestDiffSnapshots_UnchangedIsEmpty(t *testing.T) {
+	fb := Resource{Type: ResourceTypeFallback, Name: "trojan:1", Version: "v1"}
+	prev := NewSnapshot("1", []Resource{fb})
+	next := NewSnapshot("1", []Resource{fb})
+
+	delta := diffSnapshots(prev, next, "n3")
+	if !delta.Empty() {
+		t.Errorf("expected empty delta for unchanged snapshot, got %+v", delta)
+	}
+}
+
+func writeResourceFile(t *testing.T, path, version string, resources []Resource) {
+	t.Helper()
+	data, err := yaml.Marshal(fileResources{Version: version, Resources: resources})
+	if err != nil {
+		t.Fatalf("marshal resource file: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("write resource file: %v", err)
+	}
+}
+
+func TestFileControlPlane_SubscribeAndReload(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "resources.yaml")
+
+	fb := tunnel.FallbackConfig{Protocol: "trojan", Listen: ":8443"}
+	writeResourceFile(t, path, "1", []Resource{
+		{Type: ResourceTypeFallback, Name: fallbackName(fb), Version: "v1", Payload: mustJSON(t, fb)},
+	})
+
+	cp := NewFileControlPlane(path, 20*time.Millisecond, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go cp.Run(ctx)
+	defer cp.Stop()
+
+	deltas, err := cp.Subscribe(ctx, "server-1")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	select {
+	case delta := <-deltas:
+		if len(delta.Added) != 1 {
+			t.Fatalf("expected 1 added resource on first delta, got %+v", delta)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for initial delta")
+	}
+
+	if err := cp.Ack(ctx, AckRequest{NodeID: "server-1", Nonce: "whatever"}); err != nil {
+		t.Fatalf("Ack() error = %v", err)
+	}
+}
+
+func TestClient_ApplyAddsAndRemovesFallback(t *testing.T) {
+	engine, err := tunnel.NewEngine(&tunnel.Config{
+		Listen: ":443", Protocol: "vless", UUID: "u",
+		Reality: tunnel.RealityConfig{SNI: "g.com", PrivateKey: "k"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	if err := engine.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer engine.Stop()
+
+	base := &tunnel.Config{
+		Listen: ":443", Protocol: "vless", UUID: "u",
+		Reality: tunnel.RealityConfig{SNI: "g.com", PrivateKey: "k"},
+	}
+	cp := &fakeControlPlane{}
+	client := NewClient(engine, base, cp, "server-1", nil)
+
+	fb := tunnel.FallbackConfig{Protocol: "trojan", Listen: ":8443"}
+	client.apply(context.Background(), Delta{
+		Nonce: "n1",
+		Added: []Resource{
+			{Type: ResourceTypeFallback, Name: fallbackName(fb), Version: "v1", Payload: mustJSON(t, fb)},
+		},
+	})
+
+	if len(cp.acks) != 1 || cp.acks[0].Nacked() {
+		t.Fatalf("expected a clean ACK, got %+v", cp.acks)
+	}
+	if len(client.current.Fallbacks) != 1 {
+		t.Fatalf("expected 1 fallback after apply, got %d", len(client.current.Fallbacks))
+	}
+
+	client.apply(context.Background(), Delta{
+		Nonce:   "n2",
+		Removed: []resourceKey{{Type: ResourceTypeFallback, Name: fallbackName(fb)}},
+	})
+	if len(client.current.Fallbacks) != 0 {
+		t.Fatalf("expected fallback removed, got %d remaining", len(client.current.Fallbacks))
+	}
+}
+
+func TestClient_ApplyNacksBadListener(t *testing.T) {
+	engine, err := tunnel.NewEngine(&tunnel.Config{
+		Listen: ":443", Protocol: "vless", UUID: "u",
+		Reality: tunnel.RealityConfig{SNI: "g.com", PrivateKey: "k"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	if err := engine.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer engine.Stop()
+
+	base := &tunnel.Config{
+		Listen: ":443", Protocol: "vless", UUID: "u",
+		Reality: tunnel.RealityConfig{SNI: "g.com", PrivateKey: "k"},
+	}
+	cp := &fakeControlPlane{}
+	client := NewClient(engine, base, cp, "server-1", nil)
+
+	// Missing UUID makes the merged config fail Validate.
+	client.apply(context.Background(), Delta{
+		Nonce: "bad",
+		Added: []Resource{
+			{Type: ResourceTypeListener, Name: "primary", Version: "v1", Payload: mustJSON(t, ListenerResource{
+				Listen: ":8443", Protocol: "vless", SNI: "g.com",
+			})},
+		},
+	})
+
+	if len(cp.acks) != 1 || !cp.acks[0].Nacked() {
+		t.Fatalf("expected a NACK for the invalid listener resource, got %+v", cp.acks)
+	}
+}
+
+// fakeControlPlane records Acks for assertions; Client never calls
+// Subscribe directly in these tests (apply is exercised in isolation).
+type fakeControlPlane struct {
+	acks []AckRequest
+}
+
+func (f *fakeControlPlane) Subscribe(ctx context.Context, nodeID string) (<-chan Delta, error) {
+	ch := make(chan Delta)
+	close(ch)
+	return ch, nil
+}
+
+func (f *fakeControlPlane) Ack(ctx context.Context, ack AckRequest) error {
+	f.acks = append(f.acks, ack)
+	return nil
+}
+
+func (f *fakeControlPlane) Unsubscribe(nodeID string) {}